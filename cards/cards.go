@@ -39,6 +39,32 @@ func GameUI(mux *http.ServeMux, registry *CommandRegistry) {
 	mux.HandleFunc("/game/join", joinLobby)
 	mux.HandleFunc("/game/lobby", renderLobbyPage)
 	mux.HandleFunc("/ws/lobby", lobbyWebsocket(registry))
+	WsHub.OnDisconnect(leaveLobby)
+	WsHub.SetHistory("/ws/lobby", 32)
+}
+
+// leaveLobby drops a disconnected player from a lobby that hasn't started
+// yet; the lobby page polls, so the others see the updated list.
+func leaveLobby(room, playerId string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, started := games[room]; started {
+		return
+	}
+	lobby := lobbies[room]
+	if lobby == nil {
+		return
+	}
+	for i, p := range lobby.Players {
+		if p.Id == playerId {
+			log.Printf("player %s left lobby %s", p.Name, room)
+			lobby.Players = append(lobby.Players[:i], lobby.Players[i+1:]...)
+			break
+		}
+	}
+	if len(lobby.Players) == 0 {
+		delete(lobbies, room)
+	}
 }
 
 func lobbyWebsocket(registry *CommandRegistry) func(http.ResponseWriter, *http.Request) {
@@ -434,7 +460,7 @@ func renderGamePage(w http.ResponseWriter, r *http.Request) {
 		Attr("hx-ext", "ws"),
 		Div(
 			Id("game-container"),
-			Attr("ws-connect", fmt.Sprintf("/ws/lobby?room=%s&playerId=%s", room, playerId)),
+			Attr("ws-connect", fmt.Sprintf("/ws/lobby?room=%s&playerId=%s&since=%d%s", room, playerId, WsHub.Seq(room), TokenParam(room, playerId))),
 			joinScreen(room, playerId),
 		))
 	w.Header().Set("Content-Type", "text/html")
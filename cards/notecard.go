@@ -209,6 +209,7 @@ func Notecard(mux *http.ServeMux, registry *CommandRegistry) {
 	})
 	mux.Handle("/notecards/", http.StripPrefix("/notecards/", http.FileServer(http.Dir("notecards"))))
 	mux.HandleFunc("/ws/createNotecard", CreateWebsocket(registry))
+	WsHub.SetHistory("/ws/createNotecard", 64)
 
 	registry.RegisterWebsocket("createNotecard", func(_ string, hub *Hub, data map[string]interface{}) {
 		entry := data["entry"].(string)
@@ -299,7 +300,7 @@ func serveCardThreadPage(w http.ResponseWriter, r *http.Request) {
 
 	page := DefaultLayout(
 		Attr("hx-ext", "ws"),
-		Attr("ws-connect", "/ws/createNotecard?room="+roomId),
+		Attr("ws-connect", fmt.Sprintf("/ws/createNotecard?room=%s&since=%d%s", roomId, WsHub.Seq(roomId), TokenParam(roomId, ""))),
 		Attr("data-theme", "dark"),
 		Style(Raw(`
 			.fade-in.htmx-added {
@@ -0,0 +1,297 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	pb "github.com/n0remac/robot-webrtc/servo"
+)
+
+// --- Key bindings -----------------------------------------------------------
+
+// Bindings maps keyboard data channel keys to actions. It is loaded from a
+// JSON file like:
+//
+//	{
+//	  "keys": {
+//	    "w": {"motors": [-100, -100, 100, 100]},
+//	    "y": {"servo": {"channel": 4, "direction": 1, "speed": 60}},
+//	    "u": {"servo": {"name": "arm.lift", "direction": 1, "speed": 60}},
+//	    "p": {"macro": "wave"}
+//	  },
+//	  "macros": {
+//	    "wave": [
+//	      {"servo": {"channel": 5, "direction": 1, "speed": 90}, "holdMs": 500},
+//	      {"servo": {"channel": 5, "direction": -1, "speed": 90}, "holdMs": 500}
+//	    ]
+//	  }
+//	}
+type Bindings struct {
+	Keys   map[string]Action      `json:"keys"`
+	Macros map[string][]MacroStep `json:"macros"`
+}
+
+// Action is what a key does. Exactly one of Motors, Servo or Macro is set.
+//
+// Motors holds one signed duty (-100..100) per Motorer, in the order the
+// motors were set up: positive is Forward, negative Reverse, zero Stop.
+// Releasing the key stops them all. A servo action moves while the key is
+// held; a macro runs to completion on press.
+type Action struct {
+	Motors []float64    `json:"motors,omitempty"`
+	Servo  *ServoAction `json:"servo,omitempty"`
+	Macro  string       `json:"macro,omitempty"`
+}
+
+// ServoAction moves the servo called Name in the servo server's config, or
+// Channel when Name is empty.
+type ServoAction struct {
+	Channel   int32   `json:"channel"`
+	Name      string  `json:"name,omitempty"`
+	Direction int32   `json:"direction"`
+	Speed     float64 `json:"speed"` // degrees/sec
+}
+
+// MacroStep presses Action, holds it for HoldMs and releases it.
+type MacroStep struct {
+	Action
+	HoldMs int `json:"holdMs"`
+}
+
+// DefaultBindings is the layout the robot shipped with.
+// 4 open claw, 5 turn claw, 6 lift claw, 14 pan camera, 15 tilt camera
+func DefaultBindings() *Bindings {
+	const speed = 60 // degrees per second
+	servo := func(ch, dir int32) Action {
+		return Action{Servo: &ServoAction{Channel: ch, Direction: dir, Speed: speed}}
+	}
+	return &Bindings{
+		Keys: map[string]Action{
+			// Servos:
+			"y": servo(4, +1), // claw open
+			"r": servo(4, -1), // claw close
+			"t": servo(6, +1), // arm up
+			"g": servo(6, -1), // arm down
+			"f": servo(5, +1), // left/right
+			"h": servo(5, -1),
+			"i": servo(15, +1), // camera tilt
+			"k": servo(15, -1),
+			"l": servo(14, -1), // camera pan
+			"j": servo(14, +1),
+
+			// Motors (m1, m2, m3, m4):
+			"w": {Motors: []float64{-100, -100, 100, 100}},
+			"s": {Motors: []float64{100, 100, -100, -100}},
+			"a": {Motors: []float64{100, -100, -100, 100}},
+			"d": {Motors: []float64{-100, 100, 100, -100}},
+		},
+		Macros: map[string][]MacroStep{},
+	}
+}
+
+// LoadBindings reads and validates a bindings file for numMotors motors.
+func LoadBindings(path string, numMotors int) (*Bindings, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var b Bindings
+	if err := json.Unmarshal(raw, &b); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := b.Validate(numMotors); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &b, nil
+}
+
+// Validate checks every key and macro step against the hardware layout.
+func (b *Bindings) Validate(numMotors int) error {
+	for key, a := range b.Keys {
+		if key == "" {
+			return fmt.Errorf("empty key")
+		}
+		if err := b.validateAction(a, numMotors, true); err != nil {
+			return fmt.Errorf("key %q: %w", key, err)
+		}
+	}
+	for name, steps := range b.Macros {
+		if len(steps) == 0 {
+			return fmt.Errorf("macro %q has no steps", name)
+		}
+		for i, st := range steps {
+			if err := b.validateAction(st.Action, numMotors, false); err != nil {
+				return fmt.Errorf("macro %q step %d: %w", name, i, err)
+			}
+			if st.HoldMs < 0 {
+				return fmt.Errorf("macro %q step %d: negative holdMs", name, i)
+			}
+		}
+	}
+	return nil
+}
+
+func (b *Bindings) validateAction(a Action, numMotors int, allowMacro bool) error {
+	set := 0
+	if a.Motors != nil {
+		set++
+		if len(a.Motors) != numMotors {
+			return fmt.Errorf("motors has %d entries, robot has %d motors", len(a.Motors), numMotors)
+		}
+		for _, d := range a.Motors {
+			if d < -100 || d > 100 {
+				return fmt.Errorf("motor duty %v outside -100..100", d)
+			}
+		}
+	}
+	if a.Servo != nil {
+		set++
+		if a.Servo.Name == "" && a.Servo.Channel < 0 {
+			return fmt.Errorf("servo channel %d is negative", a.Servo.Channel)
+		}
+		if a.Servo.Direction != 1 && a.Servo.Direction != -1 {
+			return fmt.Errorf("servo direction must be +1 or -1")
+		}
+		if a.Servo.Speed <= 0 {
+			return fmt.Errorf("servo speed must be positive")
+		}
+	}
+	if a.Macro != "" {
+		set++
+		if !allowMacro {
+			return fmt.Errorf("macros cannot call macros")
+		}
+		if _, ok := b.Macros[a.Macro]; !ok {
+			return fmt.Errorf("unknown macro %q", a.Macro)
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("exactly one of motors, servo or macro must be set")
+	}
+	return nil
+}
+
+// CheckServos reports the first servo action naming a channel or servo the
+// server doesn't have. channels is the server's GetConfig reply.
+func (b *Bindings) CheckServos(channels []*pb.ChannelConfig) error {
+	nums, names := map[int32]bool{}, map[string]bool{}
+	for _, c := range channels {
+		nums[c.Channel] = true
+		if c.Name != "" {
+			names[c.Name] = true
+		}
+	}
+	check := func(a Action) error {
+		switch {
+		case a.Servo == nil:
+			return nil
+		case a.Servo.Name != "":
+			if !names[a.Servo.Name] {
+				return fmt.Errorf("no servo called %q", a.Servo.Name)
+			}
+		case !nums[a.Servo.Channel]:
+			return fmt.Errorf("servo channel %d not configured", a.Servo.Channel)
+		}
+		return nil
+	}
+	for key, a := range b.Keys {
+		if err := check(a); err != nil {
+			return fmt.Errorf("key %q: %w", key, err)
+		}
+	}
+	for name, steps := range b.Macros {
+		for i, st := range steps {
+			if err := check(st.Action); err != nil {
+				return fmt.Errorf("macro %q step %d: %w", name, i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// --- Store & hot reload -----------------------------------------------------
+
+// BindingStore holds the active bindings and swaps them when the file
+// changes. Once SetServos has been given the servo server's channels,
+// bindings are checked against them too.
+type BindingStore struct {
+	mu     sync.RWMutex
+	b      *Bindings
+	servos []*pb.ChannelConfig
+}
+
+func NewBindingStore(b *Bindings) *BindingStore {
+	return &BindingStore{b: b}
+}
+
+func (s *BindingStore) Get() *Bindings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.b
+}
+
+func (s *BindingStore) Set(b *Bindings) {
+	s.mu.Lock()
+	s.b = b
+	s.mu.Unlock()
+}
+
+// SetServos checks the active bindings against the servo server's channels
+// and checks every reload against them from then on.
+func (s *BindingStore) SetServos(channels []*pb.ChannelConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.b.CheckServos(channels); err != nil {
+		return err
+	}
+	s.servos = channels
+	return nil
+}
+
+// check runs b past the servo channels, if SetServos has been called.
+func (s *BindingStore) check(b *Bindings) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.servos == nil {
+		return nil
+	}
+	return b.CheckServos(s.servos)
+}
+
+// Watch polls path every interval and loads it whenever its modification
+// time changes. Invalid files are logged and the previous bindings kept.
+func (s *BindingStore) Watch(path string, numMotors int, interval time.Duration, stop <-chan struct{}) {
+	var last time.Time
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		fi, err := os.Stat(path)
+		if err != nil || fi.ModTime().Equal(last) {
+			continue
+		}
+		last = fi.ModTime()
+		b, err := LoadBindings(path, numMotors)
+		if err == nil {
+			err = s.check(b)
+		}
+		if err != nil {
+			log.Printf("bindings reload failed, keeping previous: %v", err)
+			continue
+		}
+		s.Set(b)
+		log.Printf("bindings reloaded from %s", path)
+	}
+}
+
+// KeyBindings is what Controls consults; cmd/client replaces it from
+// -bindings.
+var KeyBindings = NewBindingStore(DefaultBindings())
@@ -0,0 +1,260 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/n0remac/robot-webrtc/servo"
+	"github.com/pion/webrtc/v4"
+	"google.golang.org/grpc"
+)
+
+// fakeMotor records the last command it was given.
+type fakeMotor struct {
+	mu   sync.Mutex
+	last string
+}
+
+func (m *fakeMotor) Forward(s float64) { m.set(fmt.Sprintf("F%v", s)) }
+func (m *fakeMotor) Reverse(s float64) { m.set(fmt.Sprintf("R%v", s)) }
+func (m *fakeMotor) Stop()             { m.set("S") }
+func (m *fakeMotor) Test(bool)         {}
+
+func (m *fakeMotor) set(s string) {
+	m.mu.Lock()
+	m.last = s
+	m.mu.Unlock()
+}
+
+func (m *fakeMotor) get() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.last
+}
+
+// fakeServos records servo RPCs. Like the real server it refuses to Move a
+// channel that is already moving.
+type fakeServos struct {
+	pb.ControllerClient // RPCs the tests don't use
+
+	mu     sync.Mutex
+	calls  []string
+	moving map[string]bool
+}
+
+func (f *fakeServos) record(s string) {
+	f.mu.Lock()
+	f.calls = append(f.calls, s)
+	f.mu.Unlock()
+}
+
+func (f *fakeServos) log() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+// servoKey is how the calls name a servo: by name if the request has one.
+func servoKey(ch int32, name string) string {
+	if name != "" {
+		return name
+	}
+	return fmt.Sprint(ch)
+}
+
+func (f *fakeServos) Move(_ context.Context, in *pb.MoveRequest, _ ...grpc.CallOption) (*pb.MoveReply, error) {
+	k := servoKey(in.Channel, in.Name)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.moving[k] {
+		f.calls = append(f.calls, fmt.Sprintf("refused move %s", k))
+		return &pb.MoveReply{Ok: false, Err: "already moving"}, nil
+	}
+	if f.moving == nil {
+		f.moving = map[string]bool{}
+	}
+	f.moving[k] = true
+	f.calls = append(f.calls, fmt.Sprintf("move %s %d %v", k, in.Direction, in.Speed))
+	return &pb.MoveReply{Ok: true, Channel: in.Channel}, nil
+}
+
+func (f *fakeServos) Stop(_ context.Context, in *pb.StopRequest, _ ...grpc.CallOption) (*pb.StopReply, error) {
+	k := servoKey(in.Channel, in.Name)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.moving, k)
+	f.calls = append(f.calls, fmt.Sprintf("stop %s", k))
+	return &pb.StopReply{Ok: true}, nil
+}
+
+func (f *fakeServos) GetAngles(context.Context, *pb.GetAnglesRequest, ...grpc.CallOption) (*pb.GetAnglesReply, error) {
+	return &pb.GetAnglesReply{}, nil
+}
+
+func fakeRobot() ([]*fakeMotor, []Motorer) {
+	fakes := []*fakeMotor{{}, {}, {}, {}}
+	motors := make([]Motorer, len(fakes))
+	for i, m := range fakes {
+		motors[i] = m
+	}
+	return fakes, motors
+}
+
+func key(k, action string) webrtc.DataChannelMessage {
+	return webrtc.DataChannelMessage{Data: []byte(fmt.Sprintf(`{"Key":%q,"Action":%q}`, k, action))}
+}
+
+func motorState(fakes []*fakeMotor) string {
+	s := make([]string, len(fakes))
+	for i, m := range fakes {
+		s[i] = m.get()
+	}
+	return strings.Join(s, " ")
+}
+
+func TestDefaultBindingsDrive(t *testing.T) {
+	fakes, motors := fakeRobot()
+	servos := &fakeServos{}
+	handle := ControlsWith(NewBindingStore(DefaultBindings()), motors, servos)
+
+	handle(key("w", "pressed"))
+	if got := motorState(fakes); got != "R100 R100 F100 F100" {
+		t.Errorf("w pressed: %s", got)
+	}
+	handle(key("w", "released"))
+	if got := motorState(fakes); got != "S S S S" {
+		t.Errorf("w released: %s", got)
+	}
+
+	handle(key("y", "pressed"))
+	handle(key("y", "released"))
+	want := []string{"move 4 1 60", "stop 4"}
+	if got := servos.log(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("servo calls %v, want %v", got, want)
+	}
+}
+
+func TestMacro(t *testing.T) {
+	_, motors := fakeRobot()
+	servos := &fakeServos{}
+	b := &Bindings{
+		Keys: map[string]Action{"p": {Macro: "nod"}},
+		Macros: map[string][]MacroStep{"nod": {
+			{Action: Action{Servo: &ServoAction{Channel: 15, Direction: 1, Speed: 90}}},
+			{Action: Action{Servo: &ServoAction{Channel: 15, Direction: -1, Speed: 90}}},
+		}},
+	}
+	if err := b.Validate(4); err != nil {
+		t.Fatal(err)
+	}
+	handle := ControlsWith(NewBindingStore(b), motors, servos)
+	handle(key("p", "pressed"))
+	handle(key("p", "released"))
+
+	want := "[move 15 1 90 stop 15 move 15 -1 90 stop 15]"
+	deadline := time.Now().Add(time.Second)
+	for fmt.Sprint(servos.log()) != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("macro calls %v, want %v", servos.log(), want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	servo := &ServoAction{Channel: 4, Direction: 1, Speed: 60}
+	cases := map[string]Bindings{
+		"short vector":  {Keys: map[string]Action{"w": {Motors: []float64{100, 100}}}},
+		"duty range":    {Keys: map[string]Action{"w": {Motors: []float64{150, 0, 0, 0}}}},
+		"two kinds":     {Keys: map[string]Action{"w": {Motors: []float64{0, 0, 0, 0}, Servo: servo}}},
+		"empty":         {Keys: map[string]Action{"w": {}}},
+		"bad channel":   {Keys: map[string]Action{"y": {Servo: &ServoAction{Channel: -1, Direction: 1, Speed: 60}}}},
+		"bad direction": {Keys: map[string]Action{"y": {Servo: &ServoAction{Channel: 4, Direction: 2, Speed: 60}}}},
+		"unknown macro": {Keys: map[string]Action{"p": {Macro: "nope"}}},
+		"nested macro": {
+			Keys:   map[string]Action{"p": {Macro: "a"}},
+			Macros: map[string][]MacroStep{"a": {{Action: Action{Macro: "a"}}}},
+		},
+	}
+	for name, b := range cases {
+		if err := b.Validate(4); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	if err := DefaultBindings().Validate(4); err != nil {
+		t.Errorf("default bindings: %v", err)
+	}
+}
+
+func TestCheckServos(t *testing.T) {
+	channels := []*pb.ChannelConfig{{Channel: 4, Name: "claw.grip"}, {Channel: 17, Name: "arm2.lift"}}
+	ok := &Bindings{Keys: map[string]Action{
+		"y": {Servo: &ServoAction{Channel: 4, Direction: 1, Speed: 60}},
+		"u": {Servo: &ServoAction{Channel: 17, Direction: 1, Speed: 60}},
+		"i": {Servo: &ServoAction{Name: "arm2.lift", Direction: -1, Speed: 60}},
+	}}
+	if err := ok.Validate(4); err != nil {
+		t.Fatal(err)
+	}
+	if err := ok.CheckServos(channels); err != nil {
+		t.Errorf("second-board channel and name: %v", err)
+	}
+
+	cases := map[string]ServoAction{
+		"unknown channel": {Channel: 5, Direction: 1, Speed: 60},
+		"unknown name":    {Name: "arm.lift", Direction: 1, Speed: 60},
+	}
+	for name, a := range cases {
+		b := &Bindings{Macros: map[string][]MacroStep{"m": {{Action: Action{Servo: &a}}}}}
+		if err := b.CheckServos(channels); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	store := NewBindingStore(DefaultBindings())
+	if err := store.SetServos(channels); err == nil {
+		t.Error("default bindings accepted without channels 5, 6, 14 and 15")
+	}
+}
+
+func TestWatchReloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bindings.json")
+	write := func(s string, mod time.Time) {
+		if err := os.WriteFile(path, []byte(s), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(path, mod, mod)
+	}
+	store := NewBindingStore(DefaultBindings())
+	stop := make(chan struct{})
+	defer close(stop)
+	go store.Watch(path, 4, 10*time.Millisecond, stop)
+
+	now := time.Now()
+	write(`{"keys":{"x":{"motors":[50,50,50,50]}}}`, now)
+	waitFor(t, func() bool { _, ok := store.Get().Keys["x"]; return ok })
+
+	// an invalid file keeps the previous bindings
+	write(`{"keys":{"x":{"motors":[50]}}}`, now.Add(time.Second))
+	time.Sleep(50 * time.Millisecond)
+	if _, ok := store.Get().Keys["x"]; !ok {
+		t.Fatal("invalid reload replaced bindings")
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
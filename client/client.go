@@ -1,7 +1,9 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -16,7 +18,6 @@ import (
 
 	sv "github.com/n0remac/robot-webrtc/servo"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 
 	"github.com/gorilla/websocket"
 	"github.com/pion/rtp"
@@ -45,7 +46,71 @@ var (
 	AudioTrack       *webrtc.TrackLocalStaticRTP
 )
 
-func Setup(server *string, room *string, motors []Motorer, myID string) {
+// ServoAddr is the servo gRPC server Setup dials: host:port,
+// unix:/path/to.sock on the same host, or ServoLocal to run it in this
+// process.
+var ServoAddr = "127.0.0.1:50051"
+
+// ServoLocal is the ServoAddr that runs the servo server inside the robot
+// client, so the robot needs only the one process.
+const ServoLocal = "local"
+
+// ServoFiles and ServoBus are where an in-process servo server keeps its
+// calibration, poses and recordings, and how it opens the I²C buses.
+var (
+	ServoFiles              = sv.DefaultFiles()
+	ServoBus   sv.BusOpener = sv.SysfsBus
+)
+
+// ServoDial is the TLS and token Setup uses with the servo server.
+// cmd/client sets it from the -servo-* flags.
+var ServoDial sv.DialConfig
+
+// MissionAddr is where Setup serves the mission HTTP API; empty turns it
+// off. Keep it on loopback: it drives the robot without any auth.
+var MissionAddr = "127.0.0.1:8091"
+
+// FFmpeg inputs for the camera and microphone. cmd/client -sim swaps them
+// for generated test sources.
+var (
+	VideoInputArgs = []string{
+		"-f", "v4l2",
+		"-framerate", "30",
+		"-video_size", "640x480",
+		"-i", "/dev/video0",
+	}
+	AudioInputArgs = []string{
+		"-f", "alsa",
+		"-ar", "48000",
+		"-ac", "1",
+		"-i", "hw:1,0",
+	}
+	SimVideoInputArgs = []string{
+		"-re", "-f", "lavfi",
+		"-i", "testsrc=size=640x480:rate=30",
+	}
+	SimAudioInputArgs = []string{
+		"-re", "-f", "lavfi",
+		"-i", "sine=frequency=440:sample_rate=48000",
+		"-ac", "1",
+	}
+	// VideoFilter is applied to every video output; the camera is mounted
+	// upside down. cmd/client -sim clears it.
+	VideoFilter = "hflip,vflip"
+)
+
+// videoFilterArgs returns the -vf option for one ffmpeg output.
+func videoFilterArgs() []string {
+	if VideoFilter == "" {
+		return nil
+	}
+	return []string{"-vf", VideoFilter}
+}
+
+// Setup connects the robot to the signalling server. token is the robot's
+// long-lived device credential (see cmd/wstoken); it may be empty when the
+// server runs without auth.
+func Setup(server *string, room *string, motors []Motorer, myID string, token string) {
 	// fetch TURN credentials and build ICE servers
 	serverBase := strings.TrimSuffix(strings.TrimPrefix(*server, "wss://"), "/ws/hub")
 	creds, err := FetchTurnCredentials("https://" + serverBase + "/turn-credentials")
@@ -95,25 +160,99 @@ func Setup(server *string, room *string, motors []Motorer, myID string) {
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 
-	// connect to servo server
-	target := "127.0.0.1:50051"
-	conn, err := grpc.NewClient(
-		target,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
-	defer conn.Close()
-
+	// run the servo server here, or connect to it
+	conn, restartServo, err := connectServo()
 	if err != nil {
-		log.Fatalf("failed to dial servo server: %v", err)
+		log.Fatalf("servo server: %v", err)
 	}
 	defer conn.Close()
 
-	servoClient := sv.NewControllerClient(conn)
+	var servoClient sv.ControllerClient = sv.NewControllerClient(conn)
+	if ServoMoveLease > 0 {
+		// servo Moves stop on their own if this process dies mid-press
+		leaser := NewMoveLeaser(servoClient, ServoMoveLease)
+		go leaser.Run(nil)
+		servoClient = leaser
+	}
+	checkBindings(servoClient)
+
+	// remember motor commands for telemetry, and keep forward motion away
+	// from obstacles
+	tracked := TrackMotors(motors)
+	Safe = NewSafety(tracked, SetupSensors())
+	motors = Safe.Motors()
+	go Safe.Run(nil)
+
+	// encoder speed control and odometry, if SetupRobot found encoders
+	go Odom.Run(nil)
+
+	// stop everything if the operator goes quiet
+	Deadman = NewWatchdog(motors, servoClient, HeartbeatTimeout, nil)
+	go Deadman.Run(nil)
+
+	// analog joystick/gamepad driving
+	Drive = NewDriveController(motors, servoClient, nil)
+	Deadman.OnHalt(Drive.Halt)
+	go Drive.Run(nil)
+
+	// follow the servos so telemetry doesn't have to poll them
+	Servos = NewServoWatcher(servoClient)
+	go Servos.Run(nil)
+
+	// telemetry to every operator
+	Telem = NewTelemetryPublisher(tracked, servoClient)
+	Telem.Distance = Safe.Distance
+	Telem.Safety = Safe.State
+	Telem.Pose = Odom.Pose
+	Telem.Servos = Servos.States
+	Safe.OnVeto(func(SafetyState) { Telem.Changed() })
+	Servos.OnChange(Telem.Changed)
+	go Telem.Run(TelemetryInterval, nil)
+
+	// one operator drives at a time; the rest watch
+	Lease = NewControlLease(LeaseIdleTimeout, nil)
+	Lease.OnHandover(func() { Deadman.Halt("control changed hands") })
+	Lease.OnChange(func(LeaseState) { Telem.Changed() })
+	Telem.Control = Lease.State
+	go Lease.Run(nil)
+
+	// restart the servo server or the signalling connection if they stay
+	// down, and report how they're doing
+	Health = NewSupervisor(SupervisorFailures, nil,
+		ServoComponent(conn, restartServo),
+		signalComponent(),
+	)
+	Health.OnChange(func([]ComponentHealth) { Telem.Changed() })
+	Telem.Health = Health.State
+	go Health.Run(SupervisorInterval, nil)
+
+	// queued missions, submitted on the "mission" data channel or the
+	// local HTTP API; their progress goes out with telemetry
+	Missions = NewMissionRunner(motors, servoClient)
+	Missions.Pose = Odom.Pose
+	Missions.Safety = Safe.State
+	Missions.OnChange(func(MissionStatus) { Telem.Changed() })
+	Deadman.OnHalt(func() { Missions.Pause() })
+	Telem.Mission = Missions.Status
+	go Missions.Run(nil)
+	if MissionAddr != "" {
+		go func() {
+			missions := Missions.Handler()
+			mux := http.NewServeMux()
+			mux.Handle("/mission", missions)
+			mux.Handle("/mission/", missions)
+			mux.Handle("/health", Health.Handler())
+			log.Printf("🧭 mission API on http://%s/mission, health on /health", MissionAddr)
+			if err := http.ListenAndServe(MissionAddr, mux); err != nil {
+				log.Printf("mission API: %v", err)
+			}
+		}()
+	}
 
 	// connect and maintain webRTC signalling
 	go func() {
 		for {
-			if err := ConnectAndSignal(api, myID, *room, *server, motors, servoClient); err != nil {
+			if err := ConnectAndSignal(api, myID, *room, *server, token, motors, servoClient); err != nil {
 				log.Printf("Signal loop exited with: %v; retrying in 1s...", err)
 			}
 			time.Sleep(time.Second)
@@ -121,13 +260,9 @@ func Setup(server *string, room *string, motors []Motorer, myID string) {
 	}()
 
 	// start FFmpeg push
-	go RunFFmpegCLI([]string{
-		"-hide_banner", "-loglevel", "warning",
-		"-f", "v4l2",
-		"-framerate", "30",
-		"-video_size", "640x480",
-		"-i", "/dev/video0",
-		"-vf", "hflip,vflip",
+	video := append([]string{"-hide_banner", "-loglevel", "warning"}, VideoInputArgs...)
+	video = append(video, videoFilterArgs()...)
+	video = append(video,
 		"-c:v", "libx264",
 		"-preset", "ultrafast",
 		"-tune", "zerolatency",
@@ -136,19 +271,18 @@ func Setup(server *string, room *string, motors []Motorer, myID string) {
 		"-f", "rtp",
 		"-payload_type", "109",
 		"rtp://127.0.0.1:5004",
-	})
+	)
+	// and keep the latest frame on disk for mission snapshots
+	video = append(video, videoFilterArgs()...)
+	go RunFFmpegCLI(append(video, "-r", "2", "-update", "1", "-y", LatestFramePath))
 
-	go RunFFmpegCLI([]string{
-		"-hide_banner", "-loglevel", "warning",
-		"-f", "alsa",
-		"-ar", "48000",
-		"-ac", "1",
-		"-i", "hw:1,0",
+	audio := append([]string{"-hide_banner", "-loglevel", "warning"}, AudioInputArgs...)
+	go RunFFmpegCLI(append(audio,
 		"-acodec", "libopus",
 		"-f", "rtp",
 		"-payload_type", "111",
 		"rtp://127.0.0.1:5006",
-	})
+	))
 
 	<-sigCh
 	log.Println("Shutting down: sending leave & closing peers...")
@@ -159,6 +293,45 @@ func Setup(server *string, room *string, motors []Motorer, myID string) {
 	PeersMu.Unlock()
 }
 
+// connectServo starts the servo server in this process or dials the remote
+// one, returning the connection and how to restart the server. A remote
+// server is restarted by whatever runs it (systemd); restarting it here
+// just redials it straight away instead of waiting out the backoff.
+func connectServo() (*grpc.ClientConn, func() error, error) {
+	if ServoAddr == ServoLocal {
+		local, err := sv.StartLocal(ServoFiles, ServoBus)
+		if err != nil {
+			return nil, nil, err
+		}
+		log.Println("servo server running in-process")
+		return local.Conn(), local.Restart, nil
+	}
+	dialOpts, err := ServoDial.DialOptions(ServoAddr)
+	if err != nil {
+		return nil, nil, err
+	}
+	conn, err := grpc.NewClient(ServoAddr, dialOpts...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to dial %s: %w", ServoAddr, err)
+	}
+	return conn, func() error { conn.ResetConnectBackoff(); return nil }, nil
+}
+
+// checkBindings holds the key bindings to the servo server's channels. A
+// server that can't be reached yet leaves them unchecked.
+func checkBindings(servoClient sv.ControllerClient) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	cfg, err := servoClient.GetConfig(ctx, &sv.GetConfigRequest{})
+	if err != nil {
+		log.Printf("servo config unavailable, bindings not checked against it: %v", err)
+		return
+	}
+	if err := KeyBindings.SetServos(cfg.Channels); err != nil {
+		log.Fatalf("bindings: %v", err)
+	}
+}
+
 // pumpRTP reads RTP packets from addr and writes them into track
 func PumpRTP(addr string, track *webrtc.TrackLocalStaticRTP, payloadType uint8) {
 	log.Printf("▶ pumpRTP listening on %s (payload %d) → track %s", addr, payloadType, track.ID())
@@ -387,8 +560,41 @@ func createPeerConnection(
 	} else {
 		dc.OnOpen(func() {
 			log.Printf("✔︎ Go DataChannel 'keyboard' open")
+			Lease.Join(peerID)
+		})
+		dc.OnMessage(LeaseGate(peerID, Controls(motors, servoClient)))
+	}
+
+	tdc, err := pc.CreateDataChannel("telemetry", nil)
+	if err != nil {
+		log.Printf("CreateDataChannel telemetry error: %v", err)
+	} else {
+		tdc.OnOpen(func() {
+			log.Printf("✔︎ Go DataChannel 'telemetry' open to %s", peerID)
+			Telem.Add(peerID, tdc, pc)
+		})
+		tdc.OnClose(func() {
+			Telem.Remove(peerID)
+		})
+	}
+
+	mdc, err := pc.CreateDataChannel("mission", nil)
+	if err != nil {
+		log.Printf("CreateDataChannel mission error: %v", err)
+	} else {
+		mdc.OnMessage(func(msg webrtc.DataChannelMessage) {
+			var reply MissionReply
+			var cmd MissionCommand
+			if json.Unmarshal(msg.Data, &cmd) == nil && cmd.Action != "status" && !Lease.Allows(peerID) {
+				reply = MissionReply{Type: "mission", Status: Missions.Status(), Error: "you are not in control"}
+			} else {
+				reply = Missions.HandleMessage(msg.Data)
+			}
+			raw, _ := json.Marshal(reply)
+			if err := mdc.SendText(string(raw)); err != nil {
+				log.Printf("mission: reply to %s: %v", peerID, err)
+			}
 		})
-		dc.OnMessage(Controls(motors, servoClient))
 	}
 
 	pc.OnDataChannel(func(dc *webrtc.DataChannel) {
@@ -470,6 +676,9 @@ func createPeerConnection(
 		})
 		wsWriteMu.Unlock()
 	})
+	pc.OnConnectionStateChange(func(s webrtc.PeerConnectionState) {
+		peerStateChanged(peerID, s)
+	})
 	pc.OnICEConnectionStateChange(func(s webrtc.ICEConnectionState) {
 		if s == webrtc.ICEConnectionStateFailed {
 			restartICE(pc, ws, myID, peerID, room)
@@ -487,6 +696,24 @@ func createPeerConnection(
 	return pc
 }
 
+// peerStateChanged stops the robot when the driver's connection drops and
+// hands the lease on once it's gone for good. A spectator leaving doesn't
+// stop anything, and Disconnected can still recover, so it keeps the lease.
+func peerStateChanged(peerID string, s webrtc.PeerConnectionState) {
+	switch s {
+	case webrtc.PeerConnectionStateDisconnected,
+		webrtc.PeerConnectionStateFailed,
+		webrtc.PeerConnectionStateClosed:
+		Telem.Remove(peerID)
+		if Lease.Allows(peerID) {
+			Deadman.Halt("peer " + peerID + " " + s.String())
+		}
+		if s != webrtc.PeerConnectionStateDisconnected {
+			Lease.Leave(peerID)
+		}
+	}
+}
+
 // helpers for pointers
 func ptrString(s string) *string { return &s }
 func ptrUint16(u uint16) *uint16 { return &u }
@@ -524,13 +751,28 @@ func restartICE(pc *webrtc.PeerConnection, ws *websocket.Conn, myID, peerID, roo
 }
 
 // connectAndSignal manages WebSocket signalling (with auto-reconnect)
-func ConnectAndSignal(api *webrtc.API, myID, room, wsURL string, motors []Motorer, servoClient sv.ControllerClient) error {
+func ConnectAndSignal(api *webrtc.API, myID, room, wsURL, token string, motors []Motorer, servoClient sv.ControllerClient) error {
 	// dial
-	ws, _, err := websocket.DefaultDialer.Dial(fmt.Sprintf("%s?room=%s&playerId=robot", wsURL, room), nil)
+	header := http.Header{}
+	if token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	ws, resp, err := websocket.DefaultDialer.Dial(fmt.Sprintf("%s?room=%s&playerId=robot", wsURL, room), header)
 	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusUnauthorized {
+			return fmt.Errorf("signalling server rejected device token: %w", err)
+		}
 		return err
 	}
 	defer ws.Close()
+	setSignalConn(ws)
+	defer setSignalConn(nil)
+	ws.SetPongHandler(func(string) error {
+		signalMu.Lock()
+		signalPong = time.Now()
+		signalMu.Unlock()
+		return nil
+	})
 
 	// send join
 	wsWriteMu.Lock()
@@ -546,6 +788,53 @@ func ConnectAndSignal(api *webrtc.API, myID, room, wsURL string, motors []Motore
 	}
 }
 
+// SignalPongTimeout is how long the signalling connection may go without
+// answering a ping before the supervisor counts it as down.
+var SignalPongTimeout = 3 * SupervisorInterval
+
+// the live signalling connection, for the supervisor
+var (
+	signalMu   sync.Mutex
+	signalWS   *websocket.Conn
+	signalPong time.Time // last pong, or when it connected
+)
+
+func setSignalConn(ws *websocket.Conn) {
+	signalMu.Lock()
+	signalWS, signalPong = ws, time.Now()
+	signalMu.Unlock()
+}
+
+// signalComponent pings the signalling server and, when the connection has
+// gone quiet, closes it so ConnectAndSignal's caller dials a new one.
+func signalComponent() Component {
+	return Component{
+		Name: "signalling",
+		Check: func(ctx context.Context) error {
+			signalMu.Lock()
+			ws, pong := signalWS, signalPong
+			signalMu.Unlock()
+			if ws == nil {
+				return errors.New("not connected")
+			}
+			if quiet := time.Since(pong); quiet > SignalPongTimeout {
+				return fmt.Errorf("no pong for %v", quiet.Round(time.Second))
+			}
+			return ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(time.Second))
+		},
+		Restart: func() error {
+			signalMu.Lock()
+			ws := signalWS
+			signalMu.Unlock()
+			if ws == nil {
+				// already redialling
+				return nil
+			}
+			return ws.Close()
+		},
+	}
+}
+
 // fetchTurnCredentials GETs the TURN credentials JSON
 func FetchTurnCredentials(url string) (*turnCreds, error) {
 	resp, err := http.Get(url)
@@ -0,0 +1,272 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	pb "github.com/n0remac/robot-webrtc/servo"
+)
+
+// --- Analog drive -----------------------------------------------------------
+
+// DriveCommand is the continuous control message on the keyboard channel:
+//
+//	{"action":"drive","linear":0.5,"angular":-0.2,"arm":0,"pan":0,"tilt":0.3}
+//
+// Every axis is -1..1. Linear is forward, angular is counter-clockwise
+// (left), and arm/pan/tilt drive their servos at a speed proportional to the
+// deflection. Key messages keep working alongside it.
+type DriveCommand struct {
+	Linear  float64 `json:"linear"`
+	Angular float64 `json:"angular"`
+	Arm     float64 `json:"arm"`
+	Pan     float64 `json:"pan"`
+	Tilt    float64 `json:"tilt"`
+}
+
+// Mixer turns linear/angular velocity into per-wheel duty. Each entry is a
+// wheel's contribution (in the Motorer order) for full forward or full
+// left turn; the defaults match the w and a key bindings.
+type Mixer struct {
+	Forward []float64
+	Turn    []float64
+}
+
+var DefaultMixer = Mixer{
+	Forward: []float64{-1, -1, 1, 1},
+	Turn:    []float64{1, -1, -1, 1},
+}
+
+// Mix returns a duty in -100..100 per wheel. If any wheel would saturate,
+// all of them are scaled down together so the robot keeps its heading.
+func (mx Mixer) Mix(linear, angular float64) []float64 {
+	linear, angular = clampUnit(linear), clampUnit(angular)
+	duty := make([]float64, len(mx.Forward))
+	peak := 1.0
+	for i := range duty {
+		duty[i] = linear*mx.Forward[i] + angular*mx.Turn[i]
+		peak = math.Max(peak, math.Abs(duty[i]))
+	}
+	for i := range duty {
+		duty[i] = duty[i] / peak * 100
+	}
+	return duty
+}
+
+// Drive tuning.
+var (
+	DriveTick          = 20 * time.Millisecond
+	MinCommandInterval = 20 * time.Millisecond // faster commands wait their turn, except stops
+	MaxAcceleration    = 250.0                 // duty per second
+	MaxServoSpeed      = 90.0                  // degrees per second at full deflection
+	AxisDeadzone       = 0.05
+)
+
+// Servos driven by the analog axes, by their names in the servo server's
+// config.
+var (
+	ArmServo  = "arm.lift"
+	PanServo  = "camera.pan"
+	TiltServo = "camera.tilt"
+)
+
+// DriveController ramps the wheels towards the latest DriveCommand.
+type DriveController struct {
+	motors      []Motorer
+	servoClient pb.ControllerClient
+	mixer       Mixer
+	clock       Clock
+
+	mu      sync.Mutex
+	target  []float64
+	current []float64
+	lastCmd time.Time
+	pending *DriveCommand      // newest command held back by the rate limiter
+	axes    map[string]float64 // servo speed last sent per servo
+}
+
+func NewDriveController(motors []Motorer, servoClient pb.ControllerClient, clock Clock) *DriveController {
+	if clock == nil {
+		clock = realClock{}
+	}
+	return &DriveController{
+		motors:      motors,
+		servoClient: servoClient,
+		mixer:       DefaultMixer,
+		clock:       clock,
+		target:      make([]float64, len(motors)),
+		current:     make([]float64, len(motors)),
+		axes:        map[string]float64{},
+	}
+}
+
+// Drive is the robot's analog controller, set up by Setup. Like Deadman its
+// methods do nothing on nil.
+var Drive *DriveController
+
+// Command sets a new target. A command that comes within
+// MinCommandInterval of the last one is held and applied once the interval
+// is up, replacing any held before it, so the newest command always wins;
+// Command then reports false.
+func (d *DriveController) Command(cmd DriveCommand) bool {
+	if d == nil {
+		return false
+	}
+	now := d.clock.Now()
+	stop := cmd == DriveCommand{}
+
+	d.mu.Lock()
+	if !stop && now.Sub(d.lastCmd) < MinCommandInterval {
+		d.pending = &cmd
+		d.mu.Unlock()
+		return false
+	}
+	d.pending = nil
+	d.mu.Unlock()
+	d.apply(cmd, now)
+	return true
+}
+
+// apply makes cmd the target and moves the servo axes.
+func (d *DriveController) apply(cmd DriveCommand, now time.Time) {
+	d.mu.Lock()
+	d.lastCmd = now
+	copy(d.target, d.mixer.Mix(cmd.Linear, cmd.Angular))
+	d.mu.Unlock()
+
+	d.axis(ArmServo, cmd.Arm)
+	d.axis(PanServo, cmd.Pan)
+	d.axis(TiltServo, cmd.Tilt)
+}
+
+// axis moves a servo at a speed proportional to v, only calling the RPC
+// when the speed actually changes. The server refuses to Move a servo
+// that's already moving, so a change of speed or direction stops it first.
+func (d *DriveController) axis(name string, v float64) {
+	v = clampUnit(v)
+	if math.Abs(v) < AxisDeadzone {
+		v = 0
+	}
+	speed := math.Round(v * MaxServoSpeed)
+
+	d.mu.Lock()
+	prev := d.axes[name]
+	d.axes[name] = speed
+	d.mu.Unlock()
+	if speed == prev || d.servoClient == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if prev != 0 {
+		stop, err := d.servoClient.Stop(ctx, &pb.StopRequest{Name: name})
+		if err == nil && !stop.GetOk() {
+			err = errors.New(stop.GetErr())
+		}
+		if err != nil {
+			d.failed(name, err)
+			return
+		}
+	}
+	if speed == 0 {
+		return
+	}
+	dir := int32(1)
+	if speed < 0 {
+		dir = -1
+	}
+	mv, err := d.servoClient.Move(ctx, &pb.MoveRequest{Name: name, Direction: dir, Speed: math.Abs(speed)})
+	if err == nil && !mv.GetOk() {
+		err = errors.New(mv.GetErr())
+	}
+	if err != nil {
+		d.failed(name, err)
+	}
+}
+
+// failed logs a servo RPC that didn't take and marks the axis unknown
+// (NaN matches no speed), so the next command stops it and tries again.
+func (d *DriveController) failed(name string, err error) {
+	log.Printf("drive: servo %s: %v", name, err)
+	d.mu.Lock()
+	d.axes[name] = math.NaN()
+	d.mu.Unlock()
+}
+
+// Halt drops the target and the ramp to zero at once. The watchdog calls it
+// so a stale command can't ramp the wheels back up after a stop.
+func (d *DriveController) Halt() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	d.pending = nil
+	for i := range d.target {
+		d.target[i] = 0
+		d.current[i] = 0
+	}
+	for ch := range d.axes {
+		d.axes[ch] = 0
+	}
+	d.mu.Unlock()
+}
+
+// step applies a held command whose turn has come, advances the ramp by dt
+// and applies any duty that changed.
+func (d *DriveController) step(dt time.Duration) {
+	maxDelta := MaxAcceleration * dt.Seconds()
+	now := d.clock.Now()
+
+	d.mu.Lock()
+	if d.pending != nil && now.Sub(d.lastCmd) >= MinCommandInterval {
+		cmd := *d.pending
+		d.pending = nil
+		d.mu.Unlock()
+		d.apply(cmd, now)
+		d.mu.Lock()
+	}
+	var changed []int
+	for i := range d.current {
+		diff := d.target[i] - d.current[i]
+		if diff == 0 {
+			continue
+		}
+		d.current[i] += math.Max(-maxDelta, math.Min(maxDelta, diff))
+		changed = append(changed, i)
+	}
+	duty := append([]float64(nil), d.current...)
+	d.mu.Unlock()
+
+	for _, i := range changed {
+		switch {
+		case duty[i] > 0:
+			d.motors[i].Forward(duty[i])
+		case duty[i] < 0:
+			d.motors[i].Reverse(-duty[i])
+		default:
+			d.motors[i].Stop()
+		}
+	}
+}
+
+func (d *DriveController) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(DriveTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			d.step(DriveTick)
+		}
+	}
+}
+
+func clampUnit(v float64) float64 {
+	return math.Max(-1, math.Min(1, v))
+}
@@ -0,0 +1,127 @@
+package client
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestMixMatchesKeyBindings(t *testing.T) {
+	keys := DefaultBindings().Keys
+	cases := map[string][2]float64{"w": {1, 0}, "s": {-1, 0}, "a": {0, 1}, "d": {0, -1}}
+	for k, v := range cases {
+		got := DefaultMixer.Mix(v[0], v[1])
+		for i, want := range keys[k].Motors {
+			if got[i] != want {
+				t.Errorf("%s: mix %v, binding %v", k, got, keys[k].Motors)
+				break
+			}
+		}
+	}
+	// forward + full turn saturates two wheels; everything is scaled together
+	if got := DefaultMixer.Mix(1, 1); got[0] != 0 || got[2] != 0 || got[1] != -100 || got[3] != 100 {
+		t.Errorf("saturated mix %v", got)
+	}
+}
+
+func TestDriveRamps(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	fakes, motors := fakeRobot()
+	d := NewDriveController(motors, nil, clock)
+
+	d.Command(DriveCommand{Linear: 1})
+	// 250 duty/s: 100ms gets a quarter of the way there
+	for i := 0; i < 5; i++ {
+		d.step(20 * time.Millisecond)
+	}
+	if got := motorState(fakes); got != "R25 R25 F25 F25" {
+		t.Fatalf("after 100ms: %s", got)
+	}
+	for i := 0; i < 50; i++ {
+		d.step(20 * time.Millisecond)
+	}
+	if got := motorState(fakes); got != "R100 R100 F100 F100" {
+		t.Fatalf("after ramp: %s", got)
+	}
+
+	d.Halt()
+	d.step(20 * time.Millisecond)
+	if got := motorState(fakes); got != "R100 R100 F100 F100" {
+		t.Errorf("halt should not re-drive motors the watchdog stopped: %s", got)
+	}
+}
+
+func TestDriveRateLimit(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	_, motors := fakeRobot()
+	d := NewDriveController(motors, nil, clock)
+
+	if !d.Command(DriveCommand{Linear: 0.5}) {
+		t.Fatal("first command dropped")
+	}
+	clock.Advance(5 * time.Millisecond)
+	if d.Command(DriveCommand{Linear: 0.6}) {
+		t.Error("burst command accepted")
+	}
+	if d.Command(DriveCommand{Linear: 1}) {
+		t.Error("burst command accepted")
+	}
+	// the last command of a burst is applied once the interval is up
+	clock.Advance(MinCommandInterval)
+	d.step(0)
+	d.mu.Lock()
+	target := append([]float64(nil), d.target...)
+	d.mu.Unlock()
+	if want := DefaultMixer.Mix(1, 0); target[0] != want[0] {
+		t.Errorf("target %v after the burst, want %v", target, want)
+	}
+
+	clock.Advance(5 * time.Millisecond)
+	d.Command(DriveCommand{Linear: 0.6})
+	if !d.Command(DriveCommand{}) {
+		t.Error("stop must never be rate limited")
+	}
+	clock.Advance(MinCommandInterval)
+	d.step(0)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.target[0] != 0 {
+		t.Errorf("a command held before a stop was applied after it: %v", d.target)
+	}
+}
+
+func TestDriveAxes(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	_, motors := fakeRobot()
+	servos := &fakeServos{}
+	d := NewDriveController(motors, servos, clock)
+
+	d.Command(DriveCommand{Tilt: 0.5})
+	clock.Advance(time.Second)
+	d.Command(DriveCommand{Tilt: 0.5, Pan: 0.01}) // unchanged tilt, pan in deadzone
+	clock.Advance(time.Second)
+	d.Command(DriveCommand{Tilt: -1})
+	d.Command(DriveCommand{})
+
+	// the server won't Move a servo that's moving, so reversing stops first
+	want := "[move camera.tilt 1 45 stop camera.tilt move camera.tilt -1 90 stop camera.tilt]"
+	if got := servos.log(); fmt.Sprint(got) != want {
+		t.Errorf("servo calls %v, want %s", got, want)
+	}
+}
+
+func TestDriveAxisRetriesRefusedMove(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	_, motors := fakeRobot()
+	servos := &fakeServos{moving: map[string]bool{"camera.pan": true}} // a key press left it moving
+	d := NewDriveController(motors, servos, clock)
+
+	d.Command(DriveCommand{Pan: 1})
+	clock.Advance(time.Second)
+	d.Command(DriveCommand{Pan: 1})
+
+	want := "[refused move camera.pan stop camera.pan move camera.pan 1 90]"
+	if got := servos.log(); fmt.Sprint(got) != want {
+		t.Errorf("servo calls %v, want %s", got, want)
+	}
+}
@@ -0,0 +1,334 @@
+package client
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+)
+
+// --- Control lease ----------------------------------------------------------
+
+// LeaseIdleTimeout is how long the operator in control can go without
+// driving before the lease passes on. Heartbeats don't count.
+var LeaseIdleTimeout = 2 * time.Minute
+
+// AdminToken lets an operator take or revoke control from anyone. Empty
+// disables the override. cmd/client sets it from -admin-token.
+var AdminToken = ""
+
+// LeaseMessage is a lease request on the keyboard channel:
+//
+//	{"action":"lease","op":"request","name":"alice"}
+//	{"action":"lease","op":"release"}
+//	{"action":"lease","op":"grant","to":"<peer id>"}   holder or admin
+//	{"action":"lease","op":"revoke","token":"..."}     admin only
+//
+// A request with a valid admin token takes control at once.
+type LeaseMessage struct {
+	Action string `json:"action"`
+	Op     string `json:"op"`
+	Name   string `json:"name,omitempty"`
+	To     string `json:"to,omitempty"`
+	Token  string `json:"token,omitempty"`
+}
+
+// LeaseState is what every viewer sees in telemetry.
+type LeaseState struct {
+	Holder     string        `json:"holder,omitempty"` // peer id, empty when nobody drives
+	HolderName string        `json:"holderName,omitempty"`
+	Since      int64         `json:"since,omitempty"` // unix ms
+	Waiting    []LeaseViewer `json:"waiting,omitempty"`
+	Viewers    int           `json:"viewers"`
+}
+
+type LeaseViewer struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// ControlLease lets one operator drive at a time; everyone else watches.
+// The lease is requested, released, handed over or revoked with
+// LeaseMessages, passes to the next in line when its holder goes idle or
+// disconnects, and can be overridden with AdminToken.
+type ControlLease struct {
+	timeout time.Duration
+	clock   Clock
+
+	mu       sync.Mutex
+	viewers  map[string]string // peer id -> display name
+	holder   string
+	since    time.Time
+	active   time.Time // holder's last control message
+	waiting  []string
+	reported string // holder as of the last hook call
+	onChange []func(LeaseState)
+	onHand   []func()
+}
+
+func NewControlLease(timeout time.Duration, clock Clock) *ControlLease {
+	if clock == nil {
+		clock = realClock{}
+	}
+	return &ControlLease{timeout: timeout, clock: clock, viewers: map[string]string{}}
+}
+
+// Lease is the robot's control lease, set up by Setup. A nil lease lets
+// every operator drive, as before.
+var Lease *ControlLease
+
+// OnChange registers fn to run whenever the holder or the queue changes.
+func (l *ControlLease) OnChange(fn func(LeaseState)) {
+	l.mu.Lock()
+	l.onChange = append(l.onChange, fn)
+	l.mu.Unlock()
+}
+
+func (l *ControlLease) State() LeaseState {
+	if l == nil {
+		return LeaseState{}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.state()
+}
+
+// OnHandover registers fn to run whenever the lease changes hands, before
+// the OnChange hooks, so whatever the last operator started can be stopped.
+func (l *ControlLease) OnHandover(fn func()) {
+	l.mu.Lock()
+	l.onHand = append(l.onHand, fn)
+	l.mu.Unlock()
+}
+
+// Join adds a viewer.
+func (l *ControlLease) Join(peerID string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	if _, ok := l.viewers[peerID]; ok {
+		l.mu.Unlock()
+		return
+	}
+	l.viewers[peerID] = ""
+	l.changed()
+}
+
+// Leave drops a viewer, passing the lease on if they held it.
+func (l *ControlLease) Leave(peerID string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	if _, ok := l.viewers[peerID]; !ok {
+		l.mu.Unlock()
+		return
+	}
+	delete(l.viewers, peerID)
+	l.dequeue(peerID)
+	if l.holder == peerID {
+		l.passOn("disconnected")
+	}
+	l.changed()
+}
+
+// Allows reports whether peerID may drive.
+func (l *ControlLease) Allows(peerID string) bool {
+	if l == nil {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.holder == peerID
+}
+
+// Touch records a control message from the holder.
+func (l *ControlLease) Touch(peerID string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	if l.holder == peerID {
+		l.active = l.clock.Now()
+	}
+	l.mu.Unlock()
+}
+
+// Handle applies a lease message from peerID.
+func (l *ControlLease) Handle(peerID string, m LeaseMessage) error {
+	if l == nil {
+		return nil
+	}
+	admin := AdminToken != "" && subtle.ConstantTimeCompare([]byte(m.Token), []byte(AdminToken)) == 1
+
+	l.mu.Lock()
+	if _, ok := l.viewers[peerID]; !ok {
+		l.viewers[peerID] = ""
+	}
+	if m.Name != "" {
+		l.viewers[peerID] = m.Name
+	}
+	var err error
+	switch m.Op {
+	case "request":
+		switch {
+		case l.holder == peerID:
+		case l.holder == "" || admin:
+			l.give(peerID, "requested")
+		default:
+			l.dequeue(peerID)
+			l.waiting = append(l.waiting, peerID)
+		}
+	case "release":
+		l.dequeue(peerID)
+		if l.holder == peerID {
+			l.passOn("released")
+		}
+	case "grant":
+		_, known := l.viewers[m.To]
+		switch {
+		case l.holder != peerID && !admin:
+			err = fmt.Errorf("only the operator in control can hand it over")
+		case !known:
+			err = fmt.Errorf("no viewer %q", m.To)
+		default:
+			l.give(m.To, "granted by "+l.name(peerID))
+		}
+	case "revoke":
+		if !admin {
+			err = fmt.Errorf("revoke needs the admin token")
+		} else if l.holder != "" {
+			l.passOn("revoked by " + l.name(peerID))
+		}
+	default:
+		err = fmt.Errorf("unknown lease op %q", m.Op)
+	}
+	if err != nil {
+		l.mu.Unlock()
+		return err
+	}
+	l.changed()
+	return nil
+}
+
+// Check passes the lease on if its holder has gone idle. It reports whether
+// it did.
+func (l *ControlLease) Check() bool {
+	if l == nil {
+		return false
+	}
+	l.mu.Lock()
+	if l.holder == "" || l.clock.Now().Sub(l.active) <= l.timeout {
+		l.mu.Unlock()
+		return false
+	}
+	l.passOn("idle for " + l.timeout.String())
+	l.changed()
+	return true
+}
+
+func (l *ControlLease) Run(stop <-chan struct{}) {
+	if l == nil {
+		return
+	}
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			l.Check()
+		}
+	}
+}
+
+// give hands the lease to peerID. Callers hold mu.
+func (l *ControlLease) give(peerID, why string) {
+	l.dequeue(peerID)
+	l.holder = peerID
+	l.since = l.clock.Now()
+	l.active = l.since
+	log.Printf("🎮 control: %s (%s)", l.name(peerID), why)
+}
+
+// passOn takes the lease from its holder and gives it to whoever has waited
+// longest. Callers hold mu.
+func (l *ControlLease) passOn(why string) {
+	log.Printf("🎮 control: %s lost it (%s)", l.name(l.holder), why)
+	l.holder = ""
+	if len(l.waiting) > 0 {
+		l.give(l.waiting[0], "next in line")
+	}
+}
+
+func (l *ControlLease) dequeue(peerID string) {
+	for i, id := range l.waiting {
+		if id == peerID {
+			l.waiting = append(l.waiting[:i], l.waiting[i+1:]...)
+			return
+		}
+	}
+}
+
+func (l *ControlLease) name(peerID string) string {
+	if n := l.viewers[peerID]; n != "" {
+		return n
+	}
+	return peerID
+}
+
+func (l *ControlLease) state() LeaseState {
+	st := LeaseState{Holder: l.holder, Viewers: len(l.viewers)}
+	if l.holder != "" {
+		st.HolderName = l.name(l.holder)
+		st.Since = l.since.UnixMilli()
+	}
+	for _, id := range l.waiting {
+		st.Waiting = append(st.Waiting, LeaseViewer{ID: id, Name: l.name(id)})
+	}
+	return st
+}
+
+// changed unlocks mu and tells the hooks.
+func (l *ControlLease) changed() {
+	st, hooks := l.state(), append([]func(LeaseState){}, l.onChange...)
+	var handover []func()
+	if l.holder != l.reported {
+		l.reported = l.holder
+		handover = append(handover, l.onHand...)
+	}
+	l.mu.Unlock()
+	for _, fn := range handover {
+		fn()
+	}
+	for _, fn := range hooks {
+		fn(st)
+	}
+}
+
+// LeaseGate wraps an operator's keyboard handler: lease messages go to
+// Lease, and everything else only gets through while peerID holds it.
+func LeaseGate(peerID string, inner func(msg webrtc.DataChannelMessage)) func(msg webrtc.DataChannelMessage) {
+	return func(msg webrtc.DataChannelMessage) {
+		var m LeaseMessage
+		if err := json.Unmarshal(msg.Data, &m); err == nil && m.Action == "lease" {
+			if err := Lease.Handle(peerID, m); err != nil {
+				log.Printf("lease from %s: %v", peerID, err)
+			}
+			return
+		}
+		if !Lease.Allows(peerID) {
+			return
+		}
+		if m.Action != "heartbeat" {
+			Lease.Touch(peerID)
+		}
+		inner(msg)
+	}
+}
@@ -0,0 +1,152 @@
+package client
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+)
+
+func lease(op string, extra ...string) LeaseMessage {
+	m := LeaseMessage{Action: "lease", Op: op}
+	if len(extra) > 0 {
+		m.To = extra[0]
+	}
+	return m
+}
+
+func TestLeaseRequestQueueAndRelease(t *testing.T) {
+	l := NewControlLease(time.Minute, &fakeClock{now: time.Unix(0, 0)})
+	handovers := 0
+	l.OnHandover(func() { handovers++ })
+	l.Join("a")
+	l.Join("b")
+
+	l.Handle("a", LeaseMessage{Action: "lease", Op: "request", Name: "alice"})
+	l.Handle("b", LeaseMessage{Action: "lease", Op: "request", Name: "bob"})
+	st := l.State()
+	if st.Holder != "a" || st.HolderName != "alice" || len(st.Waiting) != 1 || st.Waiting[0] != (LeaseViewer{"b", "bob"}) || st.Viewers != 2 {
+		t.Fatalf("state %+v", st)
+	}
+	if !l.Allows("a") || l.Allows("b") {
+		t.Error("only alice should drive")
+	}
+
+	if err := l.Handle("b", lease("grant", "b")); err == nil {
+		t.Error("bob can't grant himself control")
+	}
+	l.Handle("a", lease("release"))
+	if st := l.State(); st.Holder != "b" || len(st.Waiting) != 0 {
+		t.Fatalf("release should pass control to bob: %+v", st)
+	}
+	l.Handle("a", lease("request"))
+	l.Handle("b", lease("grant", "a"))
+	if st := l.State(); st.Holder != "a" || len(st.Waiting) != 0 {
+		t.Fatalf("grant: %+v", st)
+	}
+	if handovers != 3 {
+		t.Errorf("%d handovers, want 3", handovers)
+	}
+
+	l.Leave("a")
+	if st := l.State(); st.Holder != "" || st.Viewers != 1 {
+		t.Errorf("the holder leaving should free the lease: %+v", st)
+	}
+}
+
+func TestLeaseIdleTimeout(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	l := NewControlLease(time.Minute, clock)
+	l.Handle("a", lease("request"))
+	l.Handle("b", lease("request"))
+
+	clock.Advance(50 * time.Second)
+	l.Touch("a")
+	clock.Advance(50 * time.Second)
+	if l.Check() {
+		t.Fatal("alice drove 50s ago")
+	}
+	clock.Advance(20 * time.Second)
+	if !l.Check() || l.State().Holder != "b" {
+		t.Fatalf("idle lease should pass to bob: %+v", l.State())
+	}
+}
+
+func TestLeaseAdminOverride(t *testing.T) {
+	defer func() { AdminToken = "" }()
+	AdminToken = "sekrit"
+	l := NewControlLease(time.Minute, nil)
+	l.Handle("a", lease("request"))
+
+	if err := l.Handle("b", LeaseMessage{Op: "revoke", Token: "guess"}); err == nil {
+		t.Error("revoke without the token")
+	}
+	l.Handle("root", LeaseMessage{Op: "request", Token: "sekrit"})
+	if l.State().Holder != "root" {
+		t.Fatalf("admin request should take control: %+v", l.State())
+	}
+	l.Handle("a", lease("request"))
+	l.Handle("root", LeaseMessage{Op: "revoke", Token: "sekrit"})
+	if l.State().Holder != "a" {
+		t.Fatalf("revoke should pass control on: %+v", l.State())
+	}
+}
+
+func TestLeaseGate(t *testing.T) {
+	defer func() { Lease, Deadman = nil, nil }()
+	Lease = NewControlLease(time.Minute, nil)
+	fakes, motors := fakeRobot()
+	inner := ControlsWith(NewBindingStore(DefaultBindings()), motors, &fakeServos{})
+	alice, bob := LeaseGate("a", inner), LeaseGate("b", inner)
+
+	alice(webrtcMsg(`{"action":"lease","op":"request"}`))
+	bob(webrtcMsg(`{"action":"lease","op":"request"}`))
+	bob(key("w", "pressed"))
+	if got := motorState(fakes); strings.Contains(got, "100") {
+		t.Fatalf("spectator moved the robot: %s", got)
+	}
+	alice(key("w", "pressed"))
+	if got := motorState(fakes); got != "R100 R100 F100 F100" {
+		t.Fatalf("operator in control: %s", got)
+	}
+
+	// handing over stops whatever the last operator left running
+	Deadman = NewWatchdog(motors, nil, time.Minute, nil)
+	Deadman.Beat()
+	Lease.OnHandover(func() { Deadman.Halt("control changed hands") })
+	alice(webrtcMsg(`{"action":"lease","op":"grant","to":"b"}`))
+	if got := motorState(fakes); got != "S S S S" {
+		t.Errorf("handover should stop the motors: %s", got)
+	}
+}
+
+func TestPeerStateChanged(t *testing.T) {
+	defer func() { Lease, Deadman = nil, nil }()
+	Lease = NewControlLease(time.Minute, nil)
+	fakes, motors := fakeRobot()
+	Deadman = NewWatchdog(motors, nil, time.Minute, nil)
+	Lease.Join("a")
+	Lease.Join("b")
+	Lease.Handle("a", lease("request"))
+	Deadman.Beat()
+	motors[0].Forward(100)
+
+	peerStateChanged("b", webrtc.PeerConnectionStateClosed)
+	if got := motorState(fakes); !strings.Contains(got, "100") {
+		t.Errorf("a spectator leaving stopped the robot: %s", got)
+	}
+
+	peerStateChanged("a", webrtc.PeerConnectionStateDisconnected)
+	if got := motorState(fakes); strings.Contains(got, "100") {
+		t.Errorf("the driver dropping out should stop the robot: %s", got)
+	}
+	if st := Lease.State(); st.Holder != "a" {
+		t.Errorf("a disconnect that may recover gave up the lease: %+v", st)
+	}
+
+	peerStateChanged("a", webrtc.PeerConnectionStateFailed)
+	if st := Lease.State(); st.Holder != "" {
+		t.Errorf("a failed connection kept the lease: %+v", st)
+	}
+}
@@ -0,0 +1,697 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	pb "github.com/n0remac/robot-webrtc/servo"
+)
+
+// --- Missions ---------------------------------------------------------------
+
+// MissionStep is one primitive of a mission:
+//
+//	{"type":"drive","distance":0.5,"speed":50}       metres, negative backs up
+//	{"type":"turn","angle":90,"speed":40}            degrees, positive is left
+//	{"type":"servo","channel":14,"angle":90,"speed":60}
+//	{"type":"wait","ms":1000}
+//	{"type":"snapshot"}
+//
+// Speed is a percentage of full speed for drive and turn, and degrees per
+// second for servo; 0 means the default.
+type MissionStep struct {
+	Type     string  `json:"type"`
+	Distance float64 `json:"distance,omitempty"`
+	Angle    float64 `json:"angle,omitempty"`
+	Channel  int32   `json:"channel,omitempty"`
+	Speed    float64 `json:"speed,omitempty"`
+	Ms       int     `json:"ms,omitempty"`
+}
+
+type Mission struct {
+	Name  string        `json:"name,omitempty"`
+	Steps []MissionStep `json:"steps"`
+}
+
+// Mission defaults.
+var (
+	MissionTick         = 20 * time.Millisecond
+	MissionDriveSpeed   = 50.0 // percent
+	MissionServoSpeed   = 60.0 // degrees per second
+	ServoAngleTolerance = 1.0  // degrees
+	SnapshotDir         = "snapshots"
+	// LatestFramePath is where the camera ffmpeg keeps overwriting its
+	// newest frame; a snapshot step copies it.
+	LatestFramePath = filepath.Join(os.TempDir(), "robot-frame.jpg")
+)
+
+func (m Mission) Validate() error {
+	if len(m.Steps) == 0 {
+		return errors.New("mission has no steps")
+	}
+	for i, s := range m.Steps {
+		var err error
+		switch s.Type {
+		case "drive":
+			if s.Distance == 0 {
+				err = errors.New("drive needs a distance")
+			}
+		case "turn":
+			if s.Angle == 0 {
+				err = errors.New("turn needs an angle")
+			}
+		case "servo":
+			if s.Channel < 0 || s.Angle < 0 || s.Angle > 180 {
+				err = errors.New("servo needs a channel and an angle 0–180")
+			}
+		case "wait":
+			if s.Ms <= 0 {
+				err = errors.New("wait needs ms > 0")
+			}
+		case "snapshot":
+		default:
+			err = fmt.Errorf("unknown step type %q", s.Type)
+		}
+		if err == nil && (s.Speed < 0 || (s.Type != "servo" && s.Speed > 100)) {
+			err = errors.New("speed out of range")
+		}
+		if err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// MissionStatus is reported to operators in telemetry. State is one of
+// idle, running, paused, blocked (held back by the safety layer), done,
+// aborted or failed.
+type MissionStatus struct {
+	Name      string   `json:"name,omitempty"`
+	State     string   `json:"state"`
+	Step      int      `json:"step"` // 0-based index of the current step
+	Steps     int      `json:"steps"`
+	Progress  float64  `json:"progress"` // of the current step, 0–1
+	Error     string   `json:"error,omitempty"`
+	Snapshots []string `json:"snapshots,omitempty"`
+}
+
+// --- Runner -----------------------------------------------------------------
+
+// MissionRunner executes one mission at a time. It drives the wheels through
+// the same motors as the operator, so the safety layer still vetoes forward
+// motion; while it does, a drive step is reported as blocked and only makes
+// the progress the safety layer lets through. Drive and turn steps use
+// odometry when there is any and dead reckoning from MaxWheelSpeed and
+// TrackWidth when there isn't.
+type MissionRunner struct {
+	motors      []Motorer
+	servoClient pb.ControllerClient
+	mixer       Mixer
+
+	// Pose returns the odometry estimate, if any.
+	Pose func() (Pose, bool)
+	// Safety reports the obstacle layer's state.
+	Safety func() SafetyState
+	// Snapshot saves a camera frame named name and returns its path.
+	Snapshot func(name string) (string, error)
+
+	mu         sync.Mutex
+	mission    Mission
+	status     MissionStatus
+	run        int  // bumped on every start, pause, resume and abort
+	started    bool // the current step has been set up
+	elapsed    time.Duration
+	done       float64 // metres or radians covered; degrees to go for servos
+	lastPose   Pose
+	servoDir   float64 // direction of a servo step's Move, 0 when not moving
+	servoStart time.Duration
+	applied    []float64
+	onChange   func(MissionStatus)
+}
+
+func NewMissionRunner(motors []Motorer, servoClient pb.ControllerClient) *MissionRunner {
+	return &MissionRunner{
+		motors:      motors,
+		servoClient: servoClient,
+		mixer:       DefaultMixer,
+		Snapshot:    SaveSnapshot,
+		status:      MissionStatus{State: "idle"},
+		applied:     make([]float64, len(motors)),
+	}
+}
+
+// Missions is the robot's mission runner, set up by Setup. Its methods do
+// nothing on nil.
+var Missions *MissionRunner
+
+// OnChange is called whenever the state or the current step changes.
+func (r *MissionRunner) OnChange(fn func(MissionStatus)) {
+	r.mu.Lock()
+	r.onChange = fn
+	r.mu.Unlock()
+}
+
+func (r *MissionRunner) Status() MissionStatus {
+	if r == nil {
+		return MissionStatus{State: "idle"}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.copyStatus()
+}
+
+// Submit starts m, unless another mission is still running or paused.
+func (r *MissionRunner) Submit(m Mission) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	if r.active() {
+		err := fmt.Errorf("mission %q is %s; abort it first", r.status.Name, r.status.State)
+		r.mu.Unlock()
+		return err
+	}
+	r.mission = m
+	r.status = MissionStatus{Name: m.Name, State: "running", Steps: len(m.Steps)}
+	r.started = false
+	r.run++
+	log.Printf("🧭 mission %q: %d steps", m.Name, len(m.Steps))
+	r.changed()
+	return nil
+}
+
+// Pause stops the robot where it is. The watchdog calls it too, so a
+// mission doesn't carry on after the operator watching it disappears.
+func (r *MissionRunner) Pause() error {
+	return r.transition("paused", "running", "blocked")
+}
+
+// Resume carries on with the current step. Drive and turn keep the
+// progress they had; a servo step sends its Move again.
+func (r *MissionRunner) Resume() error {
+	r.mu.Lock()
+	r.lastPose, _ = r.pose()
+	r.mu.Unlock()
+	return r.transition("running", "paused")
+}
+
+func (r *MissionRunner) Abort() error {
+	return r.transition("aborted", "running", "blocked", "paused")
+}
+
+// transition moves to state from one of from, stopping everything when the
+// mission stops moving. Callers don't hold mu.
+func (r *MissionRunner) transition(state string, from ...string) error {
+	if r == nil {
+		return errors.New("no mission runner")
+	}
+	r.mu.Lock()
+	ok := false
+	for _, f := range from {
+		ok = ok || r.status.State == f
+	}
+	if !ok {
+		current := r.status.State
+		r.mu.Unlock()
+		return fmt.Errorf("can't go from %s to %s", current, state)
+	}
+	r.status.State = state
+	r.run++
+	if state != "running" {
+		r.halt()
+	}
+	log.Printf("🧭 mission %q %s at step %d", r.status.Name, state, r.status.Step+1)
+	r.changed()
+	return nil
+}
+
+func (r *MissionRunner) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(MissionTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			r.step(MissionTick)
+		}
+	}
+}
+
+// step advances the current mission step by dt. Servo and snapshot steps
+// release mu while they wait on the servo server or the disk, so status and
+// abort aren't held up; if the mission was paused, aborted or replaced in
+// the meantime, their result is dropped.
+func (r *MissionRunner) step(dt time.Duration) {
+	r.mu.Lock()
+	if r.status.State != "running" && r.status.State != "blocked" {
+		r.mu.Unlock()
+		return
+	}
+	run := r.run
+	s := r.mission.Steps[r.status.Step]
+	if !r.started {
+		r.started = true
+		r.elapsed, r.done, r.servoDir = 0, 0, 0
+		r.lastPose, _ = r.pose()
+	}
+	r.elapsed += dt
+
+	var progress float64
+	var err error
+	switch s.Type {
+	case "drive":
+		progress = r.drive(s, dt)
+	case "turn":
+		progress = r.turn(s, dt)
+	case "servo":
+		progress, err = r.servo(s, run)
+	case "wait":
+		progress = r.elapsed.Seconds() * 1000 / float64(s.Ms)
+	case "snapshot":
+		name := fmt.Sprintf("%s-%d-%s.jpg", missionFileName(r.status.Name), r.status.Step+1, time.Now().Format("20060102-150405"))
+		r.mu.Unlock()
+		var path string
+		path, err = r.Snapshot(name)
+		r.mu.Lock()
+		if err == nil && r.run == run {
+			r.status.Snapshots = append(r.status.Snapshots, path)
+			progress = 1
+		}
+	}
+
+	if r.run != run {
+		r.mu.Unlock()
+		return
+	}
+	if err != nil {
+		r.status.State, r.status.Error = "failed", fmt.Sprintf("step %d (%s): %v", r.status.Step+1, s.Type, err)
+		r.halt()
+		log.Printf("🧭 mission %q failed: %s", r.status.Name, r.status.Error)
+		r.changed()
+		return
+	}
+	r.status.Progress = math.Min(progress, 1)
+	if progress < 1 {
+		r.mu.Unlock()
+		return
+	}
+
+	r.halt()
+	r.started = false
+	r.status.Progress = 0
+	r.status.Step++
+	if r.status.State == "blocked" {
+		r.status.State = "running"
+	}
+	if r.status.Step == len(r.mission.Steps) {
+		r.status.Step--
+		r.status.Progress = 1
+		r.status.State = "done"
+		log.Printf("🧭 mission %q done", r.status.Name)
+	}
+	r.changed()
+}
+
+// drive runs a drive step and returns its progress. Callers hold mu.
+func (r *MissionRunner) drive(s MissionStep, dt time.Duration) float64 {
+	speed := orDefaultF(s.Speed, MissionDriveSpeed)
+	dir := math.Copysign(1, s.Distance)
+
+	blocked := false
+	factor := 1.0
+	if dir > 0 && r.Safety != nil {
+		st := r.Safety()
+		factor = st.Factor
+		blocked = st.Vetoed
+	}
+	r.setBlocked(blocked)
+	r.wheels(r.mixer.Mix(dir*speed/100, 0))
+
+	if p, ok := r.pose(); ok {
+		r.done += math.Hypot(p.X-r.lastPose.X, p.Y-r.lastPose.Y)
+		r.lastPose = p
+	} else {
+		r.done += factor * speed / 100 * MaxWheelSpeed * dt.Seconds()
+	}
+	return r.done / math.Abs(s.Distance)
+}
+
+// turn runs a turn step and returns its progress. Callers hold mu.
+func (r *MissionRunner) turn(s MissionStep, dt time.Duration) float64 {
+	speed := orDefaultF(s.Speed, MissionDriveSpeed)
+	dir := math.Copysign(1, s.Angle)
+	r.wheels(r.mixer.Mix(0, dir*speed/100))
+
+	if p, ok := r.pose(); ok {
+		r.done += math.Abs(math.Remainder(p.Theta-r.lastPose.Theta, 2*math.Pi))
+		r.lastPose = p
+	} else {
+		// spinning in place, each side at v: ω = 2v / track
+		r.done += 2 * speed / 100 * MaxWheelSpeed / TrackWidth * dt.Seconds()
+	}
+	return r.done / math.Abs(s.Angle*math.Pi/180)
+}
+
+// servo moves a servo towards s.Angle and returns its progress. Callers
+// hold mu; it's released around each RPC, and the result only counts if
+// the mission is still on run afterwards.
+func (r *MissionRunner) servo(s MissionStep, run int) (float64, error) {
+	if r.servoClient == nil {
+		return 0, errors.New("no servo server")
+	}
+	r.mu.Unlock()
+	angle, err := r.servoAngle(s.Channel)
+	r.mu.Lock()
+	if err != nil || r.run != run {
+		return 0, err
+	}
+
+	speed := orDefaultF(s.Speed, MissionServoSpeed)
+	remaining := s.Angle - angle
+	if r.servoDir == 0 {
+		r.done = math.Abs(remaining)
+		if r.done <= ServoAngleTolerance {
+			return 1, nil
+		}
+		// set before the Move goes out, so a halt meanwhile stops it
+		r.servoDir = math.Copysign(1, remaining)
+		dir := r.servoDir
+		r.mu.Unlock()
+		err := r.moveServo(s.Channel, dir, speed)
+		r.mu.Lock()
+		if r.run != run {
+			// the halt's Stop may have reached the server before the Move
+			r.mu.Unlock()
+			r.stopServo(s.Channel)
+			r.mu.Lock()
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		r.servoStart = r.elapsed
+	}
+
+	// stop on arrival, or once it has gone past
+	if math.Abs(remaining) <= ServoAngleTolerance || remaining*r.servoDir < 0 {
+		r.servoDir = 0
+		r.mu.Unlock()
+		err := r.stopServo(s.Channel)
+		r.mu.Lock()
+		if err != nil {
+			return 0, err
+		}
+		return 1, nil
+	}
+	if r.elapsed-r.servoStart > time.Second+time.Duration(2*r.done/speed*float64(time.Second)) {
+		return 0, fmt.Errorf("servo %d stuck at %.0f°", s.Channel, angle)
+	}
+	return 1 - math.Abs(remaining)/r.done, nil
+}
+
+// servoAngle asks the servo server where channel ch is.
+func (r *MissionRunner) servoAngle(ch int32) (float64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	reply, err := r.servoClient.GetAngles(ctx, &pb.GetAnglesRequest{})
+	if err != nil {
+		return 0, err
+	}
+	for _, a := range reply.GetAngles() {
+		if a.Channel == ch {
+			return float64(a.Angle), nil
+		}
+	}
+	return 0, fmt.Errorf("servo %d not found", ch)
+}
+
+// moveServo starts channel ch moving in dir at speed degrees per second.
+func (r *MissionRunner) moveServo(ch int32, dir, speed float64) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	// the server refuses to move a channel that's already moving
+	r.servoClient.Stop(ctx, &pb.StopRequest{Channel: ch})
+	mv, err := r.servoClient.Move(ctx, &pb.MoveRequest{Channel: ch, Direction: int32(dir), Speed: speed})
+	if err != nil {
+		return err
+	}
+	if !mv.GetOk() {
+		return errors.New(mv.GetErr())
+	}
+	return nil
+}
+
+func (r *MissionRunner) stopServo(ch int32) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_, err := r.servoClient.Stop(ctx, &pb.StopRequest{Channel: ch})
+	return err
+}
+
+// wheels sends duty to any wheel whose duty changed. Callers hold mu.
+func (r *MissionRunner) wheels(duty []float64) {
+	for i, d := range duty {
+		if d == r.applied[i] {
+			continue
+		}
+		r.applied[i] = d
+		switch {
+		case d > 0:
+			r.motors[i].Forward(d)
+		case d < 0:
+			r.motors[i].Reverse(-d)
+		default:
+			r.motors[i].Stop()
+		}
+	}
+}
+
+// halt stops the wheels, and the servo if a servo step was moving it.
+// Callers hold mu.
+func (r *MissionRunner) halt() {
+	for i, d := range r.applied {
+		if d != 0 {
+			r.applied[i] = 0
+			r.motors[i].Stop()
+		}
+	}
+	if r.servoDir != 0 && r.servoClient != nil {
+		r.servoDir = 0
+		ch := r.mission.Steps[r.status.Step].Channel
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		if _, err := r.servoClient.Stop(ctx, &pb.StopRequest{Channel: ch}); err != nil {
+			log.Printf("mission: servo %d Stop RPC error: %v", ch, err)
+		}
+	}
+}
+
+func (r *MissionRunner) setBlocked(blocked bool) {
+	switch {
+	case blocked && r.status.State == "running":
+		r.status.State = "blocked"
+	case !blocked && r.status.State == "blocked":
+		r.status.State = "running"
+	default:
+		return
+	}
+	r.changedLocked()
+}
+
+func (r *MissionRunner) pose() (Pose, bool) {
+	if r.Pose == nil {
+		return Pose{}, false
+	}
+	return r.Pose()
+}
+
+func (r *MissionRunner) active() bool {
+	switch r.status.State {
+	case "running", "blocked", "paused":
+		return true
+	}
+	return false
+}
+
+func (r *MissionRunner) copyStatus() MissionStatus {
+	st := r.status
+	st.Snapshots = append([]string(nil), r.status.Snapshots...)
+	return st
+}
+
+// changed unlocks mu and reports the new status.
+func (r *MissionRunner) changed() {
+	st, fn := r.copyStatus(), r.onChange
+	r.mu.Unlock()
+	if fn != nil {
+		fn(st)
+	}
+}
+
+// changedLocked reports the new status without releasing mu, so fn must
+// not call back into the runner.
+func (r *MissionRunner) changedLocked() {
+	if r.onChange != nil {
+		go r.onChange(r.copyStatus())
+	}
+}
+
+func orDefaultF(v, def float64) float64 {
+	if v == 0 {
+		return def
+	}
+	return v
+}
+
+// missionFileName keeps a mission name safe to use in a file name.
+func missionFileName(name string) string {
+	out := []rune{}
+	for _, c := range name {
+		if c == '-' || c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') {
+			out = append(out, c)
+		}
+	}
+	if len(out) == 0 {
+		return "mission"
+	}
+	return string(out)
+}
+
+// SaveSnapshot copies the camera's latest frame into SnapshotDir.
+func SaveSnapshot(name string) (string, error) {
+	src, err := os.Open(LatestFramePath)
+	if err != nil {
+		return "", fmt.Errorf("no camera frame: %w", err)
+	}
+	defer src.Close()
+	if err := os.MkdirAll(SnapshotDir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(SnapshotDir, name)
+	dst, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return "", err
+	}
+	return path, dst.Close()
+}
+
+// --- Control messages -------------------------------------------------------
+
+// MissionCommand is what operators send on the "mission" data channel and
+// what the HTTP API takes:
+//
+//	{"action":"start","mission":{"name":"patrol","steps":[...]}}
+//	{"action":"pause"}  {"action":"resume"}  {"action":"abort"}  {"action":"status"}
+type MissionCommand struct {
+	Action  string   `json:"action"`
+	Mission *Mission `json:"mission,omitempty"`
+}
+
+// MissionReply answers a command on the data channel.
+type MissionReply struct {
+	Type   string        `json:"type"` // "mission"
+	Status MissionStatus `json:"status"`
+	Error  string        `json:"error,omitempty"`
+}
+
+// Handle runs one command.
+func (r *MissionRunner) Handle(cmd MissionCommand) (MissionStatus, error) {
+	if r == nil {
+		return MissionStatus{State: "idle"}, errors.New("no mission runner")
+	}
+	var err error
+	switch cmd.Action {
+	case "start":
+		if cmd.Mission == nil {
+			err = errors.New("start needs a mission")
+		} else {
+			err = r.Submit(*cmd.Mission)
+		}
+	case "pause":
+		err = r.Pause()
+	case "resume":
+		err = r.Resume()
+	case "abort":
+		err = r.Abort()
+	case "status":
+	default:
+		err = fmt.Errorf("unknown action %q", cmd.Action)
+	}
+	return r.Status(), err
+}
+
+// HandleMessage handles a raw data channel message and returns the reply.
+func (r *MissionRunner) HandleMessage(raw []byte) MissionReply {
+	var cmd MissionCommand
+	reply := MissionReply{Type: "mission"}
+	err := json.Unmarshal(raw, &cmd)
+	if err == nil {
+		reply.Status, err = r.Handle(cmd)
+	} else {
+		reply.Status = r.Status()
+	}
+	if err != nil {
+		reply.Error = err.Error()
+	}
+	return reply
+}
+
+// Handler is the local HTTP API:
+//
+//	GET  /mission          status
+//	POST /mission          start the Mission in the body
+//	POST /mission/{action} pause, resume or abort
+func (r *MissionRunner) Handler() http.Handler {
+	mux := http.NewServeMux()
+	reply := func(w http.ResponseWriter, st MissionStatus, err error) {
+		w.Header().Set("Content-Type", "application/json")
+		if err != nil {
+			w.WriteHeader(http.StatusConflict)
+		}
+		json.NewEncoder(w).Encode(MissionReply{Type: "mission", Status: st, Error: errString(err)})
+	}
+	mux.HandleFunc("GET /mission", func(w http.ResponseWriter, req *http.Request) {
+		reply(w, r.Status(), nil)
+	})
+	mux.HandleFunc("POST /mission", func(w http.ResponseWriter, req *http.Request) {
+		var m Mission
+		if err := json.NewDecoder(req.Body).Decode(&m); err != nil {
+			http.Error(w, "bad mission JSON: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		st, err := r.Handle(MissionCommand{Action: "start", Mission: &m})
+		reply(w, st, err)
+	})
+	mux.HandleFunc("POST /mission/{action}", func(w http.ResponseWriter, req *http.Request) {
+		action := req.PathValue("action")
+		if action == "start" {
+			http.Error(w, "POST the mission to /mission", http.StatusBadRequest)
+			return
+		}
+		st, err := r.Handle(MissionCommand{Action: action})
+		reply(w, st, err)
+	})
+	return mux
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,246 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/n0remac/robot-webrtc/hal"
+	pb "github.com/n0remac/robot-webrtc/servo"
+	"google.golang.org/grpc"
+)
+
+// turningServo is one servo that moves at the commanded speed, MissionTick
+// per GetAngles call.
+type turningServo struct {
+	fakeServos
+	mu    sync.Mutex
+	angle float64
+	vel   float64
+}
+
+func (s *turningServo) Move(ctx context.Context, in *pb.MoveRequest, opts ...grpc.CallOption) (*pb.MoveReply, error) {
+	s.mu.Lock()
+	s.vel = float64(in.Direction) * float64(in.Speed)
+	s.mu.Unlock()
+	s.fakeServos.Move(ctx, in, opts...)
+	return &pb.MoveReply{Ok: true}, nil
+}
+
+func (s *turningServo) Stop(ctx context.Context, in *pb.StopRequest, opts ...grpc.CallOption) (*pb.StopReply, error) {
+	s.mu.Lock()
+	s.vel = 0
+	s.mu.Unlock()
+	return s.fakeServos.Stop(ctx, in, opts...)
+}
+
+func (s *turningServo) GetAngles(context.Context, *pb.GetAnglesRequest, ...grpc.CallOption) (*pb.GetAnglesReply, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.angle += s.vel * MissionTick.Seconds()
+	return &pb.GetAnglesReply{Angles: []*pb.ServoAngle{{Channel: 14, Angle: float32(s.angle)}}}, nil
+}
+
+// missionSim is a simulated robot with a safety layer and a mission runner.
+// run advances all three by a number of ticks.
+func missionSim(t *testing.T, servos pb.ControllerClient) (sim *hal.Sim, run func(int), r *MissionRunner) {
+	t.Helper()
+	t.Cleanup(func() { Board = nil })
+	sim = NewSimBoard()
+	safety := NewSafety(SetupRobot(sim), SetupSensors())
+	r = NewMissionRunner(safety.Motors(), servos)
+	r.Safety = safety.State
+	r.Snapshot = func(name string) (string, error) { return "snap/" + name, nil }
+	run = func(ticks int) {
+		for i := 0; i < ticks; i++ {
+			if i%3 == 0 { // SensorInterval is three ticks
+				safety.Poll()
+			}
+			r.step(MissionTick)
+			sim.Step(MissionTick)
+		}
+	}
+	return sim, run, r
+}
+
+func TestMissionRunsSteps(t *testing.T) {
+	servo := &turningServo{angle: 30}
+	sim, run, r := missionSim(t, servo)
+
+	var states []string
+	var mu sync.Mutex
+	r.OnChange(func(st MissionStatus) {
+		mu.Lock()
+		states = append(states, fmt.Sprintf("%s %d", st.State, st.Step))
+		mu.Unlock()
+	})
+	err := r.Submit(Mission{Name: "tour", Steps: []MissionStep{
+		{Type: "drive", Distance: 0.5},
+		{Type: "turn", Angle: 90},
+		{Type: "servo", Channel: 14, Angle: 90},
+		{Type: "wait", Ms: 100},
+		{Type: "snapshot"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run(200)
+
+	st := r.Status()
+	if st.State != "done" || len(st.Snapshots) != 1 || !strings.HasPrefix(st.Snapshots[0], "snap/tour-5-") {
+		t.Fatalf("status %+v", st)
+	}
+	p := sim.Pose()
+	if math.Abs(p.X-0.5) > 0.02 || math.Abs(p.Theta-math.Pi/2) > 0.1 {
+		t.Errorf("pose %+v, want 0.5m ahead facing left", p)
+	}
+	if math.Abs(servo.angle-90) > ServoAngleTolerance+MissionServoSpeed*MissionTick.Seconds() {
+		t.Errorf("servo at %.1f°", servo.angle)
+	}
+	mu.Lock()
+	want := "running 0,running 1,running 2,running 3,running 4,done 4"
+	if got := strings.Join(states, ","); got != want {
+		t.Errorf("changes %s, want %s", got, want)
+	}
+	mu.Unlock()
+}
+
+func TestMissionStopsForObstacles(t *testing.T) {
+	sim, run, r := missionSim(t, &fakeServos{})
+	// the first post is 1.5m ahead
+	if err := r.Submit(Mission{Steps: []MissionStep{{Type: "drive", Distance: 3}}}); err != nil {
+		t.Fatal(err)
+	}
+	run(300)
+	if st := r.Status(); st.State != "blocked" || st.Progress > 0.5 {
+		t.Fatalf("status %+v", st)
+	}
+	if p := sim.Pose(); p.X > 1.3 {
+		t.Fatalf("drove into the post: %+v", p)
+	}
+
+	if err := r.Submit(Mission{Steps: []MissionStep{{Type: "wait", Ms: 10}}}); err == nil {
+		t.Error("a second mission should be refused while one is active")
+	}
+	if err := r.Abort(); err != nil {
+		t.Fatal(err)
+	}
+	run(1)
+	for i, v := range sim.WheelSpeeds() {
+		if v != 0 {
+			t.Errorf("wheel %d still moving after abort: %v", i, v)
+		}
+	}
+	if err := r.Resume(); err == nil {
+		t.Error("an aborted mission can't resume")
+	}
+}
+
+func TestMissionPauseResume(t *testing.T) {
+	sim, run, r := missionSim(t, &fakeServos{})
+	r.Submit(Mission{Steps: []MissionStep{{Type: "drive", Distance: 0.4}, {Type: "wait", Ms: 1000}}})
+	run(20)
+	if err := r.Pause(); err != nil {
+		t.Fatal(err)
+	}
+	paused := sim.Pose()
+	before := r.Status().Progress
+	run(50)
+	if p := sim.Pose(); p != paused || r.Status().Progress != before {
+		t.Fatalf("paused mission moved: %+v -> %+v", paused, p)
+	}
+	if err := r.Resume(); err != nil {
+		t.Fatal(err)
+	}
+	run(100)
+	st := r.Status()
+	if st.Step != 1 || st.State != "running" {
+		t.Fatalf("status %+v", st)
+	}
+	if p := sim.Pose(); math.Abs(p.X-0.4) > 0.02 {
+		t.Errorf("drive should pick up where it left off: %+v", p)
+	}
+}
+
+// slowServos holds every GetAngles until released.
+type slowServos struct {
+	turningServo
+	asked   chan struct{}
+	release chan struct{}
+}
+
+func (s *slowServos) GetAngles(ctx context.Context, in *pb.GetAnglesRequest, opts ...grpc.CallOption) (*pb.GetAnglesReply, error) {
+	s.asked <- struct{}{}
+	<-s.release
+	return s.turningServo.GetAngles(ctx, in, opts...)
+}
+
+func TestMissionServoRPCsDontHoldTheRunner(t *testing.T) {
+	servos := &slowServos{asked: make(chan struct{}), release: make(chan struct{})}
+	r := NewMissionRunner(nil, servos)
+	r.Submit(Mission{Steps: []MissionStep{{Type: "servo", Channel: 14, Angle: 90}}})
+	done := make(chan struct{})
+	go func() {
+		r.step(MissionTick)
+		close(done)
+	}()
+	<-servos.asked
+
+	if st := r.Status(); st.State != "running" {
+		t.Fatalf("status %+v", st)
+	}
+	if err := r.Abort(); err != nil {
+		t.Fatal(err)
+	}
+	close(servos.release)
+	<-done
+	if st := r.Status(); st.State != "aborted" || st.Step != 0 {
+		t.Errorf("an aborted step carried on: %+v", st)
+	}
+	for _, call := range servos.log() {
+		if strings.HasPrefix(call, "move") {
+			t.Errorf("moved after the abort: %v", servos.log())
+		}
+	}
+}
+
+func TestMissionCommands(t *testing.T) {
+	_, _, r := missionSim(t, &fakeServos{})
+
+	reply := r.HandleMessage([]byte(`{"action":"start","mission":{"steps":[{"type":"fly"}]}}`))
+	if reply.Error == "" || reply.Status.State != "idle" {
+		t.Errorf("bad mission accepted: %+v", reply)
+	}
+	reply = r.HandleMessage([]byte(`{"action":"start","mission":{"name":"w","steps":[{"type":"wait","ms":500}]}}`))
+	if reply.Error != "" || reply.Status.State != "running" || reply.Type != "mission" {
+		t.Errorf("start: %+v", reply)
+	}
+
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+	res, err := http.Post(srv.URL+"/mission/pause", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || r.Status().State != "paused" {
+		t.Errorf("pause: %d %+v", res.StatusCode, r.Status())
+	}
+	res, _ = http.Post(srv.URL+"/mission", "application/json", strings.NewReader(`{"steps":[{"type":"wait","ms":1}]}`))
+	res.Body.Close()
+	if res.StatusCode != http.StatusConflict {
+		t.Errorf("second mission: %d", res.StatusCode)
+	}
+	res, _ = http.Post(srv.URL+"/mission/abort", "", nil)
+	res.Body.Close()
+	res, _ = http.Post(srv.URL+"/mission", "application/json", strings.NewReader(`{"steps":[{"type":"wait","ms":1}]}`))
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || r.Status().State != "running" {
+		t.Errorf("mission after abort: %d %+v", res.StatusCode, r.Status())
+	}
+}
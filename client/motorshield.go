@@ -2,90 +2,34 @@ package client
 
 import (
 	"fmt"
+	"log"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
-	"github.com/stianeikeland/go-rpio/v4"
+	"github.com/n0remac/robot-webrtc/hal"
+	sv "github.com/n0remac/robot-webrtc/servo"
+	"periph.io/x/conn/v3/i2c"
+	"periph.io/x/conn/v3/physic"
+	"periph.io/x/devices/v3/pca9685"
 )
 
-// --- Software PWM ---------------------------------------------------------
-
-// PWM implements a simple software PWM on a single pin.
-type PWM struct {
-	pin   rpio.Pin
-	freq  time.Duration
-	duty  float64 // 0–100
-	quit  chan struct{}
-	guard sync.Mutex
-}
-
-// NewPWM starts a 50 Hz PWM on the given pin.
-func NewPWM(pin rpio.Pin, hz int) *PWM {
-	p := &PWM{
-		pin:  pin,
-		freq: time.Second / time.Duration(hz),
-		duty: 0,
-		quit: make(chan struct{}),
-	}
-	pin.Output()
-	go p.run()
-	return p
-}
-
-func (p *PWM) run() {
-	ticker := time.NewTicker(p.freq)
-	defer ticker.Stop()
-	for {
-		select {
-		case <-ticker.C:
-			p.guard.Lock()
-			d := p.duty / 100.0
-			p.guard.Unlock()
-
-			high := time.Duration(float64(p.freq) * d)
-			p.pin.High()
-			time.Sleep(high)
-			p.pin.Low()
-			time.Sleep(p.freq - high)
-		case <-p.quit:
-			p.pin.Low()
-			return
-		}
-	}
-}
-
-// ChangeDutyCycle sets duty to 0–100.
-func (p *PWM) ChangeDutyCycle(duty float64) {
-	if duty < 0 {
-		duty = 0
-	} else if duty > 100 {
-		duty = 100
-	}
-	p.guard.Lock()
-	p.duty = duty
-	p.guard.Unlock()
-}
-
-// Stop halts the PWM goroutine and drives pin low.
-func (p *PWM) Stop() {
-	close(p.quit)
-}
-
 // --- Arrow (LED indicator) ------------------------------------------------
 
 type Arrow struct {
-	pin rpio.Pin
+	pin hal.Pin
 }
 
-var arrowPins = map[int]rpio.Pin{
-	1: rpio.Pin(13), // BOARD 33 → BCM13
-	2: rpio.Pin(19), // BOARD 35 → BCM19
-	3: rpio.Pin(26), // BOARD 37 → BCM26
-	4: rpio.Pin(16), // BOARD 36 → BCM16
+var arrowPins = map[int]int{
+	1: 13, // BOARD 33 → BCM13
+	2: 19, // BOARD 35 → BCM19
+	3: 26, // BOARD 37 → BCM26
+	4: 16, // BOARD 36 → BCM16
 }
 
-func NewArrow(which int) *Arrow {
-	pin := arrowPins[which]
+func NewArrow(board hal.Board, which int) *Arrow {
+	pin := board.Pin(arrowPins[which])
 	pin.Output()
 	pin.Low()
 	return &Arrow{pin: pin}
@@ -97,7 +41,7 @@ func (a *Arrow) Off() { a.pin.Low() }
 // --- Motor ----------------------------------------------------------------
 
 type motorConfig struct {
-	ePin, fPin, rPin rpio.Pin
+	ePin, fPin, rPin int // BCM
 	arrow            int
 }
 
@@ -121,45 +65,201 @@ var motorConfigs = map[string]map[int]motorConfig{
 }
 
 type Motor struct {
-	pwm      *PWM
-	fPin     rpio.Pin
-	rPin     rpio.Pin
+	pwm      hal.DutyCycler
+	fPin     hal.Pin
+	rPin     hal.Pin
 	arrow    *Arrow
 	testMode bool
 }
 
-func NewMotor(name string, cfg int) *Motor {
+func NewMotor(board hal.Board, name string, cfg int) *Motor {
 	mc, ok := motorConfigs[name][cfg]
 	if !ok {
 		panic(fmt.Sprintf("invalid motor/config: %s/%d", name, cfg))
 	}
+	ePin, fPin, rPin := board.Pin(mc.ePin), board.Pin(mc.fPin), board.Pin(mc.rPin)
 	// Enable GPIO
-	mc.ePin.Output()
-	mc.fPin.Output()
-	mc.rPin.Output()
+	ePin.Output()
+	fPin.Output()
+	rPin.Output()
 	// Start off
-	mc.ePin.Low()
-	mc.fPin.Low()
-	mc.rPin.Low()
+	ePin.Low()
+	fPin.Low()
+	rPin.Low()
 
-	pwm := NewPWM(mc.ePin, 50)
-	arrow := NewArrow(mc.arrow)
+	pwm := speedLine(board, name, ePin, mc.ePin)
+	arrow := NewArrow(board, mc.arrow)
 
 	return &Motor{
 		pwm:   pwm,
-		fPin:  mc.fPin,
-		rPin:  mc.rPin,
+		fPin:  fPin,
+		rPin:  rPin,
 		arrow: arrow,
 	}
 }
 
+// --- Speed line --------------------------------------------------------------
+
+// SpeedConfig picks what drives a motor's enable (speed) line.
+type SpeedConfig struct {
+	Kind    string // "soft" (default), "hardware" or "pca9685"
+	Hz      int    // PWM frequency; 0 for the backend's default
+	Addr    uint16 // pca9685: I²C address
+	Channel int    // pca9685: output 0–15
+}
+
+// MotorSpeed overrides the speed line per motor name ("MOTOR1", ...).
+// Motors not listed use software PWM. cmd/client sets it from -motor-pwm.
+var MotorSpeed = map[string]SpeedConfig{}
+
+// ParseMotorSpeed parses -motor-pwm, a comma-separated list of
+//
+//	NAME=soft[:hz]
+//	NAME=hardware[:hz]
+//	NAME=pca9685:addr:channel[:hz]
+//
+// e.g. "MOTOR1=pca9685:0x41:0,MOTOR4=hardware:1000".
+func ParseMotorSpeed(spec string) (map[string]SpeedConfig, error) {
+	out := map[string]SpeedConfig{}
+	if strings.TrimSpace(spec) == "" {
+		return out, nil
+	}
+	for _, item := range strings.Split(spec, ",") {
+		name, val, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			return nil, fmt.Errorf("%q: want NAME=kind[:...]", item)
+		}
+		if _, known := motorConfigs[name]; !known {
+			return nil, fmt.Errorf("%q: unknown motor %s", item, name)
+		}
+		parts := strings.Split(val, ":")
+		cfg := SpeedConfig{Kind: parts[0]}
+		rest := parts[1:]
+		if cfg.Kind == "pca9685" {
+			if len(rest) < 2 {
+				return nil, fmt.Errorf("%q: pca9685 needs addr:channel", item)
+			}
+			addr, err := strconv.ParseUint(rest[0], 0, 16)
+			if err != nil {
+				return nil, fmt.Errorf("%q: bad address: %v", item, err)
+			}
+			ch, err := strconv.Atoi(rest[1])
+			if err != nil || ch < 0 || ch > 15 {
+				return nil, fmt.Errorf("%q: channel must be 0–15", item)
+			}
+			cfg.Addr, cfg.Channel = uint16(addr), ch
+			rest = rest[2:]
+		} else if cfg.Kind != "soft" && cfg.Kind != "hardware" {
+			return nil, fmt.Errorf("%q: kind must be soft, hardware or pca9685", item)
+		}
+		if len(rest) > 0 {
+			hz, err := strconv.Atoi(rest[0])
+			if err != nil || hz <= 0 {
+				return nil, fmt.Errorf("%q: bad frequency", item)
+			}
+			cfg.Hz = hz
+		}
+		out[name] = cfg
+	}
+	return out, nil
+}
+
+// pcaBoards caches one PCA9685 driver per board and address, since several
+// motors usually share a board, and pcaBuses the one bus they're all on.
+var (
+	pcaMu     sync.Mutex
+	pcaBoards = map[hal.Board]map[uint16]*pca9685.Dev{}
+	pcaBuses  = map[hal.Board]i2c.BusCloser{}
+)
+
+func pcaDev(board hal.Board, addr uint16, hz int) (*pca9685.Dev, error) {
+	pcaMu.Lock()
+	defer pcaMu.Unlock()
+	if dev, ok := pcaBoards[board][addr]; ok {
+		return dev, nil
+	}
+	// opening a PCA9685 turns all its outputs off, so one the servo server
+	// drives would drop every servo it's holding
+	if servoAddrs()[addr] {
+		return nil, fmt.Errorf("%#x is a servo board", addr)
+	}
+	bus, ok := pcaBuses[board]
+	if !ok {
+		var err error
+		if bus, err = board.I2C(); err != nil {
+			return nil, err
+		}
+		pcaBuses[board] = bus
+	}
+	dev, err := pca9685.NewI2C(bus, addr)
+	if err != nil {
+		return nil, err
+	}
+	if err := dev.SetPwmFreq(physic.Frequency(hz) * physic.Hertz); err != nil {
+		return nil, err
+	}
+	if pcaBoards[board] == nil {
+		pcaBoards[board] = map[uint16]*pca9685.Dev{}
+	}
+	pcaBoards[board][addr] = dev
+	return dev, nil
+}
+
+// servoAddrs is the addresses on the board's I²C bus that servos are wired
+// to, going by the servo calibration in ServoFiles.Config. 0x40 is always
+// one of them.
+func servoAddrs() map[uint16]bool {
+	addrs := map[uint16]bool{sv.DefaultBoard.Addr: true}
+	cs, err := sv.LoadCalibrations(ServoFiles.Config)
+	if err != nil {
+		log.Printf("⚠️  servo calibration: %v", err)
+		return addrs
+	}
+	for ch, c := range cs {
+		if b, _ := c.Location(ch); b.Bus == sv.DefaultBoard.Bus {
+			addrs[b.Addr] = true
+		}
+	}
+	return addrs
+}
+
+// speedLine builds the DutyCycler for a motor, falling back to software PWM
+// on its enable pin if the configured backend can't be set up.
+func speedLine(board hal.Board, name string, ePin hal.Pin, bcm int) hal.DutyCycler {
+	cfg := MotorSpeed[name]
+	var pwm hal.DutyCycler
+	var err error
+	switch cfg.Kind {
+	case "hardware":
+		pwm, err = board.HardwarePWM(bcm, orDefault(cfg.Hz, 1000))
+	case "pca9685":
+		var dev *pca9685.Dev
+		if dev, err = pcaDev(board, cfg.Addr, orDefault(cfg.Hz, 1000)); err == nil {
+			pwm = hal.NewPCAChannel(dev, cfg.Channel)
+		}
+	}
+	if err != nil {
+		log.Printf("⚠️  %s: %s speed line failed (%v); using software PWM", name, cfg.Kind, err)
+	}
+	if pwm == nil {
+		pwm = board.PWM(ePin, orDefault(cfg.Hz, 50))
+	}
+	return pwm
+}
+
+func orDefault(v, def int) int {
+	if v == 0 {
+		return def
+	}
+	return v
+}
+
 // Test mode: instead of driving motor, toggles the arrow LED.
 func (m *Motor) Test(state bool) {
 	m.testMode = state
 }
 
 func (m *Motor) Forward(speed float64) {
-	fmt.Println("Forward")
 	if m.testMode {
 		m.arrow.On()
 		return
@@ -170,7 +270,6 @@ func (m *Motor) Forward(speed float64) {
 }
 
 func (m *Motor) Reverse(speed float64) {
-	fmt.Println("Reverse")
 	if m.testMode {
 		m.arrow.Off()
 		return
@@ -181,7 +280,6 @@ func (m *Motor) Reverse(speed float64) {
 }
 
 func (m *Motor) Stop() {
-	fmt.Println("Stop")
 	m.arrow.Off()
 	m.pwm.ChangeDutyCycle(0)
 	m.fPin.Low()
@@ -219,7 +317,7 @@ func (lm *LinkedMotors) Stop() {
 // --- Stepper --------------------------------------------------------------
 
 type stepperPins struct {
-	en1, en2, c1, c2, c3, c4 rpio.Pin
+	en1, en2, c1, c2, c3, c4 int // BCM
 }
 
 var steppers = map[string]stepperPins{
@@ -228,95 +326,116 @@ var steppers = map[string]stepperPins{
 }
 
 type Stepper struct {
-	pins stepperPins
+	coils [4]hal.Pin
 }
 
-func NewStepper(name string) *Stepper {
+func NewStepper(board hal.Board, name string) *Stepper {
 	cfg, ok := steppers[name]
 	if !ok {
 		panic("invalid stepper: " + name)
 	}
-	for _, p := range []rpio.Pin{cfg.en1, cfg.en2, cfg.c1, cfg.c2, cfg.c3, cfg.c4} {
+	for _, n := range []int{cfg.en1, cfg.en2, cfg.c1, cfg.c2, cfg.c3, cfg.c4} {
+		p := board.Pin(n)
 		p.Output()
 		p.High()
 	}
+	s := &Stepper{coils: [4]hal.Pin{board.Pin(cfg.c1), board.Pin(cfg.c2), board.Pin(cfg.c3), board.Pin(cfg.c4)}}
 	// clear coils
-	for _, p := range []rpio.Pin{cfg.c1, cfg.c2, cfg.c3, cfg.c4} {
+	for _, p := range s.coils {
 		p.Low()
 	}
-	return &Stepper{pins: cfg}
+	return s
 }
 
-func (s *Stepper) setStep(w1, w2, w3, w4 rpio.State) {
-	s.pins.c1.Output()
-	s.pins.c1.Write(w1)
-	s.pins.c2.Output()
-	s.pins.c2.Write(w2)
-	s.pins.c3.Output()
-	s.pins.c3.Write(w3)
-	s.pins.c4.Output()
-	s.pins.c4.Write(w4)
+func (s *Stepper) setStep(w1, w2, w3, w4 bool) {
+	for i, w := range []bool{w1, w2, w3, w4} {
+		s.coils[i].Output()
+		hal.Write(s.coils[i], w)
+	}
 }
 
 func (s *Stepper) Forward(delayMs time.Duration, steps int) {
 	for i := 0; i < steps; i++ {
-		s.setStep(rpio.High, rpio.Low, rpio.Low, rpio.Low)
+		s.setStep(true, false, false, false)
 		time.Sleep(delayMs * time.Millisecond)
-		s.setStep(rpio.Low, rpio.High, rpio.Low, rpio.Low)
+		s.setStep(false, true, false, false)
 		time.Sleep(delayMs * time.Millisecond)
-		s.setStep(rpio.Low, rpio.Low, rpio.High, rpio.Low)
+		s.setStep(false, false, true, false)
 		time.Sleep(delayMs * time.Millisecond)
-		s.setStep(rpio.Low, rpio.Low, rpio.Low, rpio.High)
+		s.setStep(false, false, false, true)
 		time.Sleep(delayMs * time.Millisecond)
 	}
 }
 
 func (s *Stepper) Backward(delayMs time.Duration, steps int) {
 	for i := 0; i < steps; i++ {
-		s.setStep(rpio.Low, rpio.Low, rpio.Low, rpio.High)
+		s.setStep(false, false, false, true)
 		time.Sleep(delayMs * time.Millisecond)
-		s.setStep(rpio.Low, rpio.Low, rpio.High, rpio.Low)
+		s.setStep(false, false, true, false)
 		time.Sleep(delayMs * time.Millisecond)
-		s.setStep(rpio.Low, rpio.High, rpio.Low, rpio.Low)
+		s.setStep(false, true, false, false)
 		time.Sleep(delayMs * time.Millisecond)
-		s.setStep(rpio.High, rpio.Low, rpio.Low, rpio.Low)
+		s.setStep(true, false, false, false)
 		time.Sleep(delayMs * time.Millisecond)
 	}
 }
 
 func (s *Stepper) Stop() {
 	fmt.Println("Stop Stepper Motor")
-	for _, p := range []rpio.Pin{s.pins.c1, s.pins.c2, s.pins.c3, s.pins.c4} {
+	for _, p := range s.coils {
 		p.Low()
 	}
 }
 
 // --- Sensor ---------------------------------------------------------------
 
+// EchoTimeout bounds each wait on the ultrasonic echo pin. 30ms is a round
+// trip of about 5m, beyond the sensor's range.
+var EchoTimeout = 30 * time.Millisecond
+
+// SensorLogInterval is how often a sensor may log while an obstacle or a
+// fault persists; it's polled every SensorInterval.
+var SensorLogInterval = 5 * time.Second
+
 type Sensor struct {
-	echo      rpio.Pin
-	trigger   *rpio.Pin // nil if not used
+	name      string
+	echo      hal.Pin
+	trigger   hal.Pin // nil if not used
 	boundary  float64
 	Triggered bool
 	lastRead  float64
+	readOK    bool // lastRead is a real echo, not a timeout
 	check     func(*Sensor)
+	loggedAt  time.Time
+}
+
+// logf logs at most once every SensorLogInterval.
+func (s *Sensor) logf(format string, args ...interface{}) {
+	if time.Since(s.loggedAt) < SensorLogInterval {
+		return
+	}
+	s.loggedAt = time.Now()
+	log.Printf(format, args...)
 }
 
-func NewSensor(sensortype string, boundary float64) *Sensor {
+// irPins is where the shield wires its IR sensors: BOARD 7→BCM4,
+// BOARD 12→BCM18. BCM18 is one of hal.HardwarePWMPins, but hardware PWM
+// is only ever set up on a motor's own enable pin (17, 25, 10 or 12), so
+// BCM18 stays an input. MOTOR4's BCM12 shares PWM0 with BCM18; that's
+// harmless while BCM18 isn't switched to its PWM function.
+var irPins = map[string]int{"IR1": 4, "IR2": 18}
+
+func NewSensor(board hal.Board, sensortype string, boundary float64) *Sensor {
 	var s Sensor
+	s.name = sensortype
 	s.boundary = boundary
 
 	switch sensortype {
 	case "IR1", "IR2":
-		// BOARD 7→BCM4, BOARD12→BCM18
-		if sensortype == "IR1" {
-			s.echo = rpio.Pin(4)
-		} else {
-			s.echo = rpio.Pin(18)
-		}
+		s.echo = board.Pin(irPins[sensortype])
 		s.check = func(s *Sensor) {
-			if s.echo.Read() == rpio.High {
-				fmt.Println("Sensor:", sensortype, "Object Detected")
+			if s.echo.Read() {
+				s.logf("sensor %s: object detected", sensortype)
 				s.Triggered = true
 			} else {
 				s.Triggered = false
@@ -325,28 +444,43 @@ func NewSensor(sensortype string, boundary float64) *Sensor {
 
 	case "ULTRASONIC":
 		// BOARD29→BCM5, BOARD31→BCM6
-		t := rpio.Pin(5)
+		t := board.Pin(5)
 		t.Output()
-		e := rpio.Pin(6)
+		e := board.Pin(6)
 		e.Input()
-		s.trigger = &t
+		s.trigger = t
 		s.echo = e
 		s.check = func(s *Sensor) {
-			s.trigger.Write(rpio.High)
+			s.trigger.High()
 			time.Sleep(10 * time.Microsecond)
-			s.trigger.Write(rpio.Low)
-
-			start := time.Now()
-			for s.echo.Read() == rpio.Low {
-				start = time.Now()
+			s.trigger.Low()
+
+			// An echo that never starts means the sensor isn't answering,
+			// so forward motion is blocked rather than let through blind.
+			// One that outlasts the timeout is the HC-SR04's ~38ms pulse
+			// for nothing in range: clear, with no distance.
+			s.readOK = false
+			s.Triggered = true
+			wait := time.Now()
+			for !s.echo.Read() {
+				if time.Since(wait) > EchoTimeout {
+					s.logf("sensor %s: no echo, blocking forward motion", sensortype)
+					return
+				}
 			}
-			for s.echo.Read() == rpio.High {
+			s.Triggered = false
+			start := time.Now()
+			for s.echo.Read() {
+				if time.Since(start) > EchoTimeout {
+					return
+				}
 			}
 			elapsed := time.Since(start)
 			dist := elapsed.Seconds() * 34300.0 / 2
 			s.lastRead = dist
+			s.readOK = true
 			if dist < s.boundary {
-				fmt.Println("Boundary breached:", dist)
+				s.logf("sensor %s: boundary breached at %.0fcm", sensortype, dist)
 				s.Triggered = true
 			} else {
 				s.Triggered = false
@@ -367,3 +501,13 @@ func (s *Sensor) Trigger() {
 	fmt.Println("Trigger Called; Triggered =", s.Triggered)
 }
 
+// Poll takes a fresh reading for the safety layer.
+func (s *Sensor) Poll() Reading {
+	s.check(s)
+	return Reading{
+		Name:        s.name,
+		Blocked:     s.Triggered,
+		DistanceCm:  s.lastRead,
+		HasDistance: s.trigger != nil && s.readOK,
+	}
+}
@@ -0,0 +1,328 @@
+package client
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/n0remac/robot-webrtc/hal"
+)
+
+// --- Encoders ---------------------------------------------------------------
+
+// EncoderConfig wires a motor's encoder: A and B for quadrature, or just A
+// (B = 0) for a single hall sensor, whose direction comes from the command.
+type EncoderConfig struct {
+	A, B int
+}
+
+// MotorEncoders maps motor names to their encoders. Motors without one stay
+// open loop. cmd/client sets it from -encoders.
+var MotorEncoders = map[string]EncoderConfig{}
+
+// Closed-loop tuning. Speed commands are scaled against MaxWheelSpeed, so a
+// key bound to 50 asks for half of it whatever the battery is doing.
+var (
+	TicksPerMetre = 2000.0 // encoder counts per metre of wheel travel
+	TrackWidth    = 0.15   // metres between the left and right wheels
+	MaxWheelSpeed = 0.5    // metres/second commanded by duty 100
+	SpeedTick     = 20 * time.Millisecond
+	SpeedGains    = PIDGains{Kp: 60, Ki: 400}
+)
+
+// ParseEncoders parses -encoders, a comma-separated list of NAME=A:B for
+// quadrature encoders or NAME=A for hall sensors, e.g.
+// "MOTOR1=20:21,MOTOR4=26".
+func ParseEncoders(spec string) (map[string]EncoderConfig, error) {
+	out := map[string]EncoderConfig{}
+	if strings.TrimSpace(spec) == "" {
+		return out, nil
+	}
+	for _, item := range strings.Split(spec, ",") {
+		name, val, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			return nil, fmt.Errorf("%q: want NAME=A[:B]", item)
+		}
+		if _, known := motorConfigs[name]; !known {
+			return nil, fmt.Errorf("%q: unknown motor %s", item, name)
+		}
+		var cfg EncoderConfig
+		for i, p := range strings.Split(val, ":") {
+			n, err := strconv.Atoi(p)
+			if err != nil || n <= 0 || n > 27 || i > 1 {
+				return nil, fmt.Errorf("%q: pins must be BCM 1–27", item)
+			}
+			if i == 0 {
+				cfg.A = n
+			} else {
+				cfg.B = n
+			}
+		}
+		out[name] = cfg
+	}
+	return out, nil
+}
+
+// --- Speed control ----------------------------------------------------------
+
+type PIDGains struct {
+	Kp, Ki, Kd float64
+}
+
+// SpeedController closes the loop around one motor. Forward and Reverse ask
+// for a wheel speed rather than a duty, and Step adjusts the real duty until
+// the encoder agrees: a feed-forward guess plus PID on the speed error.
+type SpeedController struct {
+	motor Motorer
+	enc   hal.Encoder
+	quad  bool
+	gains PIDGains
+
+	mu       sync.Mutex
+	target   float64 // m/s, positive forward
+	duty     float64 // signed duty last sent
+	reverse  bool    // last non-zero target was backwards; a coasting wheel keeps turning that way
+	count    int64
+	travel   float64 // metres turned, signed
+	speed    float64 // measured m/s
+	integral float64
+	prevErr  float64
+}
+
+func NewSpeedController(motor Motorer, enc hal.Encoder, quad bool, gains PIDGains) *SpeedController {
+	return &SpeedController{motor: motor, enc: enc, quad: quad, gains: gains, count: enc.Count()}
+}
+
+func (c *SpeedController) Forward(speed float64) { c.set(speed / 100 * MaxWheelSpeed) }
+func (c *SpeedController) Reverse(speed float64) { c.set(-speed / 100 * MaxWheelSpeed) }
+func (c *SpeedController) Test(state bool)       { c.motor.Test(state) }
+
+// Stop cuts the motor at once instead of waiting for the next step.
+func (c *SpeedController) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.target, c.duty = 0, 0
+	c.integral, c.prevErr = 0, 0
+	c.motor.Stop()
+}
+
+func (c *SpeedController) set(target float64) {
+	if target == 0 {
+		c.Stop()
+		return
+	}
+	c.mu.Lock()
+	if target*c.target <= 0 {
+		c.integral, c.prevErr = 0, 0 // starting, or changing direction
+	}
+	c.target = target
+	c.reverse = target < 0
+	c.mu.Unlock()
+}
+
+// Speed is the measured wheel speed in m/s.
+func (c *SpeedController) Speed() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.speed
+}
+
+// Travel is how far the wheel has turned in metres, negative for reverse.
+func (c *SpeedController) Travel() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.travel
+}
+
+// Step measures the wheel over the last dt and corrects the duty.
+func (c *SpeedController) Step(dt time.Duration) {
+	count := c.enc.Count()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	dist := float64(count-c.count) / TicksPerMetre
+	c.count = count
+	if !c.quad && c.reverse {
+		dist = -dist // a hall sensor can't tell, so trust the command
+	}
+	c.travel += dist
+	c.speed = dist / dt.Seconds()
+
+	if c.target == 0 {
+		return
+	}
+	err := c.target - c.speed
+	deriv := (err - c.prevErr) / dt.Seconds()
+	c.prevErr = err
+	integral := c.integral + err*dt.Seconds()
+	out := c.target/MaxWheelSpeed*100 + c.gains.Kp*err + c.gains.Ki*integral + c.gains.Kd*deriv
+	if math.Abs(out) < 100 {
+		c.integral = integral // no wind-up while saturated
+	}
+	out = math.Max(-100, math.Min(100, out))
+	if out*c.target < 0 {
+		out = 0 // brake by cutting power, never by reversing
+	}
+
+	// still holding mu so a concurrent Stop can't be overwritten
+	c.duty = out
+	switch {
+	case out > 0:
+		c.motor.Forward(out)
+	case out < 0:
+		c.motor.Reverse(-out)
+	default:
+		c.motor.Stop()
+	}
+}
+
+// --- Odometry ---------------------------------------------------------------
+
+// Pose is where odometry puts the robot relative to where it started:
+// metres, and radians counter-clockwise.
+type Pose struct {
+	X     float64 `json:"x"`
+	Y     float64 `json:"y"`
+	Theta float64 `json:"theta"`
+}
+
+// Odometry steps the robot's speed controllers and dead-reckons its pose
+// from their wheel travel. Which way each wheel moves the robot comes from
+// the mixer, as in the simulator. Wheels without an encoder are nil.
+type Odometry struct {
+	wheels []*SpeedController
+	mixer  Mixer
+
+	mu   sync.Mutex
+	last []float64
+	pose Pose
+	ok   bool // both sides have an encoder
+}
+
+func NewOdometry(wheels []*SpeedController, mixer Mixer) *Odometry {
+	var left, right bool
+	for i, w := range wheels {
+		if w == nil {
+			continue
+		}
+		if mixer.Turn[i]*mixer.Forward[i] > 0 {
+			right = true
+		} else {
+			left = true
+		}
+	}
+	return &Odometry{wheels: wheels, mixer: mixer, last: make([]float64, len(wheels)), ok: left && right}
+}
+
+// Odom is the robot's odometry, set up by SetupRobot when any motor has an
+// encoder. Its methods do nothing on nil.
+var Odom *Odometry
+
+// Pose reports the dead-reckoned pose, if both sides have encoders.
+func (o *Odometry) Pose() (Pose, bool) {
+	if o == nil {
+		return Pose{}, false
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.pose, o.ok
+}
+
+func (o *Odometry) Reset() {
+	if o == nil {
+		return
+	}
+	o.mu.Lock()
+	o.pose = Pose{}
+	o.mu.Unlock()
+}
+
+// Step runs every speed controller once and integrates the pose.
+func (o *Odometry) Step(dt time.Duration) {
+	if o == nil {
+		return
+	}
+	for _, w := range o.wheels {
+		if w != nil {
+			w.Step(dt)
+		}
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var left, right float64
+	var nLeft, nRight int
+	for i, w := range o.wheels {
+		if w == nil {
+			continue
+		}
+		travel := w.Travel()
+		d := (travel - o.last[i]) * o.mixer.Forward[i]
+		o.last[i] = travel
+		if o.mixer.Turn[i]*o.mixer.Forward[i] > 0 {
+			right += d
+			nRight++
+		} else {
+			left += d
+			nLeft++
+		}
+	}
+	if !o.ok {
+		return
+	}
+	left /= float64(nLeft)
+	right /= float64(nRight)
+
+	dist := (left + right) / 2
+	dTheta := (right - left) / TrackWidth
+	mid := o.pose.Theta + dTheta/2
+	o.pose.X += dist * math.Cos(mid)
+	o.pose.Y += dist * math.Sin(mid)
+	o.pose.Theta = math.Remainder(o.pose.Theta+dTheta, 2*math.Pi)
+}
+
+func (o *Odometry) Run(stop <-chan struct{}) {
+	if o == nil {
+		return
+	}
+	ticker := time.NewTicker(SpeedTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			o.Step(SpeedTick)
+		}
+	}
+}
+
+// closeLoop wraps every motor with an encoder in a SpeedController and sets
+// up Odom. Motors whose encoder can't be opened stay open loop.
+func closeLoop(board hal.Board, names []string, motors []Motorer) []Motorer {
+	Odom = nil
+	if len(MotorEncoders) == 0 {
+		return motors
+	}
+	out := append([]Motorer(nil), motors...)
+	wheels := make([]*SpeedController, len(motors))
+	for i, name := range names {
+		ec, ok := MotorEncoders[name]
+		if !ok {
+			continue
+		}
+		enc, err := board.Encoder(ec.A, ec.B)
+		if err != nil {
+			log.Printf("⚠️  %s: encoder failed (%v); staying open loop", name, err)
+			continue
+		}
+		wheels[i] = NewSpeedController(motors[i], enc, ec.B != 0, SpeedGains)
+		out[i] = wheels[i]
+	}
+	Odom = NewOdometry(wheels, DefaultMixer)
+	return out
+}
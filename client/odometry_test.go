@@ -0,0 +1,146 @@
+package client
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/n0remac/robot-webrtc/hal"
+)
+
+var simEncoders = map[string]EncoderConfig{
+	"MOTOR1": {A: 20, B: 21},
+	"MOTOR2": {A: 16, B: 26},
+	"MOTOR3": {A: 13, B: 19},
+	"MOTOR4": {A: 3},
+}
+
+// closedLoopSim wires encoders onto a sim robot with a tired battery and
+// sluggish motors with a deadband.
+func closedLoopSim(t *testing.T) (*hal.Sim, []Motorer) {
+	t.Helper()
+	MotorEncoders = simEncoders
+	t.Cleanup(func() { Board, Odom, MotorEncoders = nil, nil, map[string]EncoderConfig{} })
+	sim := NewSimBoardWith(hal.MotorModel{Deadband: 15, Battery: 0.7, Lag: 80 * time.Millisecond})
+	motors := SetupRobot(sim)
+	if Odom == nil {
+		t.Fatal("SetupRobot should set up odometry")
+	}
+	return sim, motors
+}
+
+func runLoop(sim *hal.Sim, steps int) {
+	for i := 0; i < steps; i++ {
+		sim.Step(SpeedTick)
+		Odom.Step(SpeedTick)
+	}
+}
+
+func TestSpeedControllerHoldsSpeed(t *testing.T) {
+	sim, motors := closedLoopSim(t)
+	for _, m := range motors {
+		if _, ok := m.(*SpeedController); !ok {
+			t.Fatalf("motor is %T", m)
+		}
+	}
+	handle := ControlsWith(NewBindingStore(DefaultBindings()), motors, &fakeServos{})
+
+	// w asks for MaxWheelSpeed, which the battery can't deliver: the
+	// controller should saturate rather than give up
+	handle(key("w", "pressed"))
+	runLoop(sim, 100)
+	for i, m := range motors {
+		if d := m.(*SpeedController).duty; math.Abs(d) != 100 {
+			t.Errorf("wheel %d duty %.1f, want full", i, d)
+		}
+	}
+	handle(key("w", "released"))
+
+	// half speed is well within reach, though open loop it would be well
+	// under half
+	bindings := DefaultBindings()
+	for k, a := range bindings.Keys {
+		if k == "w" {
+			for i := range a.Motors {
+				a.Motors[i] /= 2
+			}
+		}
+	}
+	handle = ControlsWith(NewBindingStore(bindings), motors, &fakeServos{})
+	handle(key("w", "pressed"))
+	runLoop(sim, 100)
+	for i, m := range motors {
+		got := m.(*SpeedController).Speed()
+		if math.Abs(math.Abs(got)-MaxWheelSpeed/2) > 0.02 {
+			t.Errorf("wheel %d measured %.3f m/s, want %.3f", i, got, MaxWheelSpeed/2)
+		}
+	}
+	if v := sim.WheelSpeeds(); math.Abs(v[0]-v[3]) > 0.01 {
+		t.Errorf("left and right wheels differ: %v", v)
+	}
+
+	handle(key("w", "released"))
+	sim.Step(SpeedTick)
+	sim.Step(SpeedTick)
+	for i, v := range sim.WheelSpeeds() {
+		if math.Abs(v) > 0.3 {
+			t.Errorf("wheel %d still at %.3f after a stop", i, v)
+		}
+	}
+}
+
+func TestOdometryFollowsSim(t *testing.T) {
+	sim, motors := closedLoopSim(t)
+	handle := ControlsWith(NewBindingStore(DefaultBindings()), motors, &fakeServos{})
+
+	// forward, a left turn, then reverse (the hall wheel has to take its
+	// direction from the command)
+	for _, leg := range []struct {
+		key   string
+		steps int
+	}{{"w", 50}, {"a", 20}, {"s", 30}} {
+		handle(key(leg.key, "pressed"))
+		runLoop(sim, leg.steps)
+		handle(key(leg.key, "released"))
+		runLoop(sim, 10)
+	}
+
+	want := sim.Pose()
+	got, ok := Odom.Pose()
+	if !ok {
+		t.Fatal("no pose")
+	}
+	if math.Hypot(got.X-want.X, got.Y-want.Y) > 0.02 || math.Abs(got.Theta-want.Theta) > 0.05 {
+		t.Errorf("odometry %+v, sim %+v", got, want)
+	}
+	if want.Theta < 0.5 {
+		t.Errorf("the a key should have turned the robot left: %+v", want)
+	}
+}
+
+func TestOdometryNeedsBothSides(t *testing.T) {
+	MotorEncoders = map[string]EncoderConfig{"MOTOR1": {A: 20, B: 21}}
+	defer func() { Board, Odom, MotorEncoders = nil, nil, map[string]EncoderConfig{} }()
+	motors := SetupRobot(NewSimBoard())
+	if _, ok := motors[1].(*Motor); !ok {
+		t.Errorf("MOTOR2 has no encoder and should stay open loop, got %T", motors[1])
+	}
+	if _, ok := Odom.Pose(); ok {
+		t.Error("one encoder can't give a pose")
+	}
+}
+
+func TestParseEncoders(t *testing.T) {
+	got, err := ParseEncoders("MOTOR1=20:21, MOTOR4=26")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["MOTOR1"] != (EncoderConfig{A: 20, B: 21}) || got["MOTOR4"] != (EncoderConfig{A: 26}) || len(got) != 2 {
+		t.Errorf("got %+v", got)
+	}
+	for _, bad := range []string{"MOTOR9=1", "MOTOR1", "MOTOR1=x", "MOTOR1=1:2:3", "MOTOR1=40"} {
+		if _, err := ParseEncoders(bad); err == nil {
+			t.Errorf("%q should not parse", bad)
+		}
+	}
+}
@@ -9,8 +9,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/n0remac/robot-webrtc/hal"
 	pb "github.com/n0remac/robot-webrtc/servo"
-	"github.com/stianeikeland/go-rpio/v4"
 
 	"github.com/pion/webrtc/v4"
 )
@@ -29,30 +29,49 @@ func (NopMotor) Reverse(float64) {}
 func (NopMotor) Stop()           {}
 func (NopMotor) Test(bool)       {}
 
-func SetupRobot() []Motorer {
-	// 0) Open the rpio driver — must do this *once* before any Pin.Output/Pin.Input calls
-	if err := rpio.Open(); err != nil {
+// Board is the hardware SetupRobot was given; nil when running without any.
+var Board hal.Board
+
+// OpenBoard opens the Pi's GPIO, or returns nil anywhere else.
+func OpenBoard() hal.Board {
+	// Open the rpio driver — must do this *once* before any Pin.Output/Pin.Input calls
+	board, err := hal.OpenRPi()
+	if err != nil {
 		log.Printf("⚠️  rpio.Open failed (%v); falling back to no-op motors", err)
+		return nil
+	}
+	return board
+}
+
+func SetupRobot(board hal.Board) []Motorer {
+	if board == nil {
 		return []Motorer{NopMotor{}, NopMotor{}, NopMotor{}, NopMotor{}}
 	}
+	Board = board
 
-	// Create motors (these will use rpio.Pin under the hood)
-	m1 := NewMotor("MOTOR1", 1)
-	m2 := NewMotor("MOTOR2", 1)
-	m3 := NewMotor("MOTOR3", 1)
-	m4 := NewMotor("MOTOR4", 1)
+	m1 := NewMotor(board, "MOTOR1", 1)
+	m2 := NewMotor(board, "MOTOR2", 1)
+	m3 := NewMotor(board, "MOTOR3", 1)
+	m4 := NewMotor(board, "MOTOR4", 1)
 
-	return []Motorer{m1, m2, m3, m4}
+	return closeLoop(board, []string{"MOTOR1", "MOTOR2", "MOTOR3", "MOTOR4"}, []Motorer{m1, m2, m3, m4})
 }
 
 func Controls(
 	motors []Motorer,
 	servoClient pb.ControllerClient,
 ) func(msg webrtc.DataChannelMessage) {
-	const speed = 60 // degrees per second
+	return ControlsWith(KeyBindings, motors, servoClient)
+}
 
+// ControlsWith handles keyboard messages using whatever bindings the store
+// holds at the time of each message, so reloads apply to the next key press.
+func ControlsWith(
+	bindings *BindingStore,
+	motors []Motorer,
+	servoClient pb.ControllerClient,
+) func(msg webrtc.DataChannelMessage) {
 	return func(msg webrtc.DataChannelMessage) {
-		log.Printf("Received message on DataChannel 'keyboard': %s", string(msg.Data))
 		type Msg struct {
 			Key    string
 			Action string
@@ -62,110 +81,87 @@ func Controls(
 			log.Printf("Error unmarshalling message: %v", err)
 			return
 		}
+		Deadman.Beat()
+		switch m.Action {
+		case "heartbeat":
+			return
+		case "drive":
+			var cmd DriveCommand
+			if err := json.Unmarshal(msg.Data, &cmd); err != nil {
+				log.Printf("Error unmarshalling drive command: %v", err)
+				return
+			}
+			Drive.Command(cmd)
+			return
+		}
 		log.Printf("Action=%s, Key=%q", m.Action, m.Key)
 
-		// motors for numeric keys
-		m1, m2, m3, m4 := motors[0], motors[1], motors[2], motors[3]
-
-		// helper to call the servo RPC
-		rpcAct := func(pin, dir int32) {
-			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-			defer cancel()
-			if m.Action == "pressed" {
-				_, err := servoClient.Move(ctx, &pb.MoveRequest{
-					Channel:   pin,
-					Direction: dir,
-					Speed:     speed,
-				})
-				if err != nil {
-					log.Printf("Servo Move RPC error: %v", err)
-				}
-			} else {
-				_, err := servoClient.Stop(ctx, &pb.StopRequest{
-					Channel: pin,
-				})
-				if err != nil {
-					log.Printf("Servo Stop RPC error: %v", err)
-				}
+		b := bindings.Get()
+		a, ok := b.Keys[m.Key]
+		if !ok {
+			return
+		}
+		pressed := m.Action == "pressed"
+		if a.Macro != "" {
+			if pressed {
+				go runMacro(b.Macros[a.Macro], motors, servoClient)
 			}
+			return
 		}
-		// 4 open claw, 5 turn claw , 6 lift claw, 14, pan camera, 15 tilt camera
-		switch m.Key {
-		// Servos:
-		case "y": // claw open
-			rpcAct(4, +1)
-		case "r": // claw close
-			rpcAct(4, -1)
-		case "t": // arm up
-			rpcAct(6, +1)
-		case "g": // arm down
-			rpcAct(6, -1)
-		case "f": // left/right
-			rpcAct(5, +1)
-		case "h":
-			rpcAct(5, -1)
-		case "i": // camera tilt
-			rpcAct(15, +1)
-		case "k":
-			rpcAct(15, -1)
-		case "l": // camera pan
-			rpcAct(14, -1)
-		case "j":
-			rpcAct(14, +1)
-
-		// Motors:
-		case "w":
-			if m.Action == "pressed" {
-				m1.Reverse(100)
-				m3.Forward(100)
-				m2.Reverse(100)
-				m4.Forward(100)
-			} else {
-				m1.Stop()
-				m3.Stop()
-				m2.Stop()
-				m4.Stop()
+		act(a, pressed, motors, servoClient)
+	}
+}
+
+// act presses or releases a single motor or servo action.
+func act(a Action, pressed bool, motors []Motorer, servoClient pb.ControllerClient) {
+	switch {
+	case a.Motors != nil:
+		for i, duty := range a.Motors {
+			if i >= len(motors) {
+				break
 			}
-		case "s":
-			if m.Action == "pressed" {
-				m1.Forward(100)
-				m3.Reverse(100)
-				m2.Forward(100)
-				m4.Reverse(100)
-			} else {
-				m1.Stop()
-				m3.Stop()
-				m2.Stop()
-				m4.Stop()
+			switch {
+			case !pressed || duty == 0:
+				motors[i].Stop()
+			case duty > 0:
+				motors[i].Forward(duty)
+			default:
+				motors[i].Reverse(-duty)
 			}
-		case "a":
-			if m.Action == "pressed" {
-				m1.Forward(100)
-				m3.Reverse(100)
-				m2.Reverse(100)
-				m4.Forward(100)
-			} else {
-				m1.Stop()
-				m3.Stop()
-				m2.Stop()
-				m4.Stop()
+		}
+	case a.Servo != nil:
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		if pressed {
+			_, err := servoClient.Move(ctx, &pb.MoveRequest{
+				Channel:   a.Servo.Channel,
+				Name:      a.Servo.Name,
+				Direction: a.Servo.Direction,
+				Speed:     a.Servo.Speed,
+			})
+			if err != nil {
+				log.Printf("Servo Move RPC error: %v", err)
 			}
-		case "d":
-			if m.Action == "pressed" {
-				m1.Reverse(100)
-				m3.Forward(100)
-				m2.Forward(100)
-				m4.Reverse(100)
-			} else {
-				m1.Stop()
-				m3.Stop()
-				m2.Stop()
-				m4.Stop()
+		} else {
+			_, err := servoClient.Stop(ctx, &pb.StopRequest{
+				Channel: a.Servo.Channel,
+				Name:    a.Servo.Name,
+			})
+			if err != nil {
+				log.Printf("Servo Stop RPC error: %v", err)
 			}
 		}
 	}
 }
 
+func runMacro(steps []MacroStep, motors []Motorer, servoClient pb.ControllerClient) {
+	for _, st := range steps {
+		act(st.Action, true, motors, servoClient)
+		time.Sleep(time.Duration(st.HoldMs) * time.Millisecond)
+		act(st.Action, false, motors, servoClient)
+	}
+}
+
 func RunFFmpegCLI(args []string) {
 	log.Printf("running ffmpeg %v", args)
 	cmd := exec.Command("ffmpeg", args...)
@@ -0,0 +1,232 @@
+package client
+
+import (
+	"log"
+	"math"
+	"sync"
+	"time"
+)
+
+// --- Obstacle safety --------------------------------------------------------
+
+// Obstacle thresholds. Under ObstacleStopCm (or with an IR sensor firing)
+// forward motion is vetoed; between that and ObstacleSlowCm it is scaled
+// down linearly. cmd/client sets these from -stop-cm and -slow-cm.
+var (
+	ObstacleStopCm = 20.0
+	ObstacleSlowCm = 50.0
+	SensorInterval = 60 * time.Millisecond // HC-SR04 wants ≥60ms between pings
+)
+
+// Reading is one poll of an obstacle sensor.
+type Reading struct {
+	Name        string
+	Blocked     bool
+	DistanceCm  float64
+	HasDistance bool
+}
+
+// Probe is a forward-facing obstacle sensor. *Sensor is one.
+type Probe interface {
+	Poll() Reading
+}
+
+// SafetyState is what telemetry reports: Factor is how much of the
+// commanded forward motion gets through (1 = all of it) and Vetoed is set
+// while the layer is actually holding something back.
+type SafetyState struct {
+	Factor float64 `json:"factor"`
+	Vetoed bool    `json:"vetoed"`
+	Reason string  `json:"reason,omitempty"`
+}
+
+// Safety sits between Controls (keys, drive, macros) and the real motors.
+// Each wheel command is recorded, and the forward component of the combined
+// command (along mixer.Forward) is scaled by the obstacle factor before it
+// reaches the hardware. Turning and reversing are never restricted, so the
+// robot can always get itself out.
+type Safety struct {
+	motors []Motorer
+	probes []Probe
+	mixer  Mixer
+
+	mu       sync.Mutex
+	cmd      []float64 // signed duty as commanded
+	applied  []float64 // signed duty as sent to the motors
+	factor   float64
+	reason   string
+	vetoed   bool
+	distance float64
+	hasDist  bool
+	onVeto   func(SafetyState)
+}
+
+func NewSafety(motors []Motorer, probes []Probe) *Safety {
+	return &Safety{
+		motors:  motors,
+		probes:  probes,
+		mixer:   DefaultMixer,
+		cmd:     make([]float64, len(motors)),
+		applied: make([]float64, len(motors)),
+		factor:  1,
+	}
+}
+
+// Safe is the robot's safety layer, set up by Setup.
+var Safe *Safety
+
+// Motors returns Motorers that go through the safety layer.
+func (s *Safety) Motors() []Motorer {
+	out := make([]Motorer, len(s.motors))
+	for i := range s.motors {
+		out[i] = &guardedMotor{s: s, i: i}
+	}
+	return out
+}
+
+// OnVeto is called whenever the layer starts or stops holding motion back.
+func (s *Safety) OnVeto(fn func(SafetyState)) {
+	s.mu.Lock()
+	s.onVeto = fn
+	s.mu.Unlock()
+}
+
+func (s *Safety) State() SafetyState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return SafetyState{Factor: s.factor, Vetoed: s.vetoed, Reason: s.reason}
+}
+
+// Distance is the last ultrasonic reading, for telemetry.
+func (s *Safety) Distance() (float64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.distance, s.hasDist
+}
+
+// Poll reads every probe once and re-applies the current command.
+func (s *Safety) Poll() {
+	factor, reason := 1.0, ""
+	var dist float64
+	hasDist := false
+	for _, p := range s.probes {
+		r := p.Poll()
+		if r.HasDistance {
+			dist, hasDist = r.DistanceCm, true
+		}
+		f := 1.0
+		switch {
+		case r.Blocked:
+			f = 0
+		case r.HasDistance && r.DistanceCm < ObstacleSlowCm:
+			f = math.Max(0, (r.DistanceCm-ObstacleStopCm)/(ObstacleSlowCm-ObstacleStopCm))
+		}
+		if f < factor {
+			factor, reason = f, r.Name
+		}
+	}
+
+	s.mu.Lock()
+	s.factor, s.reason = factor, reason
+	s.distance, s.hasDist = dist, hasDist
+	s.mu.Unlock()
+	s.apply(-1)
+}
+
+func (s *Safety) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(SensorInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.Poll()
+		}
+	}
+}
+
+func (s *Safety) command(i int, duty float64) {
+	s.mu.Lock()
+	s.cmd[i] = duty
+	s.mu.Unlock()
+	s.apply(i)
+}
+
+// apply scales the forward part of the command and sends whatever changed,
+// plus wheel force (the one just commanded) so explicit stops always reach
+// the hardware.
+func (s *Safety) apply(force int) {
+	s.mu.Lock()
+	var forward, norm float64
+	for i, d := range s.cmd {
+		forward += d * s.mixer.Forward[i]
+		norm += math.Abs(s.mixer.Forward[i])
+	}
+	forward /= norm
+
+	out := append([]float64(nil), s.cmd...)
+	vetoed := forward > 0 && s.factor < 1
+	if vetoed {
+		for i := range out {
+			out[i] -= (1 - s.factor) * forward * s.mixer.Forward[i]
+		}
+	}
+	var changed []int
+	for i := range out {
+		if out[i] != s.applied[i] || i == force {
+			changed = append(changed, i)
+		}
+	}
+	s.applied = out
+
+	var notify func(SafetyState)
+	state := SafetyState{Factor: s.factor, Vetoed: vetoed, Reason: s.reason}
+	if vetoed != s.vetoed {
+		s.vetoed = vetoed
+		notify = s.onVeto
+		if vetoed {
+			log.Printf("⚠️  safety: %s vetoing forward motion (factor %.2f)", s.reason, s.factor)
+		}
+	}
+
+	// still holding mu so concurrent commands reach the motors in order
+	for _, i := range changed {
+		switch {
+		case out[i] > 0:
+			s.motors[i].Forward(out[i])
+		case out[i] < 0:
+			s.motors[i].Reverse(-out[i])
+		default:
+			s.motors[i].Stop()
+		}
+	}
+	s.mu.Unlock()
+
+	if notify != nil {
+		notify(state)
+	}
+}
+
+// guardedMotor is one wheel as seen from above the safety layer.
+type guardedMotor struct {
+	s *Safety
+	i int
+}
+
+func (m *guardedMotor) Forward(speed float64) { m.s.command(m.i, speed) }
+func (m *guardedMotor) Reverse(speed float64) { m.s.command(m.i, -speed) }
+func (m *guardedMotor) Stop()                 { m.s.command(m.i, 0) }
+func (m *guardedMotor) Test(state bool)       { m.s.motors[m.i].Test(state) }
+
+// SetupSensors returns the robot's obstacle sensors, or none without GPIO.
+func SetupSensors() []Probe {
+	if Board == nil {
+		return nil
+	}
+	return []Probe{
+		NewSensor(Board, "ULTRASONIC", ObstacleStopCm),
+		NewSensor(Board, "IR1", 0),
+		NewSensor(Board, "IR2", 0),
+	}
+}
@@ -0,0 +1,100 @@
+package client
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// simSensor is a probe whose reading the test sets.
+type simSensor struct {
+	mu sync.Mutex
+	r  Reading
+}
+
+func (s *simSensor) Poll() Reading {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.r
+}
+
+func (s *simSensor) set(r Reading) {
+	s.mu.Lock()
+	s.r = r
+	s.mu.Unlock()
+}
+
+func TestSafetyScalesForwardMotion(t *testing.T) {
+	defer func(a, b float64) { ObstacleStopCm, ObstacleSlowCm = a, b }(ObstacleStopCm, ObstacleSlowCm)
+	ObstacleStopCm, ObstacleSlowCm = 20, 50
+
+	fakes, raw := fakeRobot()
+	sonar := &simSensor{r: Reading{Name: "ULTRASONIC", DistanceCm: 200, HasDistance: true}}
+	s := NewSafety(raw, []Probe{sonar})
+	var events []SafetyState
+	s.OnVeto(func(st SafetyState) { events = append(events, st) })
+	handle := ControlsWith(NewBindingStore(DefaultBindings()), s.Motors(), &fakeServos{})
+
+	s.Poll()
+	handle(key("w", "pressed"))
+	if got := motorState(fakes); got != "R100 R100 F100 F100" {
+		t.Fatalf("clear path: %s", got)
+	}
+
+	// halfway into the slow zone: half speed
+	sonar.set(Reading{Name: "ULTRASONIC", DistanceCm: 35, HasDistance: true})
+	s.Poll()
+	if got := motorState(fakes); got != "R50 R50 F50 F50" {
+		t.Errorf("slow zone: %s", got)
+	}
+
+	// inside the boundary: stopped, and reported
+	sonar.set(Reading{Name: "ULTRASONIC", DistanceCm: 10, HasDistance: true})
+	s.Poll()
+	if got := motorState(fakes); got != "S S S S" {
+		t.Errorf("blocked: %s", got)
+	}
+	if st := s.State(); !st.Vetoed || st.Factor != 0 || st.Reason != "ULTRASONIC" {
+		t.Errorf("state %+v", st)
+	}
+	if len(events) != 1 || !events[0].Vetoed {
+		t.Errorf("veto events %+v", events)
+	}
+
+	// reversing and turning away are still allowed
+	handle(key("w", "released"))
+	handle(key("s", "pressed"))
+	if got := motorState(fakes); got != "F100 F100 R100 R100" {
+		t.Errorf("reverse while blocked: %s", got)
+	}
+	handle(key("s", "released"))
+	handle(key("a", "pressed"))
+	if got := motorState(fakes); got != "F100 R100 R100 F100" {
+		t.Errorf("turn while blocked: %s", got)
+	}
+	if len(events) != 2 || events[1].Vetoed {
+		t.Errorf("veto should clear once nothing is held back: %+v", events)
+	}
+}
+
+func TestSafetyIRBlocks(t *testing.T) {
+	fakes, raw := fakeRobot()
+	ir := &simSensor{r: Reading{Name: "IR1", Blocked: true}}
+	s := NewSafety(raw, []Probe{ir})
+	s.Poll()
+
+	d := NewDriveController(s.Motors(), nil, &fakeClock{now: time.Unix(0, 0)})
+	d.Command(DriveCommand{Linear: 1})
+	for i := 0; i < 50; i++ {
+		d.step(DriveTick)
+	}
+	if got := motorState(fakes); got != "S S S S" {
+		t.Errorf("drive through IR veto: %s", got)
+	}
+
+	ir.set(Reading{Name: "IR1"})
+	s.Poll()
+	if got := motorState(fakes); got != "R100 R100 F100 F100" {
+		t.Errorf("after IR clears: %s", got)
+	}
+}
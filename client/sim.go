@@ -0,0 +1,59 @@
+package client
+
+import (
+	"github.com/n0remac/robot-webrtc/hal"
+)
+
+// --- Simulator --------------------------------------------------------------
+
+// SimObstacles is the world cmd/client -sim starts in: a few posts around
+// the origin, in metres.
+var SimObstacles = []hal.Obstacle{
+	{X: 1.5, Y: 0, R: 0.2},
+	{X: 0, Y: 2, R: 0.3},
+	{X: -2, Y: -1, R: 0.4},
+}
+
+// NewSimBoard builds a simulated robot wired like the real one: the four
+// motors from motorConfigs, the ultrasonic sensor on BCM 5/6 and the IR
+// sensors on BCM 4/18. Which way each wheel pushes comes from DefaultMixer,
+// encoders come from MotorEncoders, and the robot is built to the
+// dimensions the speed controller assumes.
+func NewSimBoard() *hal.Sim {
+	return NewSimBoardWith(hal.MotorModel{})
+}
+
+// NewSimBoardWith is NewSimBoard with less than ideal motors.
+func NewSimBoardWith(model hal.MotorModel) *hal.Sim {
+	cfg := hal.SimConfig{
+		TrackWidth:    TrackWidth,
+		MaxWheelSpeed: MaxWheelSpeed,
+		Motor:         model,
+		TicksPerMetre: TicksPerMetre,
+		SonarTrigger:  5,
+		SonarEcho:     6,
+		SonarRange:    4,
+		IRPins:        []int{irPins["IR1"], irPins["IR2"]},
+		IRRange:       0.1,
+		Obstacles:     SimObstacles,
+		ServoPulseMin: 50,
+		ServoPulseMax: 650,
+	}
+	for i, name := range []string{"MOTOR1", "MOTOR2", "MOTOR3", "MOTOR4"} {
+		mc := motorConfigs[name][1]
+		push := DefaultMixer.Forward[i]
+		w := hal.SimWheel{
+			Enable:  mc.ePin,
+			Forward: mc.fPin,
+			Reverse: mc.rPin,
+			Push:    push,
+			Side:    DefaultMixer.Turn[i] * push,
+		}
+		w.Encoder = MotorEncoders[name].A
+		if sc := MotorSpeed[name]; sc.Kind == "pca9685" {
+			w.PCAAddr, w.PCAChannel = sc.Addr, sc.Channel
+		}
+		cfg.Wheels = append(cfg.Wheels, w)
+	}
+	return hal.NewSim(cfg)
+}
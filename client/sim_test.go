@@ -0,0 +1,161 @@
+package client
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/n0remac/robot-webrtc/hal"
+	"periph.io/x/devices/v3/pca9685"
+)
+
+// TestSimStack drives the simulated robot through the whole control stack
+// (bindings, safety layer, real Motor and Sensor code) into an obstacle.
+func TestSimStack(t *testing.T) {
+	defer func() { Board = nil }()
+	sim := NewSimBoard()
+	motors := SetupRobot(sim)
+	safety := NewSafety(motors, SetupSensors())
+	handle := ControlsWith(NewBindingStore(DefaultBindings()), safety.Motors(), &fakeServos{})
+
+	// the first post is 1.5m ahead, 0.2m in radius
+	handle(key("w", "pressed"))
+	for i := 0; i < 300; i++ {
+		safety.Poll()
+		sim.Step(20 * time.Millisecond)
+	}
+	p := sim.Pose()
+	if p.X < 0.9 || p.X > 1.3 || math.Abs(p.Y) > 1e-6 {
+		t.Fatalf("robot should stop short of the post, got %+v", p)
+	}
+	if d, ok := safety.Distance(); !ok || d > ObstacleStopCm+5 {
+		t.Errorf("sonar distance %v %v", d, ok)
+	}
+	if !safety.State().Vetoed {
+		t.Error("expected a veto")
+	}
+
+	// turning away is allowed
+	handle(key("w", "released"))
+	handle(key("a", "pressed"))
+	for i := 0; i < 5; i++ {
+		sim.Step(20 * time.Millisecond)
+	}
+	if th := sim.Pose().Theta; th < 0.5 || th > 1 {
+		t.Errorf("expected a left turn, theta %v", th)
+	}
+}
+
+func TestMotorSpeedPCA(t *testing.T) {
+	defer func() { Board, MotorSpeed = nil, map[string]SpeedConfig{} }()
+	speeds, err := ParseMotorSpeed("MOTOR1=pca9685:0x41:0, MOTOR2=pca9685:0x41:1,MOTOR3=pca9685:0x41:2,MOTOR4=pca9685:0x41:3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	MotorSpeed = speeds
+	sim := NewSimBoard()
+	motors := SetupRobot(sim)
+	for _, m := range motors {
+		if _, ok := m.(*Motor).pwm.(*hal.PCAChannel); !ok {
+			t.Fatalf("speed line is %T", m.(*Motor).pwm)
+		}
+	}
+
+	handle := ControlsWith(NewBindingStore(DefaultBindings()), motors, &fakeServos{})
+	handle(key("w", "pressed"))
+	if d := sim.Bus().Duty(0x41, 0); d != 100 {
+		t.Errorf("MOTOR1 duty %v", d)
+	}
+	for i := 0; i < 10; i++ {
+		sim.Step(20 * time.Millisecond)
+	}
+	if p := sim.Pose(); p.X < 0.09 {
+		t.Errorf("robot should move forward on PCA speed lines, got %+v", p)
+	}
+	handle(key("w", "released"))
+	if d := sim.Bus().Duty(0x41, 0); d != 0 {
+		t.Errorf("MOTOR1 duty after release %v", d)
+	}
+}
+
+func TestParseMotorSpeed(t *testing.T) {
+	got, err := ParseMotorSpeed("MOTOR1=pca9685:0x41:0:1500,MOTOR4=hardware:1000,MOTOR2=soft")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]SpeedConfig{
+		"MOTOR1": {Kind: "pca9685", Addr: 0x41, Channel: 0, Hz: 1500},
+		"MOTOR4": {Kind: "hardware", Hz: 1000},
+		"MOTOR2": {Kind: "soft"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v", got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s: got %+v, want %+v", k, got[k], v)
+		}
+	}
+	for _, bad := range []string{"MOTOR9=soft", "MOTOR1", "MOTOR1=pca9685:0x41", "MOTOR1=pca9685:0x41:16", "MOTOR1=laser", "MOTOR1=hardware:fast"} {
+		if _, err := ParseMotorSpeed(bad); err == nil {
+			t.Errorf("%q should not parse", bad)
+		}
+	}
+}
+
+func TestSensorFailsClosed(t *testing.T) {
+	// nothing in range: the echo outlasts the timeout and the way is clear
+	open := NewSimBoard()
+	open.SetPose(hal.Pose{Theta: math.Pi})
+	if r := NewSensor(open, "ULTRASONIC", ObstacleStopCm).Poll(); r.Blocked || r.HasDistance {
+		t.Errorf("out of range: %+v", r)
+	}
+
+	// no sonar wired up: the echo never starts
+	dead := hal.NewSim(hal.SimConfig{})
+	if r := NewSensor(dead, "ULTRASONIC", ObstacleStopCm).Poll(); !r.Blocked || r.HasDistance {
+		t.Errorf("no echo: %+v", r)
+	}
+}
+
+func TestIRPinsNeverHardwarePWM(t *testing.T) {
+	// hardware PWM only goes on enable pins, so no IR pin may be one
+	for name, cfgs := range motorConfigs {
+		for _, mc := range cfgs {
+			for ir, pin := range irPins {
+				if mc.ePin == pin {
+					t.Errorf("%s enable pin BCM%d is %s's input", name, pin, ir)
+				}
+			}
+		}
+	}
+}
+
+func TestMotorSpeedPCARefusesServoBoard(t *testing.T) {
+	config := ServoFiles.Config
+	defer func() { Board, MotorSpeed, ServoFiles.Config = nil, map[string]SpeedConfig{}, config }()
+	ServoFiles.Config = ""
+	speeds, err := ParseMotorSpeed("MOTOR1=pca9685:0x40:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	MotorSpeed = speeds
+	sim := NewSimBoard()
+	servos, err := sim.I2C()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pca, err := pca9685.NewI2C(servos, pca9685.I2CAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pca.SetPwm(4, 0, 300)
+
+	motors := SetupRobot(sim)
+	if _, ok := motors[0].(*Motor).pwm.(*hal.PCAChannel); ok {
+		t.Fatal("MOTOR1 took an output on the servo board")
+	}
+	if d := sim.Bus().Duty(0x40, 4); d == 0 {
+		t.Error("servo output turned off")
+	}
+}
@@ -0,0 +1,202 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	pb "github.com/n0remac/robot-webrtc/servo"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// --- Supervisor -------------------------------------------------------------
+
+// SupervisorInterval is how often the supervisor checks each component.
+var SupervisorInterval = 5 * time.Second
+
+// SupervisorFailures is how many checks in a row a component may fail
+// before the supervisor restarts it.
+var SupervisorFailures = 3
+
+// Component is a part of the robot the supervisor looks after. Check
+// returns nil while it's healthy; Restart gets it going again.
+type Component struct {
+	Name    string
+	Check   func(ctx context.Context) error
+	Restart func() error
+}
+
+// ComponentHealth is how a component is doing, as reported in telemetry
+// and on the local HTTP API.
+type ComponentHealth struct {
+	Name     string `json:"name"`
+	Healthy  bool   `json:"healthy"`
+	Error    string `json:"error,omitempty"` // why the last check failed
+	Since    int64  `json:"since"`           // unix ms it's been healthy or not
+	Restarts int    `json:"restarts,omitempty"`
+}
+
+// Supervisor checks the robot's components, restarts any that stay
+// unhealthy and reports how they are.
+type Supervisor struct {
+	components []Component
+	failures   int
+	clock      Clock
+
+	mu       sync.Mutex
+	health   []ComponentHealth // in the order of components
+	failed   []int             // checks failed in a row
+	onChange []func([]ComponentHealth)
+}
+
+func NewSupervisor(failures int, clock Clock, components ...Component) *Supervisor {
+	if clock == nil {
+		clock = realClock{}
+	}
+	s := &Supervisor{
+		components: components,
+		failures:   failures,
+		clock:      clock,
+		health:     make([]ComponentHealth, len(components)),
+		failed:     make([]int, len(components)),
+	}
+	now := clock.Now().UnixMilli()
+	for i, c := range components {
+		// healthy until shown otherwise, so start-up isn't reported as an outage
+		s.health[i] = ComponentHealth{Name: c.Name, Healthy: true, Since: now}
+	}
+	return s
+}
+
+// Health is the robot's supervisor, set up by Setup.
+var Health *Supervisor
+
+// OnChange registers fn to run whenever a component turns healthy or
+// unhealthy, or is restarted.
+func (s *Supervisor) OnChange(fn func([]ComponentHealth)) {
+	s.mu.Lock()
+	s.onChange = append(s.onChange, fn)
+	s.mu.Unlock()
+}
+
+func (s *Supervisor) State() []ComponentHealth {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ComponentHealth(nil), s.health...)
+}
+
+// Check checks every component once, restarting any that has now failed
+// SupervisorFailures checks in a row.
+func (s *Supervisor) Check() {
+	for i, c := range s.components {
+		ctx, cancel := context.WithTimeout(context.Background(), SupervisorInterval/2)
+		err := c.Check(ctx)
+		cancel()
+
+		s.mu.Lock()
+		h := &s.health[i]
+		changed := h.Healthy != (err == nil)
+		if changed {
+			h.Healthy = err == nil
+			h.Since = s.clock.Now().UnixMilli()
+		}
+		restart := false
+		if err == nil {
+			h.Error = ""
+			s.failed[i] = 0
+		} else {
+			h.Error = err.Error()
+			s.failed[i]++
+			if s.failed[i] >= s.failures {
+				restart = true
+				s.failed[i] = 0
+				h.Restarts++
+			}
+		}
+		s.mu.Unlock()
+
+		if changed && err == nil {
+			log.Printf("💚 %s is healthy", c.Name)
+		} else if changed {
+			log.Printf("💔 %s is unhealthy: %v", c.Name, err)
+		}
+		if restart {
+			log.Printf("🔁 restarting %s", c.Name)
+			if rerr := c.Restart(); rerr != nil {
+				log.Printf("restarting %s: %v", c.Name, rerr)
+			}
+		}
+		if changed || restart {
+			s.changed()
+		}
+	}
+}
+
+func (s *Supervisor) changed() {
+	s.mu.Lock()
+	hooks := append([]func([]ComponentHealth){}, s.onChange...)
+	s.mu.Unlock()
+	state := s.State()
+	for _, fn := range hooks {
+		fn(state)
+	}
+}
+
+func (s *Supervisor) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.Check()
+		}
+	}
+}
+
+// Handler serves GET /health: every component's health, with status 503
+// while any is unhealthy.
+func (s *Supervisor) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, req *http.Request) {
+		state := s.State()
+		w.Header().Set("Content-Type", "application/json")
+		for _, h := range state {
+			if !h.Healthy {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				break
+			}
+		}
+		json.NewEncoder(w).Encode(state)
+	})
+	return mux
+}
+
+// ServoComponent checks the servo server's health service over conn and
+// restarts it with restart.
+func ServoComponent(conn *grpc.ClientConn, restart func() error) Component {
+	hc := healthpb.NewHealthClient(conn)
+	return Component{
+		Name: "servo",
+		Check: func(ctx context.Context) error {
+			r, err := hc.Check(ctx, &healthpb.HealthCheckRequest{Service: pb.Controller_ServiceDesc.ServiceName})
+			if err != nil {
+				return err
+			}
+			if r.Status != healthpb.HealthCheckResponse_SERVING {
+				return fmt.Errorf("servo server is %s", r.Status)
+			}
+			return nil
+		},
+		Restart: restart,
+	}
+}
@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSupervisorRestarts(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	var down error
+	restarts := 0
+	flaky := Component{
+		Name:    "servo",
+		Check:   func(context.Context) error { return down },
+		Restart: func() error { restarts++; return nil },
+	}
+	steady := Component{
+		Name:    "signalling",
+		Check:   func(context.Context) error { return nil },
+		Restart: func() error { t.Error("restarted a healthy component"); return nil },
+	}
+	s := NewSupervisor(3, clock, flaky, steady)
+	changes := 0
+	s.OnChange(func([]ComponentHealth) { changes++ })
+
+	s.Check()
+	if st := s.State(); !st[0].Healthy || !st[1].Healthy || changes != 0 {
+		t.Fatalf("state %+v, %d changes", st, changes)
+	}
+
+	down = errors.New("connection refused")
+	clock.Advance(5 * time.Second)
+	s.Check()
+	s.Check()
+	if st := s.State()[0]; st.Healthy || st.Error != "connection refused" || st.Since != 5000 || restarts != 0 {
+		t.Fatalf("after 2 failures %+v, %d restarts", st, restarts)
+	}
+	s.Check()
+	if restarts != 1 || s.State()[0].Restarts != 1 {
+		t.Fatalf("%d restarts after 3 failures", restarts)
+	}
+	// the count starts again after a restart
+	s.Check()
+	s.Check()
+	if restarts != 1 {
+		t.Errorf("%d restarts", restarts)
+	}
+
+	down = nil
+	s.Check()
+	if st := s.State()[0]; !st.Healthy || st.Error != "" || st.Restarts != 1 {
+		t.Errorf("recovered %+v", st)
+	}
+	// unhealthy, restarted, healthy again
+	if changes != 3 {
+		t.Errorf("%d changes", changes)
+	}
+}
+
+func TestSupervisorHandler(t *testing.T) {
+	var down error
+	s := NewSupervisor(3, nil, Component{
+		Name:    "servo",
+		Check:   func(context.Context) error { return down },
+		Restart: func() error { return nil },
+	})
+	get := func() (int, []ComponentHealth) {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/health", nil))
+		var st []ComponentHealth
+		if err := json.Unmarshal(rec.Body.Bytes(), &st); err != nil {
+			t.Fatal(err)
+		}
+		return rec.Code, st
+	}
+
+	if code, st := get(); code != http.StatusOK || len(st) != 1 || !st[0].Healthy {
+		t.Errorf("healthy: %d %+v", code, st)
+	}
+	down = errors.New("not serving")
+	s.Check()
+	if code, st := get(); code != http.StatusServiceUnavailable || st[0].Error != "not serving" {
+		t.Errorf("unhealthy: %d %+v", code, st)
+	}
+}
@@ -0,0 +1,463 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pb "github.com/n0remac/robot-webrtc/servo"
+	"github.com/pion/webrtc/v4"
+)
+
+// --- Telemetry --------------------------------------------------------------
+
+// TelemetryInterval is how often operators get a telemetry message on the
+// "telemetry" data channel. cmd/client sets it from -telemetry-hz.
+var TelemetryInterval = 500 * time.Millisecond
+
+// CPUTempPath is the Linux thermal zone read for the CPU temperature.
+var CPUTempPath = "/sys/class/thermal/thermal_zone0/temp"
+
+// Telemetry is one message on the telemetry channel. Fields the robot can't
+// read (no sensor, no thermal zone) are left out.
+type Telemetry struct {
+	Type       string            `json:"type"` // "telemetry"
+	Time       int64             `json:"time"` // unix ms
+	Servos     []ServoTel        `json:"servos"`
+	Motors     []MotorState      `json:"motors"`
+	DistanceCm *float64          `json:"distanceCm,omitempty"`
+	CPUTempC   *float64          `json:"cpuTempC,omitempty"`
+	Link       *LinkStats        `json:"link,omitempty"`
+	Safety     *SafetyState      `json:"safety,omitempty"`
+	Pose       *Pose             `json:"pose,omitempty"`
+	Mission    *MissionStatus    `json:"mission,omitempty"`
+	Control    *LeaseState       `json:"control,omitempty"`
+	Health     []ComponentHealth `json:"health,omitempty"`
+}
+
+// ServoTel is one servo. Target, Moving, Limit and Stopped come from the
+// servo server's state stream and are left out while it is down.
+type ServoTel struct {
+	Channel int32    `json:"channel"`
+	Name    string   `json:"name,omitempty"` // from the servo server's config
+	Angle   float32  `json:"angle"`
+	Target  *float32 `json:"target,omitempty"`
+	Moving  bool     `json:"moving,omitempty"`
+	Limit   string   `json:"limit,omitempty"`   // "min" or "max" when sitting on one
+	Stopped string   `json:"stopped,omitempty"` // why the last move ended, if the server stopped it
+}
+
+// stopReasons names the ways the servo server stops a move on its own.
+var stopReasons = map[pb.StopReason]string{
+	pb.StopReason_STOP_LIMIT:         "limit",
+	pb.StopReason_STOP_TIMEOUT:       "timeout",
+	pb.StopReason_STOP_LEASE_EXPIRED: "lease expired",
+	pb.StopReason_STOP_EMERGENCY:     "emergency stop",
+}
+
+// LinkStats describes the selected ICE candidate pair to one operator.
+type LinkStats struct {
+	RTTMs         float64 `json:"rttMs"`
+	BytesSent     uint64  `json:"bytesSent"`
+	BytesReceived uint64  `json:"bytesReceived"`
+	OutgoingKbps  float64 `json:"outgoingKbps"`
+}
+
+// --- Motor state ------------------------------------------------------------
+
+// MotorState is the last command a motor was given. Direction is 1 forward,
+// -1 reverse, 0 stopped.
+type MotorState struct {
+	Duty      float64 `json:"duty"`
+	Direction int     `json:"direction"`
+}
+
+// trackedMotor remembers what it was last told so telemetry can report it.
+type trackedMotor struct {
+	Motorer
+	mu    sync.Mutex
+	state MotorState
+}
+
+// TrackMotors wraps motors so their state shows up in telemetry.
+func TrackMotors(motors []Motorer) []Motorer {
+	out := make([]Motorer, len(motors))
+	for i, m := range motors {
+		out[i] = &trackedMotor{Motorer: m}
+	}
+	return out
+}
+
+func (m *trackedMotor) Forward(speed float64) {
+	m.set(MotorState{Duty: speed, Direction: 1})
+	m.Motorer.Forward(speed)
+}
+
+func (m *trackedMotor) Reverse(speed float64) {
+	m.set(MotorState{Duty: speed, Direction: -1})
+	m.Motorer.Reverse(speed)
+}
+
+func (m *trackedMotor) Stop() {
+	m.set(MotorState{})
+	m.Motorer.Stop()
+}
+
+func (m *trackedMotor) set(s MotorState) {
+	m.mu.Lock()
+	m.state = s
+	m.mu.Unlock()
+}
+
+func (m *trackedMotor) State() MotorState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state
+}
+
+// --- Servo state ------------------------------------------------------------
+
+// ServoWatchRate caps how many servo state updates a second the servo
+// server pushes.
+var ServoWatchRate = 10.0
+
+// ServoWatcher follows the servo server's WatchState stream, so telemetry
+// can report where the servos are and whether they're moving without
+// polling. It reconnects whenever the stream drops.
+type ServoWatcher struct {
+	servoClient pb.ControllerClient
+
+	mu       sync.Mutex
+	states   []ServoTel
+	live     bool
+	onChange []func()
+}
+
+func NewServoWatcher(servoClient pb.ControllerClient) *ServoWatcher {
+	return &ServoWatcher{servoClient: servoClient}
+}
+
+// Servos is the robot's servo watcher, set up by Setup. Its methods do
+// nothing on nil.
+var Servos *ServoWatcher
+
+// OnChange registers fn to run after every update from the stream.
+func (w *ServoWatcher) OnChange(fn func()) {
+	w.mu.Lock()
+	w.onChange = append(w.onChange, fn)
+	w.mu.Unlock()
+}
+
+// States returns the latest servo state, and whether the stream is up.
+func (w *ServoWatcher) States() ([]ServoTel, bool) {
+	if w == nil {
+		return nil, false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]ServoTel(nil), w.states...), w.live
+}
+
+func (w *ServoWatcher) Run(stop <-chan struct{}) {
+	if w == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	for {
+		err := w.watch(ctx)
+		w.mu.Lock()
+		w.live = false
+		w.mu.Unlock()
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("servo state stream: %v; retrying in 1s", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (w *ServoWatcher) watch(ctx context.Context) error {
+	stream, err := w.servoClient.WatchState(ctx, &pb.WatchStateRequest{MaxRateHz: ServoWatchRate})
+	if err != nil {
+		return err
+	}
+	for {
+		st, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		states := make([]ServoTel, 0, len(st.GetChannels()))
+		for _, c := range st.GetChannels() {
+			target := float32(c.Target)
+			tel := ServoTel{Channel: c.Channel, Name: c.Name, Angle: float32(c.Angle), Target: &target, Moving: c.Moving, Stopped: stopReasons[c.Stopped]}
+			switch c.AtLimit {
+			case pb.Limit_LIMIT_MIN:
+				tel.Limit = "min"
+			case pb.Limit_LIMIT_MAX:
+				tel.Limit = "max"
+			}
+			states = append(states, tel)
+		}
+		w.mu.Lock()
+		w.states, w.live = states, true
+		hooks := append([]func(){}, w.onChange...)
+		w.mu.Unlock()
+		for _, fn := range hooks {
+			fn()
+		}
+	}
+}
+
+// --- Publisher --------------------------------------------------------------
+
+// textSender and statsGetter are the parts of a DataChannel and a
+// PeerConnection telemetry needs.
+type textSender interface {
+	SendText(string) error
+}
+
+type statsGetter interface {
+	GetStats() webrtc.StatsReport
+}
+
+type telemetryPeer struct {
+	dc textSender
+	pc statsGetter
+}
+
+// TelemetryPublisher sends a Telemetry message to every operator.
+type TelemetryPublisher struct {
+	motors      []Motorer
+	servoClient pb.ControllerClient
+
+	// Distance returns the latest ultrasonic reading in cm, if there is one.
+	Distance func() (float64, bool)
+	// Safety reports whether the obstacle layer is holding motion back.
+	Safety func() SafetyState
+	// Pose returns the odometry estimate, if the wheels have encoders.
+	Pose func() (Pose, bool)
+	// Mission reports the mission runner's progress.
+	Mission func() MissionStatus
+	// Control reports who holds the driving lease.
+	Control func() LeaseState
+	// Health reports how the supervised components are doing.
+	Health func() []ComponentHealth
+	// Servos returns streamed servo state, if the stream is up. Without it
+	// Snapshot asks the servo server with GetAngles.
+	Servos func() ([]ServoTel, bool)
+
+	mu      sync.Mutex
+	peers   map[string]telemetryPeer
+	changed chan struct{} // Changed's wake-up for Run; holds at most one
+}
+
+func NewTelemetryPublisher(motors []Motorer, servoClient pb.ControllerClient) *TelemetryPublisher {
+	return &TelemetryPublisher{
+		motors:      motors,
+		servoClient: servoClient,
+		peers:       map[string]telemetryPeer{},
+		changed:     make(chan struct{}, 1),
+	}
+}
+
+// Telem is the robot's publisher, set up by Setup. Its methods do nothing
+// on nil.
+var Telem *TelemetryPublisher
+
+// Add starts sending telemetry to peerID. pc may be nil to skip link stats.
+func (t *TelemetryPublisher) Add(peerID string, dc textSender, pc statsGetter) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.peers[peerID] = telemetryPeer{dc: dc, pc: pc}
+	t.mu.Unlock()
+}
+
+func (t *TelemetryPublisher) Remove(peerID string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	delete(t.peers, peerID)
+	t.mu.Unlock()
+}
+
+// Snapshot collects everything but the per-peer link stats.
+func (t *TelemetryPublisher) Snapshot() Telemetry {
+	msg := Telemetry{Type: "telemetry", Time: time.Now().UnixMilli()}
+
+	for _, m := range t.motors {
+		var s MotorState
+		if tm, ok := m.(interface{ State() MotorState }); ok {
+			s = tm.State()
+		}
+		msg.Motors = append(msg.Motors, s)
+	}
+
+	var streamed bool
+	if t.Servos != nil {
+		var servos []ServoTel
+		if servos, streamed = t.Servos(); streamed {
+			msg.Servos = servos
+		}
+	}
+	if !streamed && t.servoClient != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		reply, err := t.servoClient.GetAngles(ctx, &pb.GetAnglesRequest{})
+		cancel()
+		if err != nil {
+			log.Printf("telemetry: GetAngles RPC error: %v", err)
+		} else {
+			for _, a := range reply.GetAngles() {
+				msg.Servos = append(msg.Servos, ServoTel{Channel: a.Channel, Name: a.Name, Angle: a.Angle})
+			}
+			sort.Slice(msg.Servos, func(i, j int) bool { return msg.Servos[i].Channel < msg.Servos[j].Channel })
+		}
+	}
+
+	if t.Distance != nil {
+		if d, ok := t.Distance(); ok {
+			msg.DistanceCm = &d
+		}
+	}
+	if t.Safety != nil {
+		st := t.Safety()
+		msg.Safety = &st
+	}
+	if t.Pose != nil {
+		if p, ok := t.Pose(); ok {
+			msg.Pose = &p
+		}
+	}
+	if t.Mission != nil {
+		if m := t.Mission(); m.State != "idle" {
+			msg.Mission = &m
+		}
+	}
+	if t.Control != nil {
+		c := t.Control()
+		msg.Control = &c
+	}
+	if t.Health != nil {
+		msg.Health = t.Health()
+	}
+	if c, ok := cpuTemp(); ok {
+		msg.CPUTempC = &c
+	}
+	return msg
+}
+
+// Publish sends one round of telemetry.
+func (t *TelemetryPublisher) Publish() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	peers := make(map[string]telemetryPeer, len(t.peers))
+	for id, p := range t.peers {
+		peers[id] = p
+	}
+	t.mu.Unlock()
+	if len(peers) == 0 {
+		return
+	}
+
+	msg := t.Snapshot()
+	for id, p := range peers {
+		msg.Link = nil
+		if p.pc != nil {
+			msg.Link = linkStats(p.pc.GetStats())
+		}
+		raw, err := json.Marshal(msg)
+		if err != nil {
+			log.Printf("telemetry: marshal: %v", err)
+			return
+		}
+		if err := p.dc.SendText(string(raw)); err != nil {
+			log.Printf("telemetry: send to %s: %v", id, err)
+		}
+	}
+}
+
+// Changed asks Run for a snapshot before the next interval is up. Calls
+// between two publishes coalesce into one.
+func (t *TelemetryPublisher) Changed() {
+	if t == nil {
+		return
+	}
+	select {
+	case t.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Run publishes every interval, and on Changed straight away if a whole
+// interval has passed since the last publish. Either way there's at most
+// one snapshot per interval, all sent from here and so in order.
+func (t *TelemetryPublisher) Run(interval time.Duration, stop <-chan struct{}) {
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	last := time.Now()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.changed:
+			if time.Since(last) < interval {
+				continue // the next tick carries it
+			}
+		case <-timer.C:
+		}
+		t.Publish()
+		last = time.Now()
+		timer.Reset(interval)
+	}
+}
+
+func linkStats(report webrtc.StatsReport) *LinkStats {
+	for _, s := range report {
+		pair, ok := s.(webrtc.ICECandidatePairStats)
+		if !ok || !pair.Nominated || pair.State != webrtc.StatsICECandidatePairStateSucceeded {
+			continue
+		}
+		return &LinkStats{
+			RTTMs:         pair.CurrentRoundTripTime * 1000,
+			BytesSent:     pair.BytesSent,
+			BytesReceived: pair.BytesReceived,
+			OutgoingKbps:  pair.AvailableOutgoingBitrate / 1000,
+		}
+	}
+	return nil
+}
+
+// cpuTemp reads millidegrees Celsius from CPUTempPath.
+func cpuTemp() (float64, bool) {
+	raw, err := os.ReadFile(CPUTempPath)
+	if err != nil {
+		return 0, false
+	}
+	milli, err := strconv.ParseFloat(strings.TrimSpace(string(raw)), 64)
+	if err != nil {
+		return 0, false
+	}
+	return milli / 1000, true
+}
@@ -0,0 +1,184 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/n0remac/robot-webrtc/servo"
+	"github.com/pion/webrtc/v4"
+	"google.golang.org/grpc"
+)
+
+type fakeChannel struct {
+	mu   sync.Mutex
+	sent []string
+}
+
+func (c *fakeChannel) SendText(s string) error {
+	c.mu.Lock()
+	c.sent = append(c.sent, s)
+	c.mu.Unlock()
+	return nil
+}
+
+type fakeStats webrtc.StatsReport
+
+func (f fakeStats) GetStats() webrtc.StatsReport { return webrtc.StatsReport(f) }
+
+func TestTelemetryPublish(t *testing.T) {
+	defer func(p string) { CPUTempPath = p }(CPUTempPath)
+	CPUTempPath = filepath.Join(t.TempDir(), "temp")
+	os.WriteFile(CPUTempPath, []byte("48312\n"), 0o644)
+
+	_, raw := fakeRobot()
+	motors := TrackMotors(raw)
+	motors[0].Forward(40)
+	motors[2].Reverse(70)
+
+	pub := NewTelemetryPublisher(motors, &fakeServos{})
+	pub.Distance = func() (float64, bool) { return 23.5, true }
+
+	ch := &fakeChannel{}
+	pub.Add("op1", ch, fakeStats{"pair": webrtc.ICECandidatePairStats{
+		Nominated:            true,
+		State:                webrtc.StatsICECandidatePairStateSucceeded,
+		CurrentRoundTripTime: 0.042,
+		BytesSent:            1000,
+	}})
+	pub.Publish()
+
+	if len(ch.sent) != 1 {
+		t.Fatalf("sent %d messages", len(ch.sent))
+	}
+	var got Telemetry
+	if err := json.Unmarshal([]byte(ch.sent[0]), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Type != "telemetry" || got.Motors[0] != (MotorState{40, 1}) || got.Motors[2] != (MotorState{70, -1}) || got.Motors[1] != (MotorState{}) {
+		t.Errorf("motors %+v", got.Motors)
+	}
+	if got.DistanceCm == nil || *got.DistanceCm != 23.5 {
+		t.Errorf("distance %v", got.DistanceCm)
+	}
+	if got.CPUTempC == nil || *got.CPUTempC != 48.312 {
+		t.Errorf("cpu temp %v", got.CPUTempC)
+	}
+	if got.Link == nil || got.Link.RTTMs != 42 || got.Link.BytesSent != 1000 {
+		t.Errorf("link %+v", got.Link)
+	}
+
+	pub.Remove("op1")
+	pub.Publish()
+	if len(ch.sent) != 1 {
+		t.Error("sent to a removed peer")
+	}
+}
+
+func TestTelemetryChangedCoalesces(t *testing.T) {
+	_, raw := fakeRobot()
+	pub := NewTelemetryPublisher(TrackMotors(raw), &fakeServos{})
+	ch := &fakeChannel{}
+	pub.Add("op1", ch, nil)
+
+	const interval = 100 * time.Millisecond
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() { pub.Run(interval, stop); close(done) }()
+
+	// a servo moving at the watcher's rate, and a little faster
+	start := time.Now()
+	for time.Since(start) < 5*interval/2 {
+		pub.Changed()
+		time.Sleep(2 * time.Millisecond)
+	}
+	close(stop)
+	<-done
+
+	ch.mu.Lock()
+	n := len(ch.sent)
+	ch.mu.Unlock()
+	if n < 2 || n > 3 {
+		t.Errorf("%d snapshots in 2.5 intervals, want one per interval", n)
+	}
+}
+
+// watchedServos streams whatever is put on states, ending the stream when
+// it closes.
+type watchedServos struct {
+	fakeServos
+	states chan *pb.ServoState
+}
+
+type fakeStateStream struct {
+	grpc.ClientStream
+	ctx    context.Context
+	states chan *pb.ServoState
+}
+
+func (f *watchedServos) WatchState(ctx context.Context, _ *pb.WatchStateRequest, _ ...grpc.CallOption) (grpc.ServerStreamingClient[pb.ServoState], error) {
+	return &fakeStateStream{ctx: ctx, states: f.states}, nil
+}
+
+func (f *fakeStateStream) Recv() (*pb.ServoState, error) {
+	select {
+	case st, ok := <-f.states:
+		if !ok {
+			return nil, io.EOF
+		}
+		return st, nil
+	case <-f.ctx.Done():
+		return nil, f.ctx.Err()
+	}
+}
+
+func TestServoWatcher(t *testing.T) {
+	servos := &watchedServos{states: make(chan *pb.ServoState)}
+	w := NewServoWatcher(servos)
+	updates := make(chan struct{}, 10)
+	w.OnChange(func() { updates <- struct{}{} })
+	stop := make(chan struct{})
+	defer close(stop)
+	go w.Run(stop)
+
+	if _, live := w.States(); live {
+		t.Error("live before the first update")
+	}
+	servos.states <- &pb.ServoState{Channels: []*pb.ChannelState{
+		{Channel: 4, Angle: 50, Target: 140, Moving: true},
+		{Channel: 6, Angle: 15, Target: 15, AtLimit: pb.Limit_LIMIT_MIN},
+	}}
+	select {
+	case <-updates:
+	case <-time.After(time.Second):
+		t.Fatal("no OnChange")
+	}
+
+	pub := NewTelemetryPublisher(nil, servos)
+	pub.Servos = w.States
+	got := pub.Snapshot().Servos
+	if len(got) != 2 || got[0].Angle != 50 || *got[0].Target != 140 || !got[0].Moving || got[1].Limit != "min" || got[1].Moving {
+		t.Errorf("servos %+v", got)
+	}
+
+	// the stream dropping falls back to GetAngles until it's back
+	close(servos.states)
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, live := w.States(); !live {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("still live after the stream ended")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if got := pub.Snapshot().Servos; len(got) != 0 {
+		t.Errorf("stale servos %+v", got)
+	}
+}
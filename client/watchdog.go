@@ -0,0 +1,263 @@
+package client
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	pb "github.com/n0remac/robot-webrtc/servo"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// --- Dead-man's switch ------------------------------------------------------
+
+// HeartbeatTimeout is how long the robot keeps moving without hearing from
+// the operator. robot-control.js sends {"action":"heartbeat"} on the
+// keyboard channel every 250ms while it is open.
+var HeartbeatTimeout = time.Second
+
+// servoChannels is every channel on the first PCA9685, which a halt stops
+// when the servo server can't say which channels it has.
+const servoChannels = 16
+
+// Clock lets tests drive the watchdog without sleeping.
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+// Watchdog stops every motor and servo when operator messages stop arriving
+// or the peer connection drops. It only halts once per burst of activity, so
+// an idle robot isn't spammed with Stop calls.
+type Watchdog struct {
+	motors      []Motorer
+	servoClient pb.ControllerClient
+	timeout     time.Duration
+	clock       Clock
+
+	mu     sync.Mutex
+	last   time.Time
+	active bool // something may be moving since the last halt
+	onHalt []func()
+}
+
+func NewWatchdog(motors []Motorer, servoClient pb.ControllerClient, timeout time.Duration, clock Clock) *Watchdog {
+	if clock == nil {
+		clock = realClock{}
+	}
+	return &Watchdog{
+		motors:      motors,
+		servoClient: servoClient,
+		timeout:     timeout,
+		clock:       clock,
+		last:        clock.Now(),
+	}
+}
+
+// Deadman is the robot's watchdog, set up by Setup. Its methods are no-ops
+// on a nil Watchdog so Controls works without one.
+var Deadman *Watchdog
+
+// OnHalt registers fn to run at the start of every halt, for controllers
+// that would otherwise put the motors back to work.
+func (w *Watchdog) OnHalt(fn func()) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	w.onHalt = append(w.onHalt, fn)
+	w.mu.Unlock()
+}
+
+// Beat records a heartbeat or any other operator message.
+func (w *Watchdog) Beat() {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	w.last = w.clock.Now()
+	w.active = true
+	w.mu.Unlock()
+}
+
+// Check halts the robot if the last beat is older than the timeout. It
+// reports whether it halted.
+func (w *Watchdog) Check() bool {
+	if w == nil {
+		return false
+	}
+	w.mu.Lock()
+	expired := w.active && w.clock.Now().Sub(w.last) > w.timeout
+	w.mu.Unlock()
+	if !expired {
+		return false
+	}
+	w.Halt("no heartbeat for " + w.timeout.String())
+	return true
+}
+
+// Halt stops everything immediately.
+func (w *Watchdog) Halt(reason string) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	if !w.active {
+		w.mu.Unlock()
+		return
+	}
+	w.active = false
+	hooks := append([]func(){}, w.onHalt...)
+	w.mu.Unlock()
+
+	log.Printf("🛑 watchdog: %s; stopping all motion", reason)
+	for _, fn := range hooks {
+		fn()
+	}
+	for _, m := range w.motors {
+		m.Stop()
+	}
+	if w.servoClient == nil {
+		return
+	}
+	for _, ch := range haltChannels(w.servoClient) {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		if _, err := w.servoClient.Stop(ctx, &pb.StopRequest{Channel: ch}); err != nil {
+			log.Printf("watchdog: servo %d Stop RPC error: %v", ch, err)
+		}
+		cancel()
+	}
+}
+
+// haltChannels is every channel the servo server drives, so a halt stops
+// them all rather than guessing which ones a key or macro started.
+func haltChannels(servoClient pb.ControllerClient) []int32 {
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	var chs []int32
+	if reply, err := servoClient.GetAngles(ctx, &pb.GetAnglesRequest{}); err == nil {
+		for _, a := range reply.GetAngles() {
+			chs = append(chs, a.Channel)
+		}
+	}
+	if len(chs) == 0 {
+		for ch := int32(0); ch < servoChannels; ch++ {
+			chs = append(chs, ch)
+		}
+	}
+	return chs
+}
+
+// Run checks the watchdog a few times per timeout until stop is closed.
+func (w *Watchdog) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(w.timeout / 4)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			w.Check()
+		}
+	}
+}
+
+// --- Servo move leases ------------------------------------------------------
+
+// ServoMoveLease is how long a servo Move from the robot outlives the
+// robot: the servo server stops it unless renewed this often. cmd/client
+// sets it from -servo-lease; zero turns leases off.
+var ServoMoveLease = time.Second
+
+// MoveLeaser wraps a servo client so every Move it sends is leased, and
+// keeps renewing the leases until the channel is stopped. If the robot
+// client dies mid-press, the renewals stop and so does the servo.
+type MoveLeaser struct {
+	pb.ControllerClient
+	ttl time.Duration
+
+	mu     sync.Mutex
+	moving map[int32]bool
+	names  map[string]int32 // channels moved by name
+}
+
+func NewMoveLeaser(servoClient pb.ControllerClient, ttl time.Duration) *MoveLeaser {
+	return &MoveLeaser{ControllerClient: servoClient, ttl: ttl, moving: map[int32]bool{}, names: map[string]int32{}}
+}
+
+func (l *MoveLeaser) Move(ctx context.Context, in *pb.MoveRequest, opts ...grpc.CallOption) (*pb.MoveReply, error) {
+	if in.LeaseMs == 0 {
+		in = proto.Clone(in).(*pb.MoveRequest)
+		in.LeaseMs = uint32(l.ttl.Milliseconds())
+	}
+	reply, err := l.ControllerClient.Move(ctx, in, opts...)
+	if err == nil && reply.GetOk() {
+		// renewals go by number, so keep the one the server found for a name
+		l.mu.Lock()
+		ch := in.Channel
+		if in.Name != "" {
+			ch = reply.Channel
+			l.names[in.Name] = ch
+		}
+		l.moving[ch] = true
+		l.mu.Unlock()
+	}
+	return reply, err
+}
+
+func (l *MoveLeaser) Stop(ctx context.Context, in *pb.StopRequest, opts ...grpc.CallOption) (*pb.StopReply, error) {
+	l.mu.Lock()
+	ch := in.Channel
+	if in.Name != "" {
+		ch = l.names[in.Name]
+	}
+	delete(l.moving, ch)
+	l.mu.Unlock()
+	return l.ControllerClient.Stop(ctx, in, opts...)
+}
+
+// Renew renews every running Move's lease once, forgetting channels the
+// server has already stopped.
+func (l *MoveLeaser) Renew() {
+	l.mu.Lock()
+	chs := make([]int32, 0, len(l.moving))
+	for ch := range l.moving {
+		chs = append(chs, ch)
+	}
+	l.mu.Unlock()
+	if len(chs) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), l.ttl/2)
+	defer cancel()
+	reply, err := l.ControllerClient.RenewMove(ctx, &pb.RenewMoveRequest{Channels: chs})
+	if err != nil {
+		log.Printf("servo lease renewal: %v", err)
+		return
+	}
+	l.mu.Lock()
+	for _, ch := range reply.NotMoving {
+		delete(l.moving, ch)
+	}
+	l.mu.Unlock()
+}
+
+// Run renews leases three times per lease period.
+func (l *MoveLeaser) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			l.Renew()
+		}
+	}
+}
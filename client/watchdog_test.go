@@ -0,0 +1,160 @@
+package client
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/n0remac/robot-webrtc/servo"
+	"github.com/pion/webrtc/v4"
+	"google.golang.org/grpc"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+func TestWatchdogStopsOnSilence(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	fakes, motors := fakeRobot()
+	servos := &fakeServos{}
+	Deadman = NewWatchdog(motors, servos, time.Second, clock)
+	defer func() { Deadman = nil }()
+
+	handle := ControlsWith(NewBindingStore(DefaultBindings()), motors, servos)
+	handle(key("w", "pressed"))
+
+	for i := 0; i < 8; i++ {
+		clock.Advance(250 * time.Millisecond)
+		handle(webrtcMsg(`{"action":"heartbeat"}`))
+		if Deadman.Check() {
+			t.Fatal("halted while heartbeats were arriving")
+		}
+	}
+	if got := motorState(fakes); got != "R100 R100 F100 F100" {
+		t.Fatalf("motors %s", got)
+	}
+
+	clock.Advance(1500 * time.Millisecond)
+	if !Deadman.Check() {
+		t.Fatal("expected a halt after a silent second")
+	}
+	if got := motorState(fakes); got != "S S S S" {
+		t.Errorf("motors after halt %s", got)
+	}
+	if n := len(servos.log()); n != servoChannels {
+		t.Errorf("expected %d servo stops, got %d", servoChannels, n)
+	}
+
+	// once halted it stays quiet until the operator is back
+	clock.Advance(time.Hour)
+	if Deadman.Check() {
+		t.Error("halted twice without new activity")
+	}
+}
+
+func TestWatchdogHaltOnDisconnect(t *testing.T) {
+	fakes, motors := fakeRobot()
+	w := NewWatchdog(motors, nil, time.Second, &fakeClock{})
+	w.Halt("idle")
+	if got := motorState(fakes); got != "   " {
+		t.Fatalf("idle robot was stopped: %q", got)
+	}
+	w.Beat()
+	w.Halt("peer failed")
+	if got := motorState(fakes); got != "S S S S" {
+		t.Errorf("motors %s", got)
+	}
+}
+
+func webrtcMsg(s string) webrtc.DataChannelMessage {
+	return webrtc.DataChannelMessage{Data: []byte(s)}
+}
+
+// leaseServos remembers lease requests and reports channels in gone as no
+// longer moving. It knows channel 14 as camera.pan.
+type leaseServos struct {
+	fakeServos
+	leases  map[int32]uint32
+	renewed [][]int32
+	gone    map[int32]bool
+}
+
+func (f *leaseServos) Move(ctx context.Context, in *pb.MoveRequest, opts ...grpc.CallOption) (*pb.MoveReply, error) {
+	ch := in.Channel
+	if in.Name == "camera.pan" {
+		ch = 14
+	}
+	f.leases[ch] = in.LeaseMs
+	f.fakeServos.Move(ctx, in, opts...)
+	return &pb.MoveReply{Ok: true, Channel: ch}, nil
+}
+
+func (f *leaseServos) RenewMove(_ context.Context, in *pb.RenewMoveRequest, _ ...grpc.CallOption) (*pb.RenewMoveReply, error) {
+	chs := append([]int32(nil), in.Channels...)
+	sort.Slice(chs, func(i, j int) bool { return chs[i] < chs[j] })
+	f.renewed = append(f.renewed, chs)
+	reply := &pb.RenewMoveReply{}
+	for _, ch := range chs {
+		if f.gone[ch] {
+			reply.NotMoving = append(reply.NotMoving, ch)
+		}
+	}
+	return reply, nil
+}
+
+func TestMoveLeaser(t *testing.T) {
+	servos := &leaseServos{leases: map[int32]uint32{}, gone: map[int32]bool{}}
+	l := NewMoveLeaser(servos, 900*time.Millisecond)
+	ctx := context.Background()
+
+	l.Renew()
+	if len(servos.renewed) != 0 {
+		t.Error("renewed with nothing moving")
+	}
+
+	in := &pb.MoveRequest{Channel: 4, Direction: 1, Speed: 60}
+	l.Move(ctx, in)
+	l.Move(ctx, &pb.MoveRequest{Channel: 5, Direction: 1, Speed: 60, LeaseMs: 300})
+	l.Move(ctx, &pb.MoveRequest{Name: "camera.pan", Direction: -1, Speed: 60})
+	if servos.leases[4] != 900 || servos.leases[5] != 300 || servos.leases[14] != 900 {
+		t.Errorf("leases %v", servos.leases)
+	}
+	if in.LeaseMs != 0 {
+		t.Error("changed the caller's request")
+	}
+
+	l.Stop(ctx, &pb.StopRequest{Name: "camera.pan"})
+	servos.gone[5] = true // say its lease ran out
+	l.Renew()
+	l.Renew()
+	want := [][]int32{{4, 5}, {4}}
+	if !reflect.DeepEqual(servos.renewed, want) {
+		t.Errorf("renewed %v, want %v", servos.renewed, want)
+	}
+}
+
+func TestHaltChannels(t *testing.T) {
+	if chs := haltChannels(&fakeServos{}); len(chs) != servoChannels {
+		t.Errorf("server listing nothing: %v", chs)
+	}
+	if chs := haltChannels(&turningServo{}); !reflect.DeepEqual(chs, []int32{14}) {
+		t.Errorf("stops %v, want the server's channels", chs)
+	}
+}
@@ -4,20 +4,112 @@ package main
 import (
 	"flag"
 	"log"
+	"math"
+	"os"
+	"time"
+
+	"periph.io/x/conn/v3/i2c"
 
 	cl "github.com/n0remac/robot-webrtc/client"
+	"github.com/n0remac/robot-webrtc/hal"
+	pb "github.com/n0remac/robot-webrtc/servo"
 )
 
 func main() {
-	motors := cl.SetupRobot()
-
 	// CLI flags
 	server := flag.String("server", "wss://noremac.dev/ws/hub", "signaling server URL")
+	token := flag.String("token", os.Getenv("ROBOT_TOKEN"), "device token for the signaling server (see cmd/wstoken)")
+	bindings := flag.String("bindings", "", "key binding JSON file, reloaded on change (default: built-in layout)")
+	telemetryHz := flag.Float64("telemetry-hz", 2, "telemetry messages per second sent to each operator")
+	stopCm := flag.Float64("stop-cm", cl.ObstacleStopCm, "veto forward motion when an obstacle is closer than this")
+	slowCm := flag.Float64("slow-cm", cl.ObstacleSlowCm, "start slowing forward motion when an obstacle is closer than this")
+	sim := flag.Bool("sim", false, "run against a simulated robot (motors, sensors, servos and camera) instead of the Pi's hardware")
+	motorPWM := flag.String("motor-pwm", "", "motor speed lines, e.g. MOTOR1=pca9685:0x41:0,MOTOR4=hardware:1000 (default: software PWM)")
+	encoders := flag.String("encoders", "", "wheel encoders for closed-loop speed and odometry, e.g. MOTOR1=20:21,MOTOR4=26 (A:B quadrature, or A for a hall sensor)")
+	ticksPerM := flag.Float64("ticks-per-m", cl.TicksPerMetre, "encoder counts per metre of wheel travel")
+	missionAddr := flag.String("mission-addr", cl.MissionAddr, "local HTTP API for missions and component health (empty to disable)")
+	snapshotDir := flag.String("snapshot-dir", cl.SnapshotDir, "where mission snapshot steps save camera frames")
+	adminToken := flag.String("admin-token", os.Getenv("ROBOT_ADMIN_TOKEN"), "lets an operator take or revoke driving control from anyone (empty: no override)")
+	leaseIdle := flag.Duration("lease-idle", cl.LeaseIdleTimeout, "pass driving control on after the operator has been idle this long")
+	servoAddr := flag.String("servo-addr", cl.ServoAddr, "servo gRPC server: host:port, unix:/path/to.sock, or \"local\" to run it in this process")
+	flag.StringVar(&cl.ServoFiles.Config, "servo-config", cl.ServoFiles.Config, "JSON file of per-channel servo calibration, for -servo-addr local and to keep -motor-pwm off the servo boards")
+	flag.StringVar(&cl.ServoFiles.Poses, "servo-poses", cl.ServoFiles.Poses, "with -servo-addr local, JSON file the pose library is kept in")
+	flag.StringVar(&cl.ServoFiles.Recordings, "servo-recordings", cl.ServoFiles.Recordings, "with -servo-addr local, directory servo recordings are kept in")
+	servoDial := pb.AddDialFlags(flag.CommandLine)
+	servoLease := flag.Duration("servo-lease", cl.ServoMoveLease, "servo moves stop unless renewed this often, so they end if the client dies (0 to disable)")
 	room := "robot"
 	flag.Parse()
 
+	if *slowCm <= *stopCm {
+		log.Fatalf("-slow-cm (%v) must be greater than -stop-cm (%v)", *slowCm, *stopCm)
+	}
+
+	speeds, err := cl.ParseMotorSpeed(*motorPWM)
+	if err != nil {
+		log.Fatalf("-motor-pwm: %v", err)
+	}
+	cl.MotorSpeed = speeds
+
+	encs, err := cl.ParseEncoders(*encoders)
+	if err != nil {
+		log.Fatalf("-encoders: %v", err)
+	}
+	cl.MotorEncoders, cl.TicksPerMetre = encs, *ticksPerM
+
+	cl.ServoAddr, cl.ServoDial = *servoAddr, *servoDial
+	var board hal.Board
+	if *sim {
+		board = startSim()
+	} else {
+		board = cl.OpenBoard()
+	}
+	motors := cl.SetupRobot(board)
+
+	cl.ObstacleStopCm, cl.ObstacleSlowCm = *stopCm, *slowCm
+	cl.MissionAddr, cl.SnapshotDir = *missionAddr, *snapshotDir
+	cl.AdminToken, cl.LeaseIdleTimeout = *adminToken, *leaseIdle
+	cl.ServoMoveLease = *servoLease
+
+	if *telemetryHz > 0 {
+		cl.TelemetryInterval = time.Duration(float64(time.Second) / *telemetryHz)
+	}
+
+	if *bindings != "" {
+		b, err := cl.LoadBindings(*bindings, len(motors))
+		if err != nil {
+			log.Fatalf("bindings: %v", err)
+		}
+		cl.KeyBindings.Set(b)
+		go cl.KeyBindings.Watch(*bindings, len(motors), 2*time.Second, nil)
+	}
+
 	myID := "robot"
 	log.Printf("My ID: %s", myID)
 
-	cl.Setup(server, &room, motors, myID)
+	cl.Setup(server, &room, motors, myID, *token)
+}
+
+// startSim runs the simulated world and points the client at it, with an
+// in-process servo server on its I²C bus.
+func startSim() hal.Board {
+	sim := cl.NewSimBoard()
+	go sim.Run(20*time.Millisecond, nil)
+
+	// every bus number is the simulator's one bus, and the servo server
+	// runs in this process with everything in memory
+	bus, _ := sim.I2C()
+	cl.ServoBus = func(int) (i2c.BusCloser, error) { return bus, nil }
+	cl.ServoAddr, cl.ServoDial, cl.ServoFiles = cl.ServoLocal, pb.DialConfig{}, pb.Files{}
+
+	cl.VideoInputArgs, cl.AudioInputArgs = cl.SimVideoInputArgs, cl.SimAudioInputArgs
+	cl.VideoFilter = ""
+
+	go func() {
+		for range time.Tick(5 * time.Second) {
+			p := sim.Pose()
+			log.Printf("sim: pose x=%.2fm y=%.2fm θ=%.0f° servos=%v", p.X, p.Y, p.Theta*180/math.Pi, sim.ServoAngles())
+		}
+	}()
+	log.Printf("🤖 simulated robot")
+	return sim
 }
@@ -0,0 +1,191 @@
+// cmd/servo-record/main.go
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+	"time"
+
+	"google.golang.org/grpc"
+
+	pb "github.com/n0remac/robot-webrtc/servo"
+)
+
+const usage = `usage: servo-record [flags] <command>
+
+Teaches the arm by demonstration: record what the servos do while they're
+driven some other way, then play it back.
+
+commands:
+  record <name>   record until Enter (saves) or Ctrl-C (throws it away)
+  play [-speed x] [-loops n | -forever] <name>
+                  play a recording and wait for it; Ctrl-C aborts
+  list            list recordings
+  delete <name>   delete a recording
+  abort           stop whatever is playing
+
+flags:
+`
+
+func main() {
+	target := flag.String("target", "localhost:50051", "gRPC servo server address, or unix:/path/to.sock")
+	dial := pb.AddDialFlags(flag.CommandLine)
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	opts, err := dial.DialOptions(*target)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cc, err := grpc.NewClient(*target, opts...)
+	if err != nil {
+		log.Fatalf("servo server at %s: %v", *target, err)
+	}
+	defer cc.Close()
+	client := pb.NewControllerClient(cc)
+
+	switch cmd, args := flag.Arg(0), flag.Args()[1:]; cmd {
+	case "record":
+		record(client, oneName(cmd, args))
+	case "play":
+		play(client, args)
+	case "list":
+		list(client)
+	case "delete":
+		ctx, cancel := call()
+		defer cancel()
+		r, err := client.DeleteRecording(ctx, &pb.DeleteRecordingRequest{Name: oneName(cmd, args)})
+		check("DeleteRecording", err, r.GetOk(), r.GetErr())
+	case "abort":
+		ctx, cancel := call()
+		defer cancel()
+		r, err := client.AbortPlayback(ctx, &pb.AbortPlaybackRequest{})
+		check("AbortPlayback", err, r.GetOk(), r.GetErr())
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func record(client pb.ControllerClient, name string) {
+	ctx, cancel := call()
+	r, err := client.StartRecording(ctx, &pb.StartRecordingRequest{Name: name})
+	cancel()
+	check("StartRecording", err, r.GetOk(), r.GetErr())
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt)
+	enter := make(chan struct{})
+	go func() {
+		bufio.NewReader(os.Stdin).ReadString('\n')
+		close(enter)
+	}()
+	fmt.Printf("⏺ recording %q: move the servos, then press Enter to save or Ctrl-C to throw it away\n", name)
+	discard := false
+	select {
+	case <-enter:
+	case <-sigCh:
+		discard = true
+	}
+
+	ctx, cancel = call()
+	defer cancel()
+	stopped, err := client.StopRecording(ctx, &pb.StopRecordingRequest{Discard: discard})
+	check("StopRecording", err, stopped.GetOk(), stopped.GetErr())
+	if discard {
+		fmt.Println("\nthrown away")
+		return
+	}
+	info := stopped.Recording
+	fmt.Printf("saved %q: %s, %d frames, %d commands, channels %v\n",
+		info.Name, time.Duration(info.DurationMs)*time.Millisecond, info.Frames, info.Commands, info.Channels)
+}
+
+func play(client pb.ControllerClient, args []string) {
+	fs := flag.NewFlagSet("play", flag.ExitOnError)
+	speed := fs.Float64("speed", 1, "playback speed, 0.5–2")
+	loops := fs.Uint("loops", 1, "times through")
+	forever := fs.Bool("forever", false, "loop until Ctrl-C")
+	fs.Parse(args)
+	name := oneName("play", fs.Args())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt)
+	go func() {
+		<-sigCh
+		cancel()
+	}()
+
+	fmt.Printf("▶ playing %q; Ctrl-C aborts\n", name)
+	r, err := client.PlayRecording(ctx, &pb.PlayRecordingRequest{
+		Name: name, Speed: *speed, Loops: uint32(*loops), Forever: *forever, Wait: true,
+	})
+	if ctx.Err() != nil {
+		// the server keeps playing unless told otherwise
+		actx, acancel := call()
+		defer acancel()
+		a, err := client.AbortPlayback(actx, &pb.AbortPlaybackRequest{})
+		check("AbortPlayback", err, a.GetOk(), a.GetErr())
+		fmt.Println("\naborted")
+		return
+	}
+	check("PlayRecording", err, r.GetOk(), r.GetErr())
+	fmt.Println("done")
+}
+
+func list(client pb.ControllerClient) {
+	ctx, cancel := call()
+	defer cancel()
+	r, err := client.ListRecordings(ctx, &pb.ListRecordingsRequest{})
+	check("ListRecordings", err, true, "")
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tLENGTH\tFRAMES\tCOMMANDS\tCHANNELS\tRECORDED")
+	for _, rec := range r.Recordings {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%v\t%s\n", rec.Name, time.Duration(rec.DurationMs)*time.Millisecond,
+			rec.Frames, rec.Commands, rec.Channels, time.UnixMilli(rec.StartedMs).Format("2006-01-02 15:04"))
+	}
+	w.Flush()
+	if r.Recording != "" {
+		fmt.Printf("recording %q now\n", r.Recording)
+	}
+	if r.Playing != "" {
+		fmt.Printf("playing %q now\n", r.Playing)
+	}
+}
+
+func call() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), 2*time.Second)
+}
+
+func oneName(cmd string, args []string) string {
+	if len(args) != 1 {
+		log.Fatalf("%s needs a recording name", cmd)
+	}
+	return args[0]
+}
+
+// check exits on an RPC error or a refusal.
+func check(rpc string, err error, ok bool, why string) {
+	if err != nil {
+		log.Fatalf("%s: %v", rpc, err)
+	}
+	if !ok {
+		log.Fatalf("%s: %s", rpc, why)
+	}
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/n0remac/robot-webrtc/servo"
+)
+
+const calibrateHelp = `  + / -       jog by -step degrees (++ / -- for five steps)
+  <angle>     go straight to an angle
+  min / max   record where the servo is as an endpoint
+  home        record where the servo is as its resting angle
+  trim <deg>  set the trim offset
+  invert      flip the direction
+  show        print what's recorded
+  save        send it to the server, which saves its config file
+  quit        put the old calibration back and leave`
+
+// calibrate is "servo calibrate": it jogs one channel of a running server
+// from the terminal, with its limits opened right up, so its real endpoints
+// and home can be found and saved with SetConfig.
+func calibrate(args []string) {
+	fs := flag.NewFlagSet("calibrate", flag.ExitOnError)
+	target := fs.String("target", "localhost:50051", "gRPC servo server address, or unix:/path/to.sock")
+	channel := fs.String("channel", "", "servo channel, by number or name")
+	step := fs.Float64("step", 2, "degrees per jog")
+	dial := pb.AddDialFlags(fs)
+	fs.Parse(args)
+	if *channel == "" {
+		log.Fatalf("Must specify -channel, by number or name")
+	}
+
+	opts, err := dial.DialOptions(*target)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cc, err := grpc.NewClient(*target, opts...)
+	if err != nil {
+		log.Fatalf("servo server at %s: %v", *target, err)
+	}
+	defer cc.Close()
+	client := pb.NewControllerClient(cc)
+	call := func() (context.Context, context.CancelFunc) {
+		return context.WithTimeout(context.Background(), 2*time.Second)
+	}
+
+	// start from what the server has, or the usual pulses for a new channel
+	ctx, cancel := call()
+	cfg, err := client.GetConfig(ctx, &pb.GetConfigRequest{})
+	cancel()
+	if err != nil {
+		log.Fatalf("GetConfig: %v", err)
+	}
+	var orig *pb.ChannelConfig
+	for _, c := range cfg.Channels {
+		if c.Name == *channel || strconv.Itoa(int(c.Channel)) == *channel {
+			orig = c
+		}
+	}
+	var ch int32
+	if orig != nil {
+		ch = orig.Channel
+	} else if n, err := strconv.Atoi(*channel); err == nil && n >= 0 {
+		ch = int32(n)
+	} else {
+		log.Fatalf("no servo called %q; give a number to calibrate a new channel", *channel)
+	}
+	rec := &pb.ChannelConfig{Channel: ch, MinAngle: 0, MaxAngle: 180, Home: 90, MinPulse: 50, MaxPulse: 650}
+	if orig != nil {
+		rec = proto.Clone(orig).(*pb.ChannelConfig)
+	}
+
+	// the wide limits are only ever set temporarily, so the config file
+	// keeps the real ones however the session ends
+	set := func(c *pb.ChannelConfig, home, temporary bool) error {
+		ctx, cancel := call()
+		defer cancel()
+		r, err := client.SetConfig(ctx, &pb.SetConfigRequest{Channels: []*pb.ChannelConfig{c}, GoHome: home, Temporary: temporary})
+		if err == nil && !r.Ok {
+			err = fmt.Errorf("%s", r.Err)
+		}
+		return err
+	}
+	restore := func() {
+		if orig == nil {
+			fmt.Println("channel was new; it keeps the wide limits until the server restarts")
+			return
+		}
+		if err := set(orig, false, true); err != nil {
+			log.Printf("restoring calibration: %v", err)
+		}
+	}
+
+	wide := proto.Clone(rec).(*pb.ChannelConfig)
+	wide.MinAngle, wide.MaxAngle = 0, 180
+	if err := set(wide, false, true); err != nil {
+		log.Fatalf("opening limits: %v", err)
+	}
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		restore()
+		os.Exit(1)
+	}()
+
+	angle := rec.Home
+	ctx, cancel = call()
+	if r, err := client.GetAngles(ctx, &pb.GetAnglesRequest{}); err == nil {
+		for _, a := range r.Angles {
+			if a.Channel == ch {
+				angle = float64(a.Angle)
+			}
+		}
+	}
+	cancel()
+	moveTo := func(a float64) {
+		a = max(0, min(180, a))
+		ctx, cancel := call()
+		defer cancel()
+		r, err := client.SetAngle(ctx, &pb.SetAngleRequest{Channel: ch, Angle: a})
+		switch {
+		case err != nil:
+			log.Printf("SetAngle: %v", err)
+		case !r.Ok:
+			log.Printf("SetAngle: %s", r.Err)
+		default:
+			angle = a
+		}
+	}
+
+	fmt.Printf("Calibrating channel %d %s. Commands:\n%s\n", ch, rec.Name, calibrateHelp)
+	in := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Printf("ch%d @ %.1f° [min %g max %g home %g]> ", ch, angle, rec.MinAngle, rec.MaxAngle, rec.Home)
+		if !in.Scan() {
+			fmt.Println()
+			restore()
+			return
+		}
+		fields := strings.Fields(in.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch cmd := fields[0]; cmd {
+		case "+", "-", "++", "--":
+			d := *step
+			if len(cmd) == 2 {
+				d *= 5
+			}
+			if cmd[0] == '-' {
+				d = -d
+			}
+			moveTo(angle + d)
+		case "min":
+			rec.MinAngle = angle
+		case "max":
+			rec.MaxAngle = angle
+		case "home":
+			rec.Home = angle
+		case "trim":
+			if len(fields) != 2 {
+				fmt.Println("trim <degrees>")
+				continue
+			}
+			t, err := strconv.ParseFloat(fields[1], 64)
+			if err != nil {
+				fmt.Println(err)
+				continue
+			}
+			rec.Trim = t
+			wide.Trim = t
+			if err := set(wide, false, true); err != nil {
+				fmt.Println(err)
+			}
+		case "invert":
+			rec.Invert = !rec.Invert
+			wide.Invert = rec.Invert
+			if err := set(wide, false, true); err != nil {
+				fmt.Println(err)
+			}
+		case "show":
+			fmt.Printf("%+v\n", rec)
+		case "save":
+			if err := set(rec, true, false); err != nil {
+				fmt.Println("not saved:", err)
+				continue
+			}
+			fmt.Printf("saved channel %d: min %g max %g home %g\n", ch, rec.MinAngle, rec.MaxAngle, rec.Home)
+			return
+		case "quit", "q":
+			restore()
+			return
+		case "help", "?":
+			fmt.Println(calibrateHelp)
+		default:
+			a, err := strconv.ParseFloat(cmd, 64)
+			if err != nil {
+				fmt.Println("unknown command; try help")
+				continue
+			}
+			moveTo(a)
+		}
+	}
+}
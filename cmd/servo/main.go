@@ -2,82 +2,74 @@
 package main
 
 import (
+	"flag"
 	"log"
-	"net"
 	"os"
-	"strings"
-	"time"
+	"os/signal"
+	"syscall"
 
 	"google.golang.org/grpc"
-	"periph.io/x/conn/v3/i2c"
-	"periph.io/x/conn/v3/physic"
-	"periph.io/x/devices/v3/pca9685"
-	"periph.io/x/host/v3/sysfs" // only sysfs host drivers (i2c, led, thermal)
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 
 	pb "github.com/n0remac/robot-webrtc/servo"
 )
 
-type nopBus struct{}
-
-var defaultServoRanges = map[int][2]float64{
-	4:  {15, 140}, // Claw open/close
-	5:  {15, 140}, // Claw rotation
-	6:  {15, 68}, // Arm lift
-	14: {15, 140}, // Camera pan
-	15: {15, 140}, // Camera tilt
-}
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "calibrate" {
+		calibrate(os.Args[2:])
+		return
+	}
 
-func (nopBus) Tx(addr uint16, w, r []byte) error  { return nil }
-func (nopBus) Close() error                       { return nil }
-func (nopBus) SetSpeed(hz physic.Frequency) error { return nil }
-func (nopBus) String() string                     { return "nopBus" }
+	files := pb.DefaultFiles()
+	flag.StringVar(&files.Poses, "poses", files.Poses, "JSON file the pose library is kept in")
+	flag.StringVar(&files.Recordings, "recordings", files.Recordings, "directory recordings are kept in, one JSON file each")
+	flag.StringVar(&files.Config, "config", files.Config, "JSON file of per-channel servo calibration")
+	listen := flag.String("listen", ":50051", "TCP address, or unix:/path/to.sock for clients on the same host")
+	var sec pb.ServerConfig
+	flag.StringVar(&sec.Cert, "tls-cert", "", "server certificate (enables TLS)")
+	flag.StringVar(&sec.Key, "tls-key", "", "server key")
+	flag.StringVar(&sec.ClientCA, "tls-client-ca", "", "require client certificates signed by this CA (mutual TLS)")
+	flag.StringVar(&sec.Token, "token", os.Getenv("SERVO_TOKEN"), "require this bearer token on every call except health checks")
+	flag.Parse()
 
-func main() {
-	sg, cleanup := SetupServers()
-	defer cleanup()
+	opts, err := sec.ServerOptions()
+	if err != nil {
+		log.Fatalf("security: %v", err)
+	}
 
-	lis, err := net.Listen("tcp", ":50051")
+	servos, err := files.Open(pb.SysfsBus)
 	if err != nil {
-		log.Fatalf("net.Listen: %v", err)
+		log.Fatalf("servos: %v", err)
 	}
-	srv := grpc.NewServer()
-	pb.RegisterControllerServer(srv, pb.NewServer(sg, defaultServoRanges))
-	log.Println("servo gRPC listening on :50051")
-	srv.Serve(lis)
-}
+	defer servos.Close()
 
-func SetupServers() (*pca9685.ServoGroup, func()) {
-	// 1) Try opening the real /dev/i2c-1 bus:
-	var bus i2c.BusCloser
-	realBus, err := sysfs.NewI2C(1)
+	lis, err := pb.Listen(*listen)
 	if err != nil {
-		if os.IsNotExist(err) || strings.Contains(err.Error(), "no such file") {
-			log.Println("⚠️  /dev/i2c-1 not found, falling back to no-op I²C bus")
-			bus = nopBus{}
-		} else {
-			log.Fatalf("sysfs.NewI2C: %v", err)
-		}
-	} else {
-		bus = realBus
+		log.Fatalf("listen %s: %v", *listen, err)
 	}
+	srv := grpc.NewServer(opts...)
+	pb.RegisterControllerServer(srv, servos)
 
-	cleanup := func() { _ = bus.Close() }
+	// health checks for supervisors, reflection for grpcurl and friends
+	hs := health.NewServer()
+	hs.SetServingStatus(pb.Controller_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(srv, hs)
+	reflection.Register(srv)
 
-	// 2) Software reset the PCA9685 (General Call 0x06)
-	_ = bus.Tx(0x00, []byte{0x06}, nil)
-	time.Sleep(10 * time.Millisecond)
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		log.Println("servo server shutting down")
+		hs.Shutdown()
+		srv.GracefulStop()
+	}()
 
-	// 3) Create & configure the driver
-	pca, err := pca9685.NewI2C(bus, pca9685.I2CAddr)
-	if err != nil {
-		log.Fatalf("pca9685.NewI2C: %v", err)
+	log.Printf("servo gRPC listening on %s (TLS: %v, mTLS: %v, token: %v; calibration in %s, poses in %s, recordings in %s)",
+		*listen, sec.Cert != "", sec.ClientCA != "", sec.Token != "", files.Config, files.Poses, files.Recordings)
+	if err := srv.Serve(lis); err != nil {
+		log.Printf("serve: %v", err)
 	}
-	if err := pca.SetPwmFreq(50 * physic.Hertz); err != nil {
-		log.Fatalf("SetPwmFreq: %v", err)
-	}
-	if err := pca.SetAllPwm(0, 0); err != nil {
-		log.Fatalf("SetAllPwm: %v", err)
-	}
-
-	return pca9685.NewServoGroup(pca, 50, 650, 0, 180), cleanup
 }
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	pb "github.com/n0remac/robot-webrtc/servo"
+)
+
+func angles(client pb.ControllerClient, args []string) {
+	flag.NewFlagSet("angles", flag.ExitOnError).Parse(args)
+	ctx, cancel := call()
+	defer cancel()
+	r, err := client.GetAngles(ctx, &pb.GetAnglesRequest{})
+	check("GetAngles", err, true, "")
+	sort.Slice(r.Angles, func(i, j int) bool { return r.Angles[i].Channel < r.Angles[j].Channel })
+	emit(r, func(w *tabwriter.Writer) {
+		fmt.Fprintln(w, "CHANNEL\tNAME\tANGLE")
+		for _, a := range r.Angles {
+			fmt.Fprintf(w, "%d\t%s\t%.1f°\n", a.Channel, a.Name, a.Angle)
+		}
+	})
+}
+
+func watch(client pb.ControllerClient, args []string) {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	rate := fs.Float64("rate", 0, "updates per second, 0 for the server's default")
+	fs.Parse(args)
+
+	ctx, cancel := interrupted()
+	defer cancel()
+	stream, err := client.WatchState(ctx, &pb.WatchStateRequest{MaxRateHz: *rate})
+	check("WatchState", err, true, "")
+	for {
+		st, err := stream.Recv()
+		if ctx.Err() != nil {
+			return
+		}
+		check("WatchState", err, true, "")
+		if *jsonOut {
+			emit(st, nil)
+			continue
+		}
+		parts := make([]string, 0, len(st.Channels))
+		for _, c := range st.Channels {
+			parts = append(parts, describe(c))
+		}
+		fmt.Printf("%s  %s\n", time.UnixMilli(st.TimeMs).Format("15:04:05.000"), strings.Join(parts, "  "))
+	}
+}
+
+// describe sums up one channel's state in a few characters.
+func describe(c *pb.ChannelState) string {
+	s := fmt.Sprintf("[%s] %.1f°", label(c.Channel, c.Name), c.Angle)
+	if c.Moving {
+		s += fmt.Sprintf("→%.1f°", c.Target)
+	}
+	switch c.AtLimit {
+	case pb.Limit_LIMIT_MIN:
+		s += " at min"
+	case pb.Limit_LIMIT_MAX:
+		s += " at max"
+	}
+	if c.Stopped != pb.StopReason_STOP_NONE && !c.Moving {
+		s += " (" + strings.ToLower(strings.TrimPrefix(c.Stopped.String(), "STOP_")) + ")"
+	}
+	return s
+}
+
+func move(client pb.ControllerClient, args []string) {
+	fs := flag.NewFlagSet("move", flag.ExitOnError)
+	channel := fs.String("channel", "", "servo channel, by number or name")
+	direction := fs.Int("direction", 1, "1 = forward, -1 = reverse")
+	speed := fs.Float64("speed", 60, "degrees per second")
+	duration := fs.Duration("duration", 0, "how long to move before stopping (e.g. 2s; 0 = until its limit or the server's maximum)")
+	fs.Parse(args)
+	ch, name := channelRef(*channel)
+
+	ctx, cancel := call()
+	defer cancel()
+	r, err := client.Move(ctx, &pb.MoveRequest{
+		Channel:       ch,
+		Name:          name,
+		Direction:     int32(*direction),
+		Speed:         *speed,
+		MaxDurationMs: uint32(duration.Milliseconds()),
+	})
+	check("Move", err, r.GetOk(), r.GetErr())
+	if *duration > 0 {
+		time.Sleep(*duration)
+		sctx, scancel := call()
+		defer scancel()
+		sr, err := client.Stop(sctx, &pb.StopRequest{Channel: r.Channel})
+		check("Stop", err, sr.GetOk(), sr.GetErr())
+	}
+	emit(r, func(w *tabwriter.Writer) {
+		fmt.Fprintf(w, "moved channel %d\n", r.Channel)
+	})
+}
+
+func stop(client pb.ControllerClient, args []string) {
+	fs := flag.NewFlagSet("stop", flag.ExitOnError)
+	channel := fs.String("channel", "", "servo channel, by number or name")
+	fs.Parse(args)
+	ch, name := channelRef(*channel)
+
+	ctx, cancel := call()
+	defer cancel()
+	r, err := client.Stop(ctx, &pb.StopRequest{Channel: ch, Name: name})
+	check("Stop", err, r.GetOk(), r.GetErr())
+	emit(r, func(w *tabwriter.Writer) { fmt.Fprintln(w, "stopped") })
+}
+
+func goTo(client pb.ControllerClient, args []string) {
+	fs := flag.NewFlagSet("goto", flag.ExitOnError)
+	channel := fs.String("channel", "", "servo channel, by number or name")
+	angle := fs.Float64("angle", -1, "where to go, in degrees")
+	velocity := fs.Float64("velocity", 0, "degrees per second, 0 for the server's default")
+	accel := fs.Float64("accel", 0, "degrees per second², 0 to start and stop at full speed")
+	nowait := fs.Bool("nowait", false, "return straight away instead of when it gets there")
+	fs.Parse(args)
+	ch, name := channelRef(*channel)
+	if *angle < 0 {
+		log.Fatal("Must specify an -angle")
+	}
+
+	ctx, cancel := interrupted()
+	defer cancel()
+	start := time.Now()
+	r, err := client.MoveTo(ctx, &pb.MoveToRequest{
+		Channel: ch, Name: name, Angle: *angle, MaxVelocity: *velocity, Acceleration: *accel, Wait: !*nowait,
+	})
+	check("MoveTo", err, r.GetOk(), r.GetErr())
+	emit(r, func(w *tabwriter.Writer) {
+		if *nowait {
+			fmt.Fprintln(w, "on its way")
+		} else {
+			fmt.Fprintf(w, "there in %v\n", time.Since(start).Round(time.Millisecond))
+		}
+	})
+}
+
+func pose(client pb.ControllerClient, args []string) {
+	if len(args) == 0 {
+		log.Fatal("pose save <name>, pose load <name> or pose list")
+	}
+	switch args[0] {
+	case "save":
+		fs := flag.NewFlagSet("pose save", flag.ExitOnError)
+		channels := fs.String("channels", "", "comma-separated channels to save, by number or name (default: all)")
+		name := poseName(fs, args[1:])
+		req := &pb.SavePoseRequest{Name: name}
+		for _, c := range splitList(*channels) {
+			if ch, n := channelRef(c); n != "" {
+				req.Names = append(req.Names, n)
+			} else {
+				req.Channels = append(req.Channels, ch)
+			}
+		}
+		ctx, cancel := call()
+		defer cancel()
+		r, err := client.SavePose(ctx, req)
+		check("SavePose", err, r.GetOk(), r.GetErr())
+		emit(r.Pose, func(w *tabwriter.Writer) { poseTable(w, r.Pose) })
+	case "load":
+		fs := flag.NewFlagSet("pose load", flag.ExitOnError)
+		duration := fs.Duration("duration", 0, "how long to take (0: as fast as -velocity allows)")
+		velocity := fs.Float64("velocity", 0, "degrees per second, 0 for the server's default")
+		name := poseName(fs, args[1:])
+		ctx, cancel := interrupted()
+		defer cancel()
+		r, err := client.MoveToPose(ctx, &pb.MoveToPoseRequest{
+			Name: name, DurationMs: uint32(duration.Milliseconds()), MaxVelocity: *velocity, Wait: true,
+		})
+		check("MoveToPose", err, r.GetOk(), r.GetErr())
+		emit(r, func(w *tabwriter.Writer) { fmt.Fprintf(w, "in pose %q\n", name) })
+	case "list":
+		ctx, cancel := call()
+		defer cancel()
+		r, err := client.ListPoses(ctx, &pb.ListPosesRequest{})
+		check("ListPoses", err, true, "")
+		emit(r, func(w *tabwriter.Writer) {
+			for _, p := range r.Poses {
+				poseTable(w, p)
+			}
+		})
+	default:
+		log.Fatalf("unknown pose command %q", args[0])
+	}
+}
+
+func poseName(fs *flag.FlagSet, args []string) string {
+	// the name may come before or after the flags
+	var name string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	fs.Parse(args)
+	if name == "" && fs.NArg() == 1 {
+		name = fs.Arg(0)
+	}
+	if name == "" {
+		log.Fatalf("%s needs a pose name", fs.Name())
+	}
+	return name
+}
+
+func poseTable(w *tabwriter.Writer, p *pb.Pose) {
+	parts := make([]string, 0, len(p.Angles))
+	for _, a := range p.Angles {
+		parts = append(parts, fmt.Sprintf("%d: %.1f°", a.Channel, a.Angle))
+	}
+	fmt.Fprintf(w, "%s\t%s\n", p.Name, strings.Join(parts, ", "))
+}
+
+func estop(client pb.ControllerClient, args []string) {
+	fs := flag.NewFlagSet("estop", flag.ExitOnError)
+	cut := fs.Bool("cut", false, "turn the PWM outputs off too, so the servos go limp")
+	fs.Parse(args)
+	ctx, cancel := call()
+	defer cancel()
+	r, err := client.EmergencyStop(ctx, &pb.EmergencyStopRequest{CutPwm: *cut})
+	check("EmergencyStop", err, r.GetOk(), r.GetErr())
+	emit(r, func(w *tabwriter.Writer) { fmt.Fprintln(w, "🛑 stopped") })
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, f := range strings.Split(s, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			out = append(out, f)
+		}
+	}
+	return out
+}
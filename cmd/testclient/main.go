@@ -3,77 +3,146 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"text/tabwriter"
 	"time"
 
-	pb "github.com/n0remac/robot-webrtc/servo"
-
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	pb "github.com/n0remac/robot-webrtc/servo"
 )
 
+const usage = `usage: testclient [flags] <command> [command flags]
+
+Exercises a servo server. Channels are given by number or by name.
+
+commands:
+  angles                      every channel's angle
+  watch [-rate hz]            stream state changes until Ctrl-C
+  move -channel c [-direction 1|-1] [-speed deg/s] [-duration d]
+                              jog a channel, stopping after -duration
+  stop -channel c             stop a channel
+  goto -channel c -angle a [-velocity deg/s] [-accel deg/s²] [-nowait]
+                              move a channel to an angle
+  sweep [-channels 4,arm.lift] [-speed deg/s] [-duration d] [-pause d]
+                              jog every configured channel both ways
+  pose save <name> [-channels c,...]
+  pose load <name> [-duration d] [-velocity deg/s]
+  pose list
+  estop [-cut]                stop everything, with -cut turning the outputs off
+
+flags:
+`
+
+// jsonOut prints results as JSON instead of tables.
+var jsonOut = flag.Bool("json", false, "print JSON instead of tables")
+
 func main() {
-	// CLI flags
-	target := flag.String("target", "localhost:50051", "gRPC servo server address")
-	pin := flag.Int("pin", -1, "servo channel (0–15)")
-	direction := flag.Int("direction", 1, "1 = forward, -1 = reverse (ignored with -stop)")
-	speed := flag.Float64("speed", 60, "degrees per second (ignored with -stop)")
-	duration := flag.Duration("duration", 0, "how long to move before stopping (e.g. 2s; 0 = no auto-stop)")
-	stopOnly := flag.Bool("stop", false, "if true, only send Stop for the given pin and exit")
+	target := flag.String("target", "localhost:50051", "gRPC servo server address, or unix:/path/to.sock")
+	dial := pb.AddDialFlags(flag.CommandLine)
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
 	flag.Parse()
-
-	if *pin < 0 {
-		log.Fatalf("Must specify a valid -pin (0–15)")
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
 	}
 
-	// 1) Dial the servo server once
-	cc, err := grpc.NewClient(
-		*target,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	opts, err := dial.DialOptions(*target)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cc, err := grpc.NewClient(*target, opts...)
 	if err != nil {
 		log.Fatalf("Failed to create gRPC client for servo server at %s: %v", *target, err)
 	}
 	defer cc.Close()
-
 	client := pb.NewControllerClient(cc)
 
-	// 2) If -stop is set, just send Stop and exit
-	if *stopOnly {
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-		defer cancel()
-		resp, err := client.Stop(ctx, &pb.StopRequest{Channel: int32(*pin)})
-		if err != nil {
-			log.Fatalf("Stop RPC failed: %v", err)
-		}
-		log.Printf("Stop.ok = %v", resp.GetOk())
-		return
+	commands := map[string]func(pb.ControllerClient, []string){
+		"angles": angles,
+		"watch":  watch,
+		"move":   move,
+		"stop":   stop,
+		"goto":   goTo,
+		"sweep":  sweep,
+		"pose":   pose,
+		"estop":  estop,
+	}
+	run, ok := commands[flag.Arg(0)]
+	if !ok {
+		flag.Usage()
+		os.Exit(2)
 	}
+	run(client, flag.Args()[1:])
+}
+
+func call() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), 2*time.Second)
+}
+
+// interrupted is cancelled by Ctrl-C.
+func interrupted() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt)
+}
 
-	// 3) Otherwise, send Move
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-	defer cancel()
-	resp, err := client.Move(ctx, &pb.MoveRequest{
-		Channel:   int32(*pin),
-		Direction: int32(*direction),
-		Speed:     *speed,
-	})
+// check exits on an RPC error or a refusal.
+func check(rpc string, err error, ok bool, why string) {
 	if err != nil {
-		log.Fatalf("Move RPC failed: %v", err)
+		log.Fatalf("%s RPC failed: %v", rpc, err)
+	}
+	if !ok {
+		log.Fatalf("%s: %s", rpc, why)
+	}
+}
+
+// channelRef reads a channel given by number or name.
+func channelRef(s string) (int32, string) {
+	if s == "" {
+		log.Fatal("Must specify a -channel, by number or name")
 	}
-	log.Printf("Move.ok = %v", resp.GetOk())
-
-	// 4) If a duration was specified, wait then Stop
-	if *duration > 0 {
-		log.Printf("Running for %v…", *duration)
-		time.Sleep(*duration)
-		ctx2, cancel2 := context.WithTimeout(context.Background(), time.Second)
-		defer cancel2()
-		sresp, serr := client.Stop(ctx2, &pb.StopRequest{Channel: int32(*pin)})
-		if serr != nil {
-			log.Fatalf("Stop RPC failed: %v", serr)
+	if n, err := strconv.Atoi(s); err == nil {
+		return int32(n), ""
+	}
+	return 0, s
+}
+
+// label is how a channel is shown: its name if it has one.
+func label(ch int32, name string) string {
+	if name != "" {
+		return fmt.Sprintf("%d %s", ch, name)
+	}
+	return strconv.Itoa(int(ch))
+}
+
+// emit prints v as a line of JSON with -json, or else as the table fill
+// writes.
+func emit(v any, fill func(w *tabwriter.Writer)) {
+	if *jsonOut {
+		var raw []byte
+		var err error
+		if m, ok := v.(proto.Message); ok {
+			raw, err = protojson.Marshal(m)
+		} else {
+			raw, err = json.Marshal(v)
+		}
+		if err != nil {
+			log.Fatal(err)
 		}
-		log.Printf("Auto-Stop.ok = %v", sresp.GetOk())
+		fmt.Println(string(raw))
+		return
 	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fill(w)
+	w.Flush()
 }
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	pb "github.com/n0remac/robot-webrtc/servo"
+)
+
+// sweepResult is one channel jogged one way.
+type sweepResult struct {
+	Channel   int32   `json:"channel"`
+	Name      string  `json:"name,omitempty"`
+	Direction int32   `json:"direction"`
+	From      float64 `json:"from"`
+	To        float64 `json:"to"`
+	Ms        int64   `json:"ms"`
+}
+
+// sweep jogs each configured channel forward and then back, reporting how
+// far each went. It stands in for the old test_all_servo_pins.sh.
+func sweep(client pb.ControllerClient, args []string) {
+	fs := flag.NewFlagSet("sweep", flag.ExitOnError)
+	only := fs.String("channels", "", "comma-separated channels to sweep, by number or name (default: every configured channel)")
+	speed := fs.Float64("speed", 60, "degrees per second")
+	duration := fs.Duration("duration", 2*time.Second, "how long to move each way")
+	pause := fs.Duration("pause", time.Second, "rest between moves")
+	fs.Parse(args)
+
+	ctx, cancel := call()
+	cfg, err := client.GetConfig(ctx, &pb.GetConfigRequest{})
+	cancel()
+	check("GetConfig", err, true, "")
+	want := map[string]bool{}
+	for _, c := range splitList(*only) {
+		want[c] = true
+	}
+	var channels []*pb.ChannelConfig
+	for _, c := range cfg.Channels {
+		if len(want) == 0 || want[strconv.Itoa(int(c.Channel))] || (c.Name != "" && want[c.Name]) {
+			channels = append(channels, c)
+		}
+	}
+	if len(channels) == 0 {
+		log.Fatal("No channels to sweep")
+	}
+
+	stop, cancelStop := interrupted()
+	defer cancelStop()
+	var results []sweepResult
+	for i, c := range channels {
+		for _, dir := range []int32{1, -1} {
+			if stop.Err() != nil {
+				break
+			}
+			if i > 0 || dir < 0 {
+				time.Sleep(*pause)
+			}
+			log.Printf("Sweeping channel %s, direction %d", label(c.Channel, c.Name), dir)
+			results = append(results, sweepOne(client, c, dir, *speed, *duration))
+		}
+	}
+	emit(results, func(w *tabwriter.Writer) {
+		fmt.Fprintln(w, "CHANNEL\tNAME\tDIRECTION\tFROM\tTO\tMOVED\tTIME")
+		for _, r := range results {
+			fmt.Fprintf(w, "%d\t%s\t%+d\t%.1f°\t%.1f°\t%.1f°\t%v\n",
+				r.Channel, r.Name, r.Direction, r.From, r.To, r.To-r.From, time.Duration(r.Ms)*time.Millisecond)
+		}
+	})
+}
+
+func sweepOne(client pb.ControllerClient, c *pb.ChannelConfig, dir int32, speed float64, d time.Duration) sweepResult {
+	r := sweepResult{Channel: c.Channel, Name: c.Name, Direction: dir, From: angleOf(client, c.Channel)}
+	start := time.Now()
+	ctx, cancel := call()
+	mr, err := client.Move(ctx, &pb.MoveRequest{
+		Channel: c.Channel, Direction: dir, Speed: speed, MaxDurationMs: uint32(d.Milliseconds()),
+	})
+	cancel()
+	check("Move", err, mr.GetOk(), mr.GetErr())
+	time.Sleep(d)
+	ctx, cancel = call()
+	sr, err := client.Stop(ctx, &pb.StopRequest{Channel: c.Channel})
+	cancel()
+	check("Stop", err, sr.GetOk(), sr.GetErr())
+	r.Ms = time.Since(start).Milliseconds()
+	r.To = angleOf(client, c.Channel)
+	return r
+}
+
+func angleOf(client pb.ControllerClient, ch int32) float64 {
+	ctx, cancel := call()
+	defer cancel()
+	r, err := client.GetAngles(ctx, &pb.GetAnglesRequest{})
+	check("GetAngles", err, true, "")
+	for _, a := range r.Angles {
+		if a.Channel == ch {
+			return float64(a.Angle)
+		}
+	}
+	log.Fatalf("Channel %d has no angle", ch)
+	return 0
+}
@@ -0,0 +1,39 @@
+// cmd/wstoken/main.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	ws "github.com/n0remac/robot-webrtc/websocket"
+)
+
+// Mints websocket tokens with the server's WS_TOKEN_SECRET, e.g. the robot's
+// device credential:
+//
+//	WS_TOKEN_SECRET=... go run ./cmd/wstoken -sub robot -role robot -rooms robot -ttl 8760h
+func main() {
+	sub := flag.String("sub", "robot", "user id the token is bound to")
+	role := flag.String("role", ws.RoleRobot, "role: player, robot or admin")
+	rooms := flag.String("rooms", "robot", "comma-separated rooms the token may join (* for any)")
+	ttl := flag.Duration("ttl", 365*24*time.Hour, "how long the token is valid")
+	flag.Parse()
+
+	if !ws.AuthEnabled() {
+		log.Fatal("WS_TOKEN_SECRET must be set to the server's secret")
+	}
+	switch *role {
+	case ws.RolePlayer, ws.RoleRobot, ws.RoleAdmin:
+	default:
+		log.Fatalf("unknown role %q", *role)
+	}
+
+	tok, err := ws.IssueToken(*sub, *role, strings.Split(*rooms, ","), *ttl)
+	if err != nil {
+		log.Fatalf("sign token: %v", err)
+	}
+	fmt.Println(tok)
+}
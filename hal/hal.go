@@ -0,0 +1,55 @@
+// Package hal is the robot's hardware abstraction layer: GPIO pins, PWM
+// outputs and the I²C bus, with a Raspberry Pi implementation (rpio for
+// GPIO, periph for I²C) and a simulated one for laptops and tests.
+package hal
+
+import (
+	"periph.io/x/conn/v3/i2c"
+)
+
+// Pin is a GPIO pin, numbered BCM.
+type Pin interface {
+	Output()
+	Input()
+	High()
+	Low()
+	Read() bool // true is high
+}
+
+// DutyCycler is a pulse-width modulated output with duty 0–100, such as a
+// motor's speed line. Backends: SoftPWM (any pin), HardwarePWM (the Pi's
+// PWM pins) and PCAChannel (a PCA9685 output).
+type DutyCycler interface {
+	ChangeDutyCycle(duty float64)
+	Stop()
+}
+
+// Encoder counts wheel encoder edges. A quadrature encoder counts up while
+// its motor runs forward and down in reverse; a single-channel hall sensor
+// only counts up, and the caller works out the direction.
+type Encoder interface {
+	Count() int64
+}
+
+// Board hands out the hardware the robot is wired to.
+type Board interface {
+	Pin(bcm int) Pin
+	// PWM starts a software PWM output at hz on pin.
+	PWM(pin Pin, hz int) DutyCycler
+	// HardwarePWM drives one of HardwarePWMPins from the PWM peripheral.
+	HardwarePWM(bcm, hz int) (DutyCycler, error)
+	// Encoder counts edges on a, and decodes quadrature with b unless b is 0.
+	Encoder(a, b int) (Encoder, error)
+	// I2C opens the bus the PCA9685 servo driver sits on.
+	I2C() (i2c.BusCloser, error)
+	Close() error
+}
+
+// Write drives pin high or low.
+func Write(p Pin, high bool) {
+	if high {
+		p.High()
+	} else {
+		p.Low()
+	}
+}
@@ -0,0 +1,37 @@
+package hal
+
+import (
+	"log"
+
+	"periph.io/x/conn/v3/gpio"
+	"periph.io/x/devices/v3/pca9685"
+)
+
+// PCAChannel drives one PCA9685 output as a DutyCycler. Every channel on a
+// board shares the board's PWM frequency.
+type PCAChannel struct {
+	dev *pca9685.Dev
+	ch  int
+}
+
+func NewPCAChannel(dev *pca9685.Dev, ch int) *PCAChannel {
+	return &PCAChannel{dev: dev, ch: ch}
+}
+
+func (c *PCAChannel) ChangeDutyCycle(duty float64) {
+	duty = clampDuty(duty)
+	var err error
+	switch duty {
+	case 0:
+		err = c.dev.SetFullOff(c.ch)
+	case 100:
+		err = c.dev.SetFullOn(c.ch)
+	default:
+		err = c.dev.SetPwm(c.ch, 0, gpio.Duty(duty/100*4095))
+	}
+	if err != nil {
+		log.Printf("PCA9685 channel %d: %v", c.ch, err)
+	}
+}
+
+func (c *PCAChannel) Stop() { c.ChangeDutyCycle(0) }
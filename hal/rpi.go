@@ -0,0 +1,266 @@
+package hal
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/stianeikeland/go-rpio/v4"
+	"periph.io/x/conn/v3/driver/driverreg"
+	"periph.io/x/conn/v3/gpio"
+	"periph.io/x/conn/v3/i2c"
+	"periph.io/x/host/v3/gpioioctl"
+	"periph.io/x/host/v3/sysfs"
+)
+
+// RPi is the Raspberry Pi: GPIO through rpio, encoders through the kernel's
+// GPIO character device and I²C bus 1 through periph.
+type RPi struct {
+	stop chan struct{} // closed by Close to end the encoder watchers
+}
+
+// OpenRPi maps the GPIO registers. It fails anywhere but a Pi.
+func OpenRPi() (*RPi, error) {
+	if err := rpio.Open(); err != nil {
+		return nil, err
+	}
+	return &RPi{stop: make(chan struct{})}, nil
+}
+
+func (*RPi) Pin(bcm int) Pin { return rpioPin{rpio.Pin(bcm)} }
+
+func (*RPi) PWM(pin Pin, hz int) DutyCycler { return NewSoftPWM(pin, hz) }
+
+func (*RPi) HardwarePWM(bcm, hz int) (DutyCycler, error) {
+	if !isHardwarePWMPin(bcm) {
+		return nil, fmt.Errorf("BCM %d has no hardware PWM (use one of %v)", bcm, HardwarePWMPins)
+	}
+	pin := rpio.Pin(bcm)
+	pin.Pwm()
+	pin.Freq(hz * hwCycle)
+	pin.DutyCycle(0, hwCycle)
+	return &hardwarePWM{pin: pin}, nil
+}
+
+func (*RPi) I2C() (i2c.BusCloser, error) { return sysfs.NewI2C(1) }
+
+func (r *RPi) Close() error {
+	close(r.stop)
+	return rpio.Close()
+}
+
+type rpioPin struct{ p rpio.Pin }
+
+func (p rpioPin) Output()    { p.p.Output() }
+func (p rpioPin) Input()     { p.p.Input() }
+func (p rpioPin) High()      { p.p.High() }
+func (p rpioPin) Low()       { p.p.Low() }
+func (p rpioPin) Read() bool { return p.p.Read() == rpio.High }
+
+// --- Hardware PWM ---------------------------------------------------------
+
+// HardwarePWMPins are the BCM pins routed to the Pi's PWM peripheral.
+var HardwarePWMPins = []int{12, 13, 18, 19}
+
+// hwCycle is the number of PWM clock ticks per period, one per duty percent.
+const hwCycle = 100
+
+func isHardwarePWMPin(bcm int) bool {
+	for _, p := range HardwarePWMPins {
+		if p == bcm {
+			return true
+		}
+	}
+	return false
+}
+
+type hardwarePWM struct {
+	pin rpio.Pin
+}
+
+func (p *hardwarePWM) ChangeDutyCycle(duty float64) {
+	p.pin.DutyCycle(uint32(clampDuty(duty)), hwCycle)
+}
+
+func (p *hardwarePWM) Stop() { p.pin.DutyCycle(0, hwCycle) }
+
+// --- Encoders -------------------------------------------------------------
+
+// EncoderWake is how often a blocked encoder wakes to see if the board has
+// been closed. Edges themselves come from the kernel's GPIO character
+// device: each one is timestamped and queued (16 per line) as it happens,
+// so an encoder only loses edges if its goroutine falls a whole queue
+// behind, and costs nothing while the wheel is still.
+var EncoderWake = 100 * time.Millisecond
+
+// quadStep is the count change for a quadrature transition, indexed by
+// previous state<<2 | new state, where state is A<<1 | B. Impossible
+// transitions (both lines changing) count as 0.
+var quadStep = [16]int64{0, 1, -1, 0, -1, 0, 0, 1, 1, 0, 0, -1, 0, -1, 1, 0}
+
+type rpiEncoder struct {
+	lines *gpioioctl.LineSet
+	a, b  int // line numbers on the chip, which are BCM numbers on a Pi
+	quad  bool
+	count atomic.Int64
+}
+
+var gpioInit sync.Once
+
+// gpioChip returns the GPIO chip with the BCM line, registering the ioctl
+// GPIO driver the first time.
+func gpioChip(bcm int) (*gpioioctl.GPIOChip, error) {
+	var err error
+	gpioInit.Do(func() { _, err = driverreg.Init() })
+	if err != nil {
+		return nil, err
+	}
+	for _, chip := range gpioioctl.Chips {
+		if chip.ByName(lineName(bcm)) != nil {
+			return chip, nil
+		}
+	}
+	return nil, fmt.Errorf("no GPIO chip has %s", lineName(bcm))
+}
+
+func lineName(bcm int) string { return fmt.Sprintf("GPIO%d", bcm) }
+
+func (r *RPi) Encoder(a, b int) (Encoder, error) {
+	chip, err := gpioChip(a)
+	if err != nil {
+		return nil, err
+	}
+	names := []string{lineName(a)}
+	if b != 0 {
+		names = append(names, lineName(b))
+	}
+	ls, err := chip.LineSet(gpioioctl.LineInput, gpio.BothEdges, gpio.PullUp, names...)
+	if err != nil {
+		return nil, fmt.Errorf("encoder on BCM %d: %w", a, err)
+	}
+	e := &rpiEncoder{lines: ls, a: a, b: b, quad: b != 0}
+	go e.watch(r.stop)
+	return e, nil
+}
+
+func (e *rpiEncoder) Count() int64 { return e.count.Load() }
+
+func (e *rpiEncoder) state() int {
+	s := 0
+	lines := e.lines.Lines()
+	if lines[0].Read() == gpio.High {
+		s |= 2
+	}
+	if e.quad && lines[1].Read() == gpio.High {
+		s |= 1
+	}
+	return s
+}
+
+// watch blocks for each edge and counts it. The edge says which line moved
+// and which way, so the new state needs no second read that a later edge
+// could race.
+func (e *rpiEncoder) watch(stop <-chan struct{}) {
+	defer e.lines.Close()
+	prev := e.state()
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+		}
+		line, edge, err := e.lines.WaitForEdge(EncoderWake)
+		if errors.Is(err, os.ErrDeadlineExceeded) || err == nil && edge == gpio.NoEdge {
+			continue
+		}
+		if err != nil {
+			log.Printf("encoder on BCM %d stopped: %v", e.a, err)
+			return
+		}
+		if !e.quad {
+			e.count.Add(1)
+			continue
+		}
+		bit := 2
+		if line == e.b {
+			bit = 1
+		}
+		cur := prev &^ bit
+		if edge == gpio.RisingEdge {
+			cur |= bit
+		}
+		e.count.Add(quadStep[prev<<2|cur])
+		prev = cur
+	}
+}
+
+// --- Software PWM ---------------------------------------------------------
+
+// SoftPWM implements a simple software PWM on a single pin.
+type SoftPWM struct {
+	pin   Pin
+	freq  time.Duration
+	duty  float64 // 0–100
+	quit  chan struct{}
+	guard sync.Mutex
+}
+
+// NewSoftPWM starts a PWM at hz on the given pin.
+func NewSoftPWM(pin Pin, hz int) *SoftPWM {
+	p := &SoftPWM{
+		pin:  pin,
+		freq: time.Second / time.Duration(hz),
+		duty: 0,
+		quit: make(chan struct{}),
+	}
+	pin.Output()
+	go p.run()
+	return p
+}
+
+func (p *SoftPWM) run() {
+	ticker := time.NewTicker(p.freq)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.guard.Lock()
+			d := p.duty / 100.0
+			p.guard.Unlock()
+
+			high := time.Duration(float64(p.freq) * d)
+			p.pin.High()
+			time.Sleep(high)
+			p.pin.Low()
+			time.Sleep(p.freq - high)
+		case <-p.quit:
+			p.pin.Low()
+			return
+		}
+	}
+}
+
+// ChangeDutyCycle sets duty to 0–100.
+func (p *SoftPWM) ChangeDutyCycle(duty float64) {
+	p.guard.Lock()
+	p.duty = clampDuty(duty)
+	p.guard.Unlock()
+}
+
+// Stop halts the PWM goroutine and drives pin low.
+func (p *SoftPWM) Stop() {
+	close(p.quit)
+}
+
+func clampDuty(duty float64) float64 {
+	if duty < 0 {
+		return 0
+	} else if duty > 100 {
+		return 100
+	}
+	return duty
+}
@@ -0,0 +1,483 @@
+package hal
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"periph.io/x/conn/v3/i2c"
+	"periph.io/x/conn/v3/physic"
+)
+
+// --- Simulated robot --------------------------------------------------------
+
+// SimWheel is one motor as wired to the board. Push is +1 if driving the
+// forward pin moves the robot forward, -1 if it moves it backwards; Side is
+// +1 for a right-hand wheel and -1 for a left-hand one. A wheel whose speed
+// line is a PCA9685 output sets PCAAddr and PCAChannel instead of Enable.
+// Encoder is the A pin of the wheel's encoder, 0 for none, and Gain scales
+// this motor's speed against the others (0 means 1), for mismatched motors.
+type SimWheel struct {
+	Enable, Forward, Reverse int
+	Push, Side               float64
+	PCAAddr                  uint16
+	PCAChannel               int
+	Encoder                  int
+	Gain                     float64
+}
+
+// MotorModel makes the simulated motors behave more like real ones. The
+// zero value is an ideal motor whose speed follows duty instantly.
+type MotorModel struct {
+	Deadband float64       // duty below which the motor doesn't turn
+	Battery  float64       // fraction of full speed the battery delivers, 0 for 1
+	Lag      time.Duration // time constant of the speed response
+}
+
+// Obstacle is a round obstacle in the world, in metres.
+type Obstacle struct {
+	X, Y, R float64
+}
+
+// SimConfig describes the simulated robot and its world.
+type SimConfig struct {
+	Wheels        []SimWheel
+	TrackWidth    float64 // metres between left and right wheels
+	MaxWheelSpeed float64 // metres/second at 100% duty
+	Motor         MotorModel
+	TicksPerMetre float64 // encoder counts per metre of wheel travel
+
+	SonarTrigger, SonarEcho int     // 0 for no ultrasonic sensor
+	SonarRange              float64 // metres
+	IRPins                  []int   // read high while an obstacle is within IRRange
+	IRRange                 float64 // metres
+
+	Obstacles []Obstacle
+
+	// PCA9685 pulse counts for 0° and 180°, as in servo.DefaultCalibrations.
+	ServoPulseMin, ServoPulseMax float64
+}
+
+// Pose is the robot's position in the world: metres and radians, theta
+// counter-clockwise from the x axis.
+type Pose struct {
+	X, Y, Theta float64
+}
+
+// Sim is a Board backed by a simple world model. Motor pins and PWM duty
+// drive skid-steer kinematics, the ultrasonic echo pin answers pings with
+// the distance to the nearest obstacle ahead, and the I²C bus decodes
+// PCA9685 writes into servo angles.
+type Sim struct {
+	cfg SimConfig
+	bus *SimI2C
+
+	mu     sync.Mutex
+	pins   map[int]*simPin
+	pwms   map[int]*simPWM
+	pose   Pose
+	speeds []float64
+	motor  []float64 // each motor's speed in its own forward direction
+	travel []float64 // metres each motor has turned, signed
+	odo    []float64 // metres each motor has turned, unsigned
+	pingAt time.Time
+	echo   float64 // seconds the echo pin stays high after pingAt
+}
+
+// sonarNoTarget is how long an HC-SR04 holds its echo high when nothing is
+// in range.
+const sonarNoTarget = 0.038
+
+func NewSim(cfg SimConfig) *Sim {
+	return &Sim{
+		cfg:    cfg,
+		bus:    NewSimI2C(),
+		pins:   map[int]*simPin{},
+		pwms:   map[int]*simPWM{},
+		speeds: make([]float64, len(cfg.Wheels)),
+		motor:  make([]float64, len(cfg.Wheels)),
+		travel: make([]float64, len(cfg.Wheels)),
+		odo:    make([]float64, len(cfg.Wheels)),
+	}
+}
+
+func (s *Sim) Pin(bcm int) Pin {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.pins[bcm]
+	if !ok {
+		p = &simPin{sim: s, n: bcm}
+		s.pins[bcm] = p
+	}
+	return p
+}
+
+func (s *Sim) PWM(pin Pin, hz int) DutyCycler {
+	p := &simPWM{}
+	if sp, ok := pin.(*simPin); ok {
+		s.mu.Lock()
+		s.pwms[sp.n] = p
+		s.mu.Unlock()
+	}
+	return p
+}
+
+func (s *Sim) HardwarePWM(bcm, hz int) (DutyCycler, error) {
+	if !isHardwarePWMPin(bcm) {
+		return nil, fmt.Errorf("BCM %d has no hardware PWM (use one of %v)", bcm, HardwarePWMPins)
+	}
+	return s.PWM(s.Pin(bcm), hz), nil
+}
+
+// Encoder counts the travel of the wheel whose Encoder pin is a: signed for
+// a quadrature encoder, unsigned for a single hall sensor (b == 0).
+func (s *Sim) Encoder(a, b int) (Encoder, error) {
+	for i, w := range s.cfg.Wheels {
+		if w.Encoder != 0 && w.Encoder == a {
+			return &simEncoder{sim: s, wheel: i, quad: b != 0}, nil
+		}
+	}
+	return nil, fmt.Errorf("no simulated encoder on BCM %d", a)
+}
+
+func (s *Sim) I2C() (i2c.BusCloser, error) { return s.bus, nil }
+
+func (s *Sim) Close() error { return nil }
+
+// Bus is the simulated I²C bus, for inspecting servo angles.
+func (s *Sim) Bus() *SimI2C { return s.bus }
+
+func (s *Sim) Pose() Pose {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pose
+}
+
+func (s *Sim) SetPose(p Pose) {
+	s.mu.Lock()
+	s.pose = p
+	s.mu.Unlock()
+}
+
+// WheelSpeeds returns each wheel's ground speed in m/s, positive pushing
+// the robot forward.
+func (s *Sim) WheelSpeeds() []float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]float64(nil), s.speeds...)
+}
+
+// ServoAngles decodes the PCA9685 at its default address.
+func (s *Sim) ServoAngles() map[int]float64 {
+	return s.bus.Angles(0x40, s.cfg.ServoPulseMin, s.cfg.ServoPulseMax)
+}
+
+// Step advances the world by dt.
+func (s *Sim) Step(dt time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := dt.Seconds()
+	var left, right float64
+	var nLeft, nRight int
+	for i, w := range s.cfg.Wheels {
+		dir := 0.0
+		if s.level(w.Forward) && !s.level(w.Reverse) {
+			dir = 1
+		} else if s.level(w.Reverse) && !s.level(w.Forward) {
+			dir = -1
+		}
+		duty := 0.0
+		if w.PCAAddr != 0 {
+			duty = s.bus.Duty(w.PCAAddr, w.PCAChannel)
+		} else if p, ok := s.pwms[w.Enable]; ok {
+			duty = p.Duty()
+		}
+		s.motor[i] = s.motorSpeed(w, s.motor[i], duty*dir, t)
+		s.travel[i] += s.motor[i] * t
+		s.odo[i] += math.Abs(s.motor[i]) * t
+		v := s.motor[i] * w.Push
+		s.speeds[i] = v
+		if w.Side > 0 {
+			right += v
+			nRight++
+		} else {
+			left += v
+			nLeft++
+		}
+	}
+	if nLeft > 0 {
+		left /= float64(nLeft)
+	}
+	if nRight > 0 {
+		right /= float64(nRight)
+	}
+
+	v := (left + right) / 2
+	omega := 0.0
+	if s.cfg.TrackWidth > 0 {
+		omega = (right - left) / s.cfg.TrackWidth
+	}
+	s.pose.X += v * math.Cos(s.pose.Theta) * t
+	s.pose.Y += v * math.Sin(s.pose.Theta) * t
+	s.pose.Theta = math.Remainder(s.pose.Theta+omega*t, 2*math.Pi)
+}
+
+// motorSpeed moves a motor's speed towards what duty (signed) would give it
+// under the motor model. Callers hold mu.
+func (s *Sim) motorSpeed(w SimWheel, current, duty, t float64) float64 {
+	m := s.cfg.Motor
+	mag := math.Abs(duty)
+	if mag <= m.Deadband {
+		mag = 0
+	} else {
+		mag = (mag - m.Deadband) / (100 - m.Deadband)
+	}
+	if m.Battery > 0 {
+		mag *= m.Battery
+	}
+	if w.Gain > 0 {
+		mag *= w.Gain
+	}
+	target := math.Copysign(mag*s.cfg.MaxWheelSpeed, duty)
+	if m.Lag <= 0 {
+		return target
+	}
+	return current + (target-current)*math.Min(1, t/m.Lag.Seconds())
+}
+
+// Run steps the world in real time until stop is closed.
+func (s *Sim) Run(tick time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.Step(tick)
+		}
+	}
+}
+
+// Ahead returns the distance in metres to the nearest obstacle straight
+// ahead, within max.
+func (s *Sim) Ahead(max float64) (float64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ahead(max)
+}
+
+func (s *Sim) ahead(max float64) (float64, bool) {
+	dx, dy := math.Cos(s.pose.Theta), math.Sin(s.pose.Theta)
+	best, hit := max, false
+	for _, o := range s.cfg.Obstacles {
+		// ray/circle intersection
+		ox, oy := o.X-s.pose.X, o.Y-s.pose.Y
+		along := ox*dx + oy*dy
+		if along < 0 {
+			continue
+		}
+		perp2 := ox*ox + oy*oy - along*along
+		if perp2 > o.R*o.R {
+			continue
+		}
+		d := along - math.Sqrt(o.R*o.R-perp2)
+		if d < 0 {
+			d = 0
+		}
+		if d <= best {
+			best, hit = d, true
+		}
+	}
+	return best, hit
+}
+
+// level reports a pin's output level. Callers hold mu.
+func (s *Sim) level(n int) bool {
+	p, ok := s.pins[n]
+	return ok && p.high
+}
+
+// ping starts an echo for the obstacle ahead. Callers hold mu.
+func (s *Sim) ping() {
+	s.pingAt = time.Now()
+	s.echo = sonarNoTarget
+	if d, ok := s.ahead(s.cfg.SonarRange); ok {
+		s.echo = math.Max(2*d/343.0, 1e-5)
+	}
+}
+
+// read answers a pin read, simulating the sensors wired to it.
+func (s *Sim) read(p *simPin) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p.n == s.cfg.SonarEcho && s.cfg.SonarEcho != 0 {
+		return s.echo > 0 && time.Since(s.pingAt).Seconds() < s.echo
+	}
+	for _, ir := range s.cfg.IRPins {
+		if p.n == ir {
+			_, hit := s.ahead(s.cfg.IRRange)
+			return hit
+		}
+	}
+	return p.high
+}
+
+type simPin struct {
+	sim  *Sim
+	n    int
+	high bool
+}
+
+func (p *simPin) Output() {}
+func (p *simPin) Input()  {}
+func (p *simPin) Read() bool {
+	return p.sim.read(p)
+}
+
+func (p *simPin) High() {
+	p.sim.mu.Lock()
+	p.high = true
+	p.sim.mu.Unlock()
+}
+
+func (p *simPin) Low() {
+	p.sim.mu.Lock()
+	falling := p.high
+	p.high = false
+	if falling && p.n == p.sim.cfg.SonarTrigger && p.sim.cfg.SonarTrigger != 0 {
+		p.sim.ping()
+	}
+	p.sim.mu.Unlock()
+}
+
+type simEncoder struct {
+	sim   *Sim
+	wheel int
+	quad  bool
+}
+
+func (e *simEncoder) Count() int64 {
+	e.sim.mu.Lock()
+	defer e.sim.mu.Unlock()
+	if e.quad {
+		return int64(e.sim.travel[e.wheel] * e.sim.cfg.TicksPerMetre)
+	}
+	return int64(e.sim.odo[e.wheel] * e.sim.cfg.TicksPerMetre)
+}
+
+type simPWM struct {
+	mu   sync.Mutex
+	duty float64
+}
+
+func (p *simPWM) ChangeDutyCycle(duty float64) {
+	p.mu.Lock()
+	p.duty = clampDuty(duty)
+	p.mu.Unlock()
+}
+
+func (p *simPWM) Stop() { p.ChangeDutyCycle(0) }
+
+func (p *simPWM) Duty() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.duty
+}
+
+// --- Simulated I²C ----------------------------------------------------------
+
+// SimI2C is an I²C bus of register-file devices with auto-increment, which
+// is all the PCA9685 driver needs.
+type SimI2C struct {
+	mu   sync.Mutex
+	regs map[uint16]*[256]byte
+	set  map[uint16]*[16]bool // channels given a per-channel PWM value
+}
+
+func NewSimI2C() *SimI2C {
+	return &SimI2C{regs: map[uint16]*[256]byte{}, set: map[uint16]*[16]bool{}}
+}
+
+func (b *SimI2C) Tx(addr uint16, w, r []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if addr == 0 {
+		return nil // general call (software reset)
+	}
+	regs, ok := b.regs[addr]
+	if !ok {
+		regs = &[256]byte{}
+		b.regs[addr] = regs
+		b.set[addr] = &[16]bool{}
+	}
+	if len(w) == 0 {
+		return nil
+	}
+	reg := int(w[0])
+	for i, v := range w[1:] {
+		at := (reg + i) & 0xFF
+		regs[at] = v
+		if at >= 0x06 && at < 0x46 {
+			b.set[addr][(at-0x06)/4] = true
+		}
+	}
+	for i := range r {
+		r[i] = regs[(reg+i)&0xFF]
+	}
+	return nil
+}
+
+func (b *SimI2C) SetSpeed(physic.Frequency) error { return nil }
+func (b *SimI2C) String() string                  { return "simI2C" }
+func (b *SimI2C) Close() error                    { return nil }
+
+// Pulse returns the OFF count of a PCA9685 channel at addr, or false if it
+// was never set or is fully off.
+func (b *SimI2C) Pulse(addr uint16, ch int) (int, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	regs, ok := b.regs[addr]
+	if !ok || !b.set[addr][ch] {
+		return 0, false
+	}
+	base := 0x06 + 4*ch
+	if regs[base+3]&0x10 != 0 {
+		return 0, false
+	}
+	return int(regs[base+2]) | int(regs[base+3]&0x0F)<<8, true
+}
+
+// Duty returns a PCA9685 channel's duty cycle, 0–100.
+func (b *SimI2C) Duty(addr uint16, ch int) float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	regs, ok := b.regs[addr]
+	if !ok {
+		return 0
+	}
+	base := 0x06 + 4*ch
+	switch {
+	case regs[base+3]&0x10 != 0:
+		return 0
+	case regs[base+1]&0x10 != 0:
+		return 100
+	}
+	on := int(regs[base]) | int(regs[base+1]&0x0F)<<8
+	off := int(regs[base+2]) | int(regs[base+3]&0x0F)<<8
+	return float64((off-on+4096)%4096) / 4096 * 100
+}
+
+// Angles maps the PCA9685 at addr back to servo angles, given the pulse
+// counts for 0° and 180°.
+func (b *SimI2C) Angles(addr uint16, min, max float64) map[int]float64 {
+	out := map[int]float64{}
+	for ch := 0; ch < 16; ch++ {
+		if pulse, ok := b.Pulse(addr, ch); ok {
+			out[ch] = (float64(pulse) - min) / (max - min) * 180
+		}
+	}
+	return out
+}
@@ -0,0 +1,198 @@
+package hal
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"periph.io/x/conn/v3/physic"
+	"periph.io/x/devices/v3/pca9685"
+)
+
+// a two-wheel robot: left wheel on pins 1/2/3, right on 4/5/6
+func twoWheel() *Sim {
+	return NewSim(SimConfig{
+		Wheels: []SimWheel{
+			{Enable: 1, Forward: 2, Reverse: 3, Push: 1, Side: -1},
+			{Enable: 4, Forward: 5, Reverse: 6, Push: 1, Side: 1},
+		},
+		TrackWidth:    0.2,
+		MaxWheelSpeed: 1,
+		SonarTrigger:  7,
+		SonarEcho:     8,
+		SonarRange:    4,
+		Obstacles:     []Obstacle{{X: 3, Y: 0, R: 0.5}},
+	})
+}
+
+func drive(s *Sim, left, right float64) {
+	for _, w := range []struct {
+		e, f, r int
+		v       float64
+	}{{1, 2, 3, left}, {4, 5, 6, right}} {
+		pwm := s.PWM(s.Pin(w.e), 50)
+		pwm.ChangeDutyCycle(math.Abs(w.v))
+		Write(s.Pin(w.f), w.v > 0)
+		Write(s.Pin(w.r), w.v < 0)
+	}
+}
+
+func TestSimKinematics(t *testing.T) {
+	s := twoWheel()
+	drive(s, 50, 50)
+	for i := 0; i < 100; i++ {
+		s.Step(10 * time.Millisecond)
+	}
+	if p := s.Pose(); math.Abs(p.X-0.5) > 1e-9 || p.Y != 0 || p.Theta != 0 {
+		t.Errorf("straight: %+v", p)
+	}
+	if v := s.WheelSpeeds(); v[0] != 0.5 || v[1] != 0.5 {
+		t.Errorf("wheel speeds %v", v)
+	}
+
+	// spin in place: 0.2 m/s each way over a 0.2m track is 2 rad/s
+	s.SetPose(Pose{})
+	drive(s, -20, 20)
+	for i := 0; i < 50; i++ {
+		s.Step(10 * time.Millisecond)
+	}
+	if p := s.Pose(); math.Abs(p.Theta-1) > 1e-9 || math.Abs(p.X) > 1e-9 {
+		t.Errorf("spin: %+v", p)
+	}
+}
+
+func TestSimSonar(t *testing.T) {
+	s := twoWheel()
+	if d, ok := s.Ahead(4); !ok || math.Abs(d-2.5) > 1e-9 {
+		t.Fatalf("ahead %v %v", d, ok)
+	}
+	trig, echo := s.Pin(7), s.Pin(8)
+	trig.High()
+	trig.Low()
+	if !echo.Read() {
+		t.Fatal("echo should go high after a ping")
+	}
+	time.Sleep(20 * time.Millisecond) // 2.5m is a ~14.6ms round trip
+	if echo.Read() {
+		t.Fatal("echo should be over")
+	}
+
+	// nothing behind the robot: the long no-target pulse
+	s.SetPose(Pose{Theta: math.Pi})
+	trig.High()
+	trig.Low()
+	time.Sleep(30 * time.Millisecond)
+	if !echo.Read() {
+		t.Fatal("echo should outlast the sensor's range")
+	}
+}
+
+func TestSimI2CServoAngles(t *testing.T) {
+	bus := NewSimI2C()
+	pca, err := pca9685.NewI2C(bus, pca9685.I2CAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sg := pca9685.NewServoGroup(pca, 50, 650, 0, 180)
+	sg.SetAngle(4, physic.Angle(90))
+	sg.SetAngle(15, physic.Angle(30))
+
+	got := bus.Angles(pca9685.I2CAddr, 50, 650)
+	if len(got) != 2 || got[4] != 90 || got[15] != 30 {
+		t.Errorf("angles %v", got)
+	}
+	pca.SetFullOff(4)
+	if _, ok := bus.Pulse(pca9685.I2CAddr, 4); ok {
+		t.Error("full-off channel still reports a pulse")
+	}
+}
+
+func TestPCAChannelDuty(t *testing.T) {
+	bus := NewSimI2C()
+	dev, err := pca9685.NewI2C(bus, 0x41)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewPCAChannel(dev, 3)
+	for _, tc := range []struct{ in, want float64 }{
+		{50, 50}, {100, 100}, {0, 0}, {150, 100}, {25, 25},
+	} {
+		c.ChangeDutyCycle(tc.in)
+		if got := bus.Duty(0x41, 3); math.Abs(got-tc.want) > 0.1 {
+			t.Errorf("duty %v: got %v, want %v", tc.in, got, tc.want)
+		}
+	}
+	c.Stop()
+	if got := bus.Duty(0x41, 3); got != 0 {
+		t.Errorf("stopped: %v", got)
+	}
+	if got := bus.Duty(0x41, 4); got != 0 {
+		t.Errorf("channel 4 touched: %v", got)
+	}
+}
+
+func TestSimHardwarePWM(t *testing.T) {
+	s := twoWheel()
+	if _, err := s.HardwarePWM(17, 1000); err == nil {
+		t.Error("BCM 17 has no hardware PWM")
+	}
+	if _, err := s.HardwarePWM(18, 1000); err != nil {
+		t.Errorf("BCM 18: %v", err)
+	}
+}
+
+func TestSimEncodersAndMotorModel(t *testing.T) {
+	s := NewSim(SimConfig{
+		Wheels: []SimWheel{
+			{Enable: 1, Forward: 2, Reverse: 3, Push: 1, Side: -1, Encoder: 20},
+			{Enable: 4, Forward: 5, Reverse: 6, Push: 1, Side: 1, Encoder: 21, Gain: 0.5},
+		},
+		TrackWidth:    0.2,
+		MaxWheelSpeed: 1,
+		Motor:         MotorModel{Deadband: 20, Battery: 0.8, Lag: 100 * time.Millisecond},
+		TicksPerMetre: 1000,
+	})
+	quad, err := s.Encoder(20, 22)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hall, err := s.Encoder(21, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Encoder(9, 10); err == nil {
+		t.Error("BCM 9 has no encoder")
+	}
+
+	// inside the deadband nothing turns
+	drive(s, 15, 15)
+	s.Step(time.Second)
+	if v := s.WheelSpeeds(); v[0] != 0 || v[1] != 0 {
+		t.Fatalf("deadband: %v", v)
+	}
+
+	// 60% duty is half of the usable range, at 80% battery
+	drive(s, 60, 60)
+	s.Step(50 * time.Millisecond)
+	if v := s.WheelSpeeds(); math.Abs(v[0]-0.2) > 1e-9 {
+		t.Errorf("lag: left wheel %v after half a time constant", v[0])
+	}
+	for i := 0; i < 100; i++ {
+		s.Step(10 * time.Millisecond)
+	}
+	if v := s.WheelSpeeds(); math.Abs(v[0]-0.4) > 1e-3 || math.Abs(v[1]-0.2) > 1e-3 {
+		t.Errorf("steady state %v", v)
+	}
+
+	q, h := quad.Count(), hall.Count()
+	drive(s, -60, -60)
+	for i := 0; i < 100; i++ {
+		s.Step(10 * time.Millisecond)
+	}
+	if quad.Count() >= q {
+		t.Errorf("quadrature count should fall in reverse: %d -> %d", q, quad.Count())
+	}
+	if hall.Count() <= h {
+		t.Errorf("hall count should keep rising: %d -> %d", h, hall.Count())
+	}
+}
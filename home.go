@@ -104,7 +104,7 @@ func HomePage(websocketRegistry *CommandRegistry) *Node {
 			Raw(LoadFile("home.js")),
 		),
 		Attr("hx-ext", "ws"),
-		Attr("ws-connect", "/ws/hub?room="+id),
+		Attr("ws-connect", "/ws/hub?room="+id+TokenParam(id, "")),
 		Div(Attrs(map[string]string{
 			"class":      "flex flex-col items-center min-h-screen",
 			"data-theme": "dark",
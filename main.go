@@ -41,7 +41,18 @@ func main() {
 	Notecard(mux, globalRegistry)
 
 	WithWS("/ws/logs", mux, logSocketWS)
+	mux.HandleFunc("/ws/token", TokenHandler)
+	if !AuthEnabled() {
+		log.Println("WS_TOKEN_SECRET not set – websocket connections are not authenticated")
+	}
+
+	proxies, err := ParseProxies(os.Getenv("WS_TRUSTED_PROXIES"))
+	if err != nil {
+		log.Fatalf("WS_TRUSTED_PROXIES: %v", err)
+	}
+	TrustedProxies = proxies
 
+	setupPubSub()
 	go WsHub.Run()
 
 	log.Println("WebRTC server started on port", webPort)
@@ -54,6 +65,29 @@ var debugEnabled = func() bool {
 	return v == "1" || v == "true" || v == "yes"
 }()
 
+// setupPubSub shares hub rooms with other instances when WS_BROKER is set.
+// WS_BROKER_LISTEN additionally runs the broker inside this process.
+func setupPubSub() {
+	if addr := os.Getenv("WS_BROKER_LISTEN"); addr != "" {
+		broker, err := ListenBroker(addr)
+		if err != nil {
+			log.Fatalf("ws broker listen %s: %v", addr, err)
+		}
+		log.Println("WS broker listening on", broker.Addr())
+		go broker.Serve()
+	}
+	addr := os.Getenv("WS_BROKER")
+	if addr == "" {
+		return
+	}
+	ps, err := NewTCPPubSub(addr)
+	if err != nil {
+		log.Fatalf("ws broker dial %s: %v", addr, err)
+	}
+	log.Println("WS hub using broker at", addr)
+	WsHub.PubSub = ps
+}
+
 // logSocketWS streams browser logs to both file and stdout
 func logSocketWS(conn *websocket.Conn) {
 	if !debugEnabled {
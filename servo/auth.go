@@ -0,0 +1,191 @@
+package servo
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// --- Listening --------------------------------------------------------------
+
+// ServerConfig secures the servo server. The zero value is the old
+// behaviour: plain TCP, anyone may call.
+type ServerConfig struct {
+	Cert, Key string // server certificate and key; both or neither
+	ClientCA  string // with TLS, require client certificates signed by this CA (mTLS)
+	Token     string // require "authorization: Bearer <token>" on every call
+}
+
+// ServerOptions turns c into gRPC server options.
+func (c ServerConfig) ServerOptions() ([]grpc.ServerOption, error) {
+	var opts []grpc.ServerOption
+	if c.Cert != "" || c.Key != "" {
+		cert, err := tls.LoadX509KeyPair(c.Cert, c.Key)
+		if err != nil {
+			return nil, fmt.Errorf("server certificate: %w", err)
+		}
+		cfg := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+		if c.ClientCA != "" {
+			pool, err := loadCertPool(c.ClientCA)
+			if err != nil {
+				return nil, err
+			}
+			cfg.ClientCAs = pool
+			cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(cfg)))
+	} else if c.ClientCA != "" {
+		return nil, errors.New("a client CA needs a server certificate and key")
+	}
+	if c.Token != "" {
+		opts = append(opts,
+			grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+				if err := c.authorize(ctx, info.FullMethod); err != nil {
+					return nil, err
+				}
+				return handler(ctx, req)
+			}),
+			grpc.ChainStreamInterceptor(func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+				if err := c.authorize(ss.Context(), info.FullMethod); err != nil {
+					return err
+				}
+				return handler(srv, ss)
+			}),
+		)
+	}
+	return opts, nil
+}
+
+// authorize checks the bearer token. Health checks don't need one, so
+// probes can run without the secret.
+func (c ServerConfig) authorize(ctx context.Context, method string) error {
+	if strings.HasPrefix(method, "/grpc.health.v1.Health/") {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, v := range md.Get("authorization") {
+		token, ok := strings.CutPrefix(v, "Bearer ")
+		if ok && subtle.ConstantTimeCompare([]byte(token), []byte(c.Token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "missing or wrong bearer token")
+}
+
+// Listen listens on addr: "unix:/path/to.sock" for a unix socket, which
+// only the owner and group may use, or a TCP address.
+func Listen(addr string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(addr, "unix:")
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+	path = strings.TrimPrefix(path, "//")
+	// a socket left by a server that didn't shut down cleanly
+	if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		os.Remove(path)
+	}
+	lis, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o660); err != nil {
+		lis.Close()
+		return nil, err
+	}
+	return lis, nil
+}
+
+// --- Dialling ---------------------------------------------------------------
+
+// DialConfig is how a client reaches a secured servo server.
+type DialConfig struct {
+	CA         string // verify the server against this CA; enables TLS
+	Cert, Key  string // client certificate for mTLS
+	ServerName string // override the name checked in the server certificate
+	Token      string // bearer token
+}
+
+// AddDialFlags registers -servo-ca, -servo-cert, -servo-key,
+// -servo-server-name and -servo-token on fs, the token defaulting to
+// $SERVO_TOKEN.
+func AddDialFlags(fs *flag.FlagSet) *DialConfig {
+	c := &DialConfig{}
+	fs.StringVar(&c.CA, "servo-ca", "", "CA certificate to verify the servo server with (enables TLS)")
+	fs.StringVar(&c.Cert, "servo-cert", "", "client certificate for mutual TLS with the servo server")
+	fs.StringVar(&c.Key, "servo-key", "", "client key for mutual TLS with the servo server")
+	fs.StringVar(&c.ServerName, "servo-server-name", "", "name to expect in the servo server's certificate (default: from the address)")
+	fs.StringVar(&c.Token, "servo-token", os.Getenv("SERVO_TOKEN"), "bearer token for the servo server")
+	return c
+}
+
+// DialOptions turns c into gRPC dial options for target. A token is only
+// sent over TLS or a unix socket.
+func (c DialConfig) DialOptions(target string) ([]grpc.DialOption, error) {
+	var opts []grpc.DialOption
+	secure := c.CA != ""
+	if secure {
+		pool, err := loadCertPool(c.CA)
+		if err != nil {
+			return nil, err
+		}
+		cfg := &tls.Config{RootCAs: pool, ServerName: c.ServerName, MinVersion: tls.VersionTLS12}
+		if c.Cert != "" || c.Key != "" {
+			cert, err := tls.LoadX509KeyPair(c.Cert, c.Key)
+			if err != nil {
+				return nil, fmt.Errorf("client certificate: %w", err)
+			}
+			cfg.Certificates = []tls.Certificate{cert}
+		}
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(cfg)))
+	} else {
+		if c.Cert != "" || c.Key != "" {
+			return nil, errors.New("a client certificate needs the server's CA too")
+		}
+		opts = append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+	if c.Token != "" {
+		local := strings.HasPrefix(target, "unix:")
+		if !secure && !local {
+			return nil, errors.New("refusing to send the servo token over plain TCP; use TLS or a unix socket")
+		}
+		opts = append(opts, grpc.WithPerRPCCredentials(bearerToken{token: c.Token, secure: secure}))
+	}
+	return opts, nil
+}
+
+type bearerToken struct {
+	token  string
+	secure bool
+}
+
+func (b bearerToken) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + b.token}, nil
+}
+
+func (b bearerToken) RequireTransportSecurity() bool { return b.secure }
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("%s: no certificates", path)
+	}
+	return pool, nil
+}
@@ -0,0 +1,197 @@
+package servo
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// serve runs a servo server with sec on addr and returns where it listens.
+func serve(t *testing.T, sec ServerConfig, addr string) string {
+	t.Helper()
+	opts, err := sec.ServerOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis, err := Listen(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer(opts...)
+	RegisterControllerServer(srv, newTestServer(t))
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	if lis.Addr().Network() == "unix" {
+		return "unix:" + lis.Addr().String()
+	}
+	return lis.Addr().String()
+}
+
+func dial(t *testing.T, target string, c DialConfig) *grpc.ClientConn {
+	t.Helper()
+	opts, err := c.DialOptions(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cc, err := grpc.NewClient(target, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cc.Close() })
+	return cc
+}
+
+func callCode(cc *grpc.ClientConn) codes.Code {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_, err := NewControllerClient(cc).GetAngles(ctx, &GetAnglesRequest{})
+	return status.Code(err)
+}
+
+func TestBearerToken(t *testing.T) {
+	target := serve(t, ServerConfig{Token: "s3cret"}, "unix:"+filepath.Join(t.TempDir(), "servo.sock"))
+
+	if c := callCode(dial(t, target, DialConfig{})); c != codes.Unauthenticated {
+		t.Errorf("no token: %v", c)
+	}
+	if c := callCode(dial(t, target, DialConfig{Token: "guess"})); c != codes.Unauthenticated {
+		t.Errorf("wrong token: %v", c)
+	}
+	good := dial(t, target, DialConfig{Token: "s3cret"})
+	if c := callCode(good); c != codes.OK {
+		t.Errorf("right token: %v", c)
+	}
+
+	// streams are checked too
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	stream, err := NewControllerClient(dial(t, target, DialConfig{})).WatchState(ctx, &WatchStateRequest{})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("WatchState without a token: %v", err)
+	}
+
+	// health checks don't need the secret
+	h, err := healthpb.NewHealthClient(dial(t, target, DialConfig{})).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil || h.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("health: %v, %v", h, err)
+	}
+}
+
+func TestTokenNeedsTLSOverTCP(t *testing.T) {
+	if _, err := (DialConfig{Token: "s3cret"}).DialOptions("robot.local:50051"); err == nil {
+		t.Error("would send the token in the clear")
+	}
+	if _, err := (DialConfig{Cert: "c.pem", Key: "k.pem"}).DialOptions("robot.local:50051"); err == nil {
+		t.Error("client certificate without a CA accepted")
+	}
+	if _, err := (ServerConfig{ClientCA: "ca.pem"}).ServerOptions(); err == nil {
+		t.Error("client CA without a server certificate accepted")
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := writeCert(t, dir, "ca", nil, nil)
+	writeCert(t, dir, "server", ca, caKey)
+	writeCert(t, dir, "client", ca, caKey)
+	writeCert(t, dir, "stranger", nil, nil)
+	file := func(name string) string { return filepath.Join(dir, name) }
+
+	target := serve(t, ServerConfig{
+		Cert: file("server.pem"), Key: file("server-key.pem"), ClientCA: file("ca.pem"),
+	}, "127.0.0.1:0")
+
+	if c := callCode(dial(t, target, DialConfig{CA: file("ca.pem"), Cert: file("client.pem"), Key: file("client-key.pem")})); c != codes.OK {
+		t.Errorf("client certificate: %v", c)
+	}
+	if c := callCode(dial(t, target, DialConfig{CA: file("ca.pem")})); c == codes.OK {
+		t.Error("no client certificate accepted")
+	}
+	if c := callCode(dial(t, target, DialConfig{CA: file("ca.pem"), Cert: file("stranger.pem"), Key: file("stranger-key.pem")})); c == codes.OK {
+		t.Error("certificate from another CA accepted")
+	}
+	if c := callCode(dial(t, target, DialConfig{})); c == codes.OK {
+		t.Error("plaintext accepted")
+	}
+}
+
+func TestListenUnixReplacesStaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "servo.sock")
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	lis, err := Listen("unix:" + path)
+	if err != nil {
+		t.Fatalf("Listen over a stale socket: %v", err)
+	}
+	defer lis.Close()
+	fi, err := os.Stat(path)
+	if err != nil || fi.Mode().Perm() != 0o660 {
+		t.Errorf("socket mode %v, %v", fi.Mode(), err)
+	}
+}
+
+// writeCert writes name.pem and name-key.pem to dir, signed by parent, or
+// self-signed as a CA when parent is nil. Leaf certificates are good for
+// localhost and 127.0.0.1.
+func writeCert(t *testing.T, dir, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	if parent == nil {
+		tmpl.IsCA, tmpl.BasicConstraintsValid = true, true
+		parent, parentKey = tmpl, key
+	} else {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+		tmpl.DNSNames = []string{"localhost"}
+		tmpl.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, name+".pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644)
+	os.WriteFile(filepath.Join(dir, name+"-key.pem"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
@@ -0,0 +1,218 @@
+package servo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// Calibration is how one channel's servo is driven: the angles it may move
+// between, where it rests, and how angles turn into PCA9685 pulses.
+type Calibration struct {
+	Min  float64 `json:"min"`  // degrees
+	Max  float64 `json:"max"`  // degrees
+	Home float64 `json:"home"` // where the server puts it on start-up
+
+	// PCA9685 counts (of 4096) at 0° and 180°
+	MinPulse int `json:"minPulse"`
+	MaxPulse int `json:"maxPulse"`
+
+	Invert bool    `json:"invert,omitempty"` // servo mounted the other way round
+	Trim   float64 `json:"trim,omitempty"`   // degrees added before the pulse is worked out
+
+	// Name lets requests say "arm.lift" rather than a channel number.
+	Name string `json:"name,omitempty"`
+
+	// Where the servo is wired. With neither Bus nor Addr it's output
+	// <channel> of the board at 0x40 on bus 1, as before boards were
+	// chained; otherwise it's Output of the board at Addr on Bus, either
+	// defaulting as before.
+	Bus    int    `json:"bus,omitempty"`    // /dev/i2c-<bus>
+	Addr   uint16 `json:"addr,omitempty"`   // I²C address, 0x40–0x7F
+	Output int    `json:"output,omitempty"` // 0–15
+}
+
+// MaxTrim bounds Trim: anything more means the horn is on the wrong spline.
+const MaxTrim = 30.0
+
+// Calibrations maps channels to their calibration. It's what the config
+// file holds, e.g.
+//
+//	{"4": {"min": 15, "max": 140, "home": 77.5, "minPulse": 50, "maxPulse": 650},
+//	 "16": {"name": "wrist.roll", "addr": 65, "output": 0, ...}}
+//
+// where channel 16 is the first output of a second board at 0x41. JSON has
+// no hex, so addresses are decimal.
+type Calibrations map[int]Calibration
+
+// DefaultCalibrations are the robot's servos as built, used when there's no
+// config file yet.
+var DefaultCalibrations = Calibrations{
+	4:  {Name: "claw.grip", Min: 15, Max: 140, Home: 77.5, MinPulse: 50, MaxPulse: 650},
+	5:  {Name: "claw.rotate", Min: 15, Max: 140, Home: 77.5, MinPulse: 50, MaxPulse: 650},
+	6:  {Name: "arm.lift", Min: 15, Max: 68, Home: 41.5, MinPulse: 50, MaxPulse: 650},
+	14: {Name: "camera.pan", Min: 15, Max: 140, Home: 77.5, MinPulse: 50, MaxPulse: 650},
+	15: {Name: "camera.tilt", Min: 15, Max: 140, Home: 15, MinPulse: 50, MaxPulse: 650}, // resting level
+}
+
+// Validate reports the first thing wrong with c.
+func (c Calibration) Validate() error {
+	switch {
+	case math.IsNaN(c.Min) || math.IsNaN(c.Max) || math.IsNaN(c.Home) || math.IsNaN(c.Trim):
+		return fmt.Errorf("angles must be numbers")
+	case c.Min < 0 || c.Max > 180 || c.Min >= c.Max:
+		return fmt.Errorf("min %g and max %g must satisfy 0 ≤ min < max ≤ 180", c.Min, c.Max)
+	case c.Home < c.Min || c.Home > c.Max:
+		return fmt.Errorf("home %g outside %g–%g", c.Home, c.Min, c.Max)
+	case c.MinPulse < 0 || c.MaxPulse > 4095 || c.MinPulse >= c.MaxPulse:
+		return fmt.Errorf("pulses %d and %d must satisfy 0 ≤ minPulse < maxPulse ≤ 4095", c.MinPulse, c.MaxPulse)
+	case math.Abs(c.Trim) > MaxTrim:
+		return fmt.Errorf("trim %g beyond ±%g", c.Trim, MaxTrim)
+	case c.Name != "" && !validName.MatchString(c.Name):
+		return fmt.Errorf("name %q: use letters, digits, '.', '_' and '-', starting with a letter", c.Name)
+	case c.Bus < 0:
+		return fmt.Errorf("bus %d can't be negative", c.Bus)
+	case c.Addr != 0 && (c.Addr < 0x40 || c.Addr > 0x7F):
+		return fmt.Errorf("address %#x: PCA9685s are at 0x40–0x7F", c.Addr)
+	case c.Output < 0 || c.Output > 15:
+		return fmt.Errorf("output %d: PCA9685 outputs are 0–15", c.Output)
+	}
+	return nil
+}
+
+// validName keeps names from looking like channel numbers.
+var validName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9._-]*$`)
+
+// Location is the board and output ch's servo is wired to.
+func (c Calibration) Location(ch int) (Board, int) {
+	if c.Bus == 0 && c.Addr == 0 {
+		return DefaultBoard, ch
+	}
+	b := DefaultBoard
+	if c.Bus != 0 {
+		b.Bus = c.Bus
+	}
+	if c.Addr != 0 {
+		b.Addr = c.Addr
+	}
+	return b, c.Output
+}
+
+// Pulse is the PCA9685 count that puts the servo at angle.
+func (c Calibration) Pulse(angle float64) int {
+	a := math.Max(0, math.Min(180, angle+c.Trim))
+	if c.Invert {
+		a = 180 - a
+	}
+	return c.MinPulse + int(math.Round(a/180*float64(c.MaxPulse-c.MinPulse)))
+}
+
+// Validate checks every channel, and that no two share an output or a name.
+func (cs Calibrations) Validate() error {
+	type wire struct {
+		board Board
+		out   int
+	}
+	wired := map[wire]int{}
+	named := map[string]int{}
+	for _, ch := range cs.channels() {
+		c := cs[ch]
+		if ch < 0 {
+			return fmt.Errorf("channel %d: channels can't be negative", ch)
+		}
+		if err := c.Validate(); err != nil {
+			return fmt.Errorf("channel %d: %w", ch, err)
+		}
+		b, out := c.Location(ch)
+		if out > 15 {
+			return fmt.Errorf("channel %d: only 0–15 are on the first board; give it a bus or addr and an output", ch)
+		}
+		if other, ok := wired[wire{b, out}]; ok {
+			return fmt.Errorf("channels %d and %d are both output %d of %s", other, ch, out, b)
+		}
+		wired[wire{b, out}] = ch
+		if c.Name == "" {
+			continue
+		}
+		if other, ok := named[c.Name]; ok {
+			return fmt.Errorf("channels %d and %d are both called %q", other, ch, c.Name)
+		}
+		named[c.Name] = ch
+	}
+	return nil
+}
+
+func (cs Calibrations) channels() []int {
+	chs := make([]int, 0, len(cs))
+	for ch := range cs {
+		chs = append(chs, ch)
+	}
+	sort.Ints(chs)
+	return chs
+}
+
+func (cs Calibrations) names() map[string]int {
+	names := map[string]int{}
+	for ch, c := range cs {
+		if c.Name != "" {
+			names[c.Name] = ch
+		}
+	}
+	return names
+}
+
+// LoadCalibrations reads the config file at path, falling back to
+// DefaultCalibrations if there isn't one.
+func LoadCalibrations(path string) (Calibrations, error) {
+	raw, err := os.ReadFile(path)
+	if path == "" || errors.Is(err, os.ErrNotExist) {
+		cs := Calibrations{}
+		for ch, c := range DefaultCalibrations {
+			cs[ch] = c
+		}
+		return cs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cs Calibrations
+	if err := json.Unmarshal(raw, &cs); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := cs.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cs, nil
+}
+
+// SaveCalibrations writes cs to path.
+func SaveCalibrations(path string, cs Calibrations) error {
+	return writeJSON(path, cs)
+}
+
+// writeJSON replaces the file at path via a temporary file, so a crash
+// can't leave it half written.
+func writeJSON(path string, v any) error {
+	raw, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(raw, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
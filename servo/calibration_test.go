@@ -0,0 +1,188 @@
+package servo
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCalibrationPulse(t *testing.T) {
+	c := Calibration{Min: 0, Max: 180, MinPulse: 100, MaxPulse: 500}
+	for _, tc := range []struct {
+		cal   Calibration
+		angle float64
+		want  int
+	}{
+		{c, 0, 100},
+		{c, 90, 300},
+		{c, 180, 500},
+		{c, 200, 500},
+		{Calibration{MinPulse: 100, MaxPulse: 500, Invert: true}, 45, 400},
+		{Calibration{MinPulse: 100, MaxPulse: 500, Trim: 9}, 81, 300},
+		{Calibration{MinPulse: 100, MaxPulse: 500, Trim: -10}, 5, 100},
+	} {
+		if got := tc.cal.Pulse(tc.angle); got != tc.want {
+			t.Errorf("%+v: Pulse(%v) = %d, want %d", tc.cal, tc.angle, got, tc.want)
+		}
+	}
+}
+
+func TestCalibrationValidate(t *testing.T) {
+	ok := Calibration{Min: 15, Max: 140, Home: 90, MinPulse: 50, MaxPulse: 650}
+	if err := ok.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	for name, edit := range map[string]func(*Calibration){
+		"min over max":   func(c *Calibration) { c.Min = 150 },
+		"past 180":       func(c *Calibration) { c.Max = 200 },
+		"home outside":   func(c *Calibration) { c.Home = 10 },
+		"pulses swapped": func(c *Calibration) { c.MinPulse, c.MaxPulse = 650, 50 },
+		"pulse too big":  func(c *Calibration) { c.MaxPulse = 5000 },
+		"trim":           func(c *Calibration) { c.Trim = 45 },
+	} {
+		c := ok
+		edit(&c)
+		if err := c.Validate(); err == nil {
+			t.Errorf("%s: accepted %+v", name, c)
+		}
+	}
+	if err := (Calibrations{16: ok}).Validate(); err == nil {
+		t.Error("accepted channel 16 without a board")
+	}
+	second := ok
+	second.Addr, second.Output = 0x41, 4
+	alsoFour, badAddr, badOut := second, second, second
+	alsoFour.Addr, badAddr.Addr, badOut.Output = 0x40, 0x20, 16
+	lift, lift2, four := ok, second, ok
+	lift.Name, lift2.Name, four.Name = "arm.lift", "arm.lift", "4"
+	for name, cs := range map[string]Calibrations{
+		"shared output":  {4: ok, 20: alsoFour},
+		"shared name":    {4: lift, 20: lift2},
+		"numeric name":   {4: four},
+		"address":        {20: badAddr},
+		"output too big": {20: badOut},
+	} {
+		if err := cs.Validate(); err == nil {
+			t.Errorf("%s: accepted %+v", name, cs)
+		}
+	}
+	if err := (Calibrations{4: ok, 20: second}).Validate(); err != nil {
+		t.Errorf("second board: %v", err)
+	}
+	if err := DefaultCalibrations.Validate(); err != nil {
+		t.Errorf("defaults: %v", err)
+	}
+}
+
+func TestLoadCalibrations(t *testing.T) {
+	dir := t.TempDir()
+	cs, err := LoadCalibrations(filepath.Join(dir, "missing.json"))
+	if err != nil || len(cs) != len(DefaultCalibrations) {
+		t.Fatalf("missing file: %v, %v", cs, err)
+	}
+	cs[4] = Calibration{}
+	if DefaultCalibrations[4].Max == 0 {
+		t.Error("changing the loaded copy changed the defaults")
+	}
+
+	bad := filepath.Join(dir, "bad.json")
+	os.WriteFile(bad, []byte(`{"4": {"min": 100, "max": 50}}`), 0o644)
+	if _, err := LoadCalibrations(bad); err == nil {
+		t.Error("loaded an invalid file")
+	}
+}
+
+func TestSetConfig(t *testing.T) {
+	s := newTestServer(t)
+	s.ConfigPath = filepath.Join(t.TempDir(), "servo-config.json")
+	ctx := context.Background()
+
+	s.SetAngle(ctx, &SetAngleRequest{Channel: 4, Angle: 130})
+	r, _ := s.SetConfig(ctx, &SetConfigRequest{Channels: []*ChannelConfig{
+		{Channel: 4, MinAngle: 20, MaxAngle: 120, Home: 60, MinPulse: 80, MaxPulse: 600, Invert: true},
+		{Channel: 9, MinAngle: 0, MaxAngle: 180, Home: 90, MinPulse: 50, MaxPulse: 650},
+	}})
+	if !r.Ok {
+		t.Fatalf("SetConfig: %s", r.Err)
+	}
+	if a := angle(s, 4); a != 120 {
+		t.Errorf("ch4 at %v, want pulled in to 120", a)
+	}
+	if a := angle(s, 9); a != 90 {
+		t.Errorf("new ch9 at %v, want home", a)
+	}
+	if r, _ := s.SetAngle(ctx, &SetAngleRequest{Channel: 4, Angle: 130}); r.Ok {
+		t.Error("old limits still apply")
+	}
+
+	saved, err := LoadCalibrations(s.ConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := saved[4]; c.Max != 120 || c.MinPulse != 80 || !c.Invert || saved[6].Max != 68 || saved[9].Home != 90 {
+		t.Errorf("saved %+v", saved)
+	}
+
+	got, _ := s.GetConfig(ctx, &GetConfigRequest{})
+	if len(got.Channels) != 3 || got.Channels[0].Channel != 4 || got.Channels[0].Home != 60 || got.Channels[2].Channel != 9 {
+		t.Errorf("GetConfig %v", got.Channels)
+	}
+
+	// one bad channel and nothing changes
+	r, _ = s.SetConfig(ctx, &SetConfigRequest{Channels: []*ChannelConfig{
+		{Channel: 6, MinAngle: 10, MaxAngle: 50, Home: 30, MinPulse: 50, MaxPulse: 650},
+		{Channel: 4, MinAngle: 20, MaxAngle: 120, Home: 160, MinPulse: 80, MaxPulse: 600},
+	}})
+	if r.Ok {
+		t.Fatal("accepted home outside the limits")
+	}
+	if saved, _ := LoadCalibrations(s.ConfigPath); saved[6].Max != 68 {
+		t.Error("rejected config was saved")
+	}
+
+	r, _ = s.SetConfig(ctx, &SetConfigRequest{GoHome: true, Channels: []*ChannelConfig{
+		{Channel: 4, MinAngle: 20, MaxAngle: 120, Home: 45, MinPulse: 80, MaxPulse: 600},
+	}})
+	if !r.Ok || angle(s, 4) != 45 {
+		t.Errorf("go_home: %+v, ch4 at %v", r, angle(s, 4))
+	}
+}
+
+func TestSetConfigTemporary(t *testing.T) {
+	s := newTestServer(t)
+	s.ConfigPath = filepath.Join(t.TempDir(), "servo-config.json")
+	ctx := context.Background()
+	set := func(temporary bool, ccs ...*ChannelConfig) {
+		t.Helper()
+		if r, _ := s.SetConfig(ctx, &SetConfigRequest{Channels: ccs, Temporary: temporary}); !r.Ok {
+			t.Fatalf("SetConfig: %s", r.Err)
+		}
+	}
+
+	// calibrating arm.lift with its limits opened right up
+	set(true, &ChannelConfig{Channel: 6, MinAngle: 0, MaxAngle: 180, Home: 41.5, MinPulse: 50, MaxPulse: 650},
+		&ChannelConfig{Channel: 9, MinAngle: 0, MaxAngle: 180, Home: 90, MinPulse: 50, MaxPulse: 650})
+	if r, _ := s.SetAngle(ctx, &SetAngleRequest{Channel: 6, Angle: 170}); !r.Ok {
+		t.Errorf("temporary limits not applied: %s", r.Err)
+	}
+	if _, err := os.Stat(s.ConfigPath); !os.IsNotExist(err) {
+		t.Fatalf("temporary config was saved: %v", err)
+	}
+
+	// saving another channel writes what the file had for the others
+	set(false, &ChannelConfig{Channel: 4, MinAngle: 20, MaxAngle: 120, Home: 60, MinPulse: 50, MaxPulse: 650})
+	saved, err := LoadCalibrations(s.ConfigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := saved[9]; ok || saved[6].Max != 68 || saved[4].Max != 120 {
+		t.Errorf("saved %+v", saved)
+	}
+
+	// and setting it for good saves it
+	set(false, &ChannelConfig{Channel: 6, MinAngle: 20, MaxAngle: 70, Home: 40, MinPulse: 50, MaxPulse: 650})
+	if saved, _ := LoadCalibrations(s.ConfigPath); saved[6].Max != 70 {
+		t.Errorf("saved %+v", saved)
+	}
+}
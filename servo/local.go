@@ -0,0 +1,237 @@
+package servo
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// --- Files ------------------------------------------------------------------
+
+// Files are where a server keeps its calibration, poses and recordings.
+// Empty paths keep them in memory, starting from DefaultCalibrations.
+type Files struct {
+	Config     string // calibration JSON
+	Poses      string // pose library JSON
+	Recordings string // directory of recording JSON files
+}
+
+// DefaultFiles keeps everything next to the running binary.
+func DefaultFiles() Files {
+	return Files{
+		Config:     BesideBinary("servo-config.json"),
+		Poses:      BesideBinary("poses.json"),
+		Recordings: BesideBinary("recordings"),
+	}
+}
+
+// BesideBinary is name in the running binary's directory.
+func BesideBinary(name string) string {
+	exe, err := os.Executable()
+	if err != nil {
+		return name
+	}
+	return filepath.Join(filepath.Dir(exe), name)
+}
+
+// Open loads f and starts a server on the boards open reaches.
+func (f Files) Open(open BusOpener) (*server, error) {
+	return f.open(newBoards(open, true), nil)
+}
+
+// open is Open on bs, starting the servos at where they are in at.
+func (f Files) open(bs *boards, at map[int]float64) (*server, error) {
+	cal, err := LoadCalibrations(f.Config)
+	if err != nil {
+		return nil, fmt.Errorf("calibration: %w", err)
+	}
+	poses, err := LoadPoses(f.Poses)
+	if err != nil {
+		return nil, fmt.Errorf("poses: %w", err)
+	}
+	recordings, err := LoadRecordings(f.Recordings)
+	if err != nil {
+		return nil, fmt.Errorf("recordings: %w", err)
+	}
+	s, err := newServer(bs, cal, at)
+	if err != nil {
+		return nil, err
+	}
+	s.Poses, s.Recordings, s.ConfigPath = poses, recordings, f.Config
+	return s, nil
+}
+
+// --- In-process server ------------------------------------------------------
+
+// Local runs a servo server inside another process, so the robot client
+// can drive the servos itself instead of through cmd/servo. Conn is an
+// in-memory gRPC connection to it, health service included, so callers
+// use the same ControllerClient either way.
+type Local struct {
+	files Files
+	open  BusOpener
+	conn  *grpc.ClientConn
+
+	mu     sync.Mutex
+	lis    *pipeListener
+	srv    *grpc.Server
+	health *health.Server
+	servos *server
+}
+
+// StartLocal opens files and the boards and serves them in this process.
+func StartLocal(files Files, open BusOpener) (*Local, error) {
+	l := &Local{files: files, open: open}
+	if err := l.start(newBoards(open, true), nil); err != nil {
+		return nil, err
+	}
+	conn, err := grpc.NewClient("passthrough:///local",
+		grpc.WithContextDialer(l.dial),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		l.stop()
+		return nil, err
+	}
+	l.conn = conn
+	return l, nil
+}
+
+// Conn is the connection to the server. It outlives Restart.
+func (l *Local) Conn() *grpc.ClientConn { return l.conn }
+
+// Restart replaces the server with a fresh one, re-reading the files and
+// reattaching the boards as they are. The servos stay where they were, and
+// there's no bus reset, frequency change or all-off: only the channels the
+// server drives are written, so nothing else on the boards is disturbed.
+// Calls in flight
+// fail; the connection redials the new server. If it can't start, Conn
+// fails until a Restart succeeds.
+func (l *Local) Restart() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var at map[int]float64
+	if l.servos != nil {
+		l.servos.moverMu.Lock()
+		at = l.servos.angles()
+		l.servos.moverMu.Unlock()
+	}
+	l.stop()
+	l.awaitDisconnect()
+	return l.start(newBoards(l.open, false), at)
+}
+
+// awaitDisconnect waits, for up to a second, for the connection to notice
+// the old server has gone, so calls made once Restart returns redial the
+// new one instead of failing on the old pipe.
+func (l *Local) awaitDisconnect() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for l.conn.GetState() == connectivity.Ready && l.conn.WaitForStateChange(ctx, connectivity.Ready) {
+	}
+}
+
+// Close stops the server and closes the connection.
+func (l *Local) Close() {
+	l.conn.Close()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stop()
+}
+
+func (l *Local) dial(ctx context.Context, _ string) (net.Conn, error) {
+	l.mu.Lock()
+	lis := l.lis
+	l.mu.Unlock()
+	if lis == nil {
+		return nil, fmt.Errorf("servo server isn't running")
+	}
+	return lis.dial(ctx)
+}
+
+// start opens a server on bs and serves it. Callers hold mu.
+func (l *Local) start(bs *boards, at map[int]float64) error {
+	servos, err := l.files.open(bs, at)
+	if err != nil {
+		return err
+	}
+	l.lis = newPipeListener()
+	l.srv = grpc.NewServer()
+	l.health = health.NewServer()
+	l.servos = servos
+	RegisterControllerServer(l.srv, servos)
+	l.health.SetServingStatus(Controller_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(l.srv, l.health)
+	go l.srv.Serve(l.lis)
+	return nil
+}
+
+// stop stops the running server, if there is one. Callers hold mu.
+func (l *Local) stop() {
+	if l.srv == nil {
+		return
+	}
+	l.health.Shutdown()
+	l.srv.Stop()
+	l.servos.Close()
+	l.lis, l.srv, l.health, l.servos = nil, nil, nil, nil
+}
+
+// pipeListener is a net.Listener whose connections are net.Pipes made by
+// dial.
+type pipeListener struct {
+	conns chan net.Conn
+	done  chan struct{}
+	once  sync.Once
+}
+
+func newPipeListener() *pipeListener {
+	return &pipeListener{conns: make(chan net.Conn), done: make(chan struct{})}
+}
+
+func (p *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-p.conns:
+		return c, nil
+	case <-p.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (p *pipeListener) Close() error {
+	p.once.Do(func() { close(p.done) })
+	return nil
+}
+
+func (p *pipeListener) Addr() net.Addr { return pipeAddr{} }
+
+func (p *pipeListener) dial(ctx context.Context) (net.Conn, error) {
+	client, server := net.Pipe()
+	select {
+	case p.conns <- server:
+		return client, nil
+	case <-p.done:
+		client.Close()
+		server.Close()
+		return nil, net.ErrClosed
+	case <-ctx.Done():
+		client.Close()
+		server.Close()
+		return nil, ctx.Err()
+	}
+}
+
+type pipeAddr struct{}
+
+func (pipeAddr) Network() string { return "pipe" }
+func (pipeAddr) String() string  { return "local" }
@@ -0,0 +1,82 @@
+package servo
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"periph.io/x/conn/v3/i2c"
+)
+
+func TestLocal(t *testing.T) {
+	dir := t.TempDir()
+	files := Files{
+		Config:     filepath.Join(dir, "servo-config.json"),
+		Poses:      filepath.Join(dir, "poses.json"),
+		Recordings: filepath.Join(dir, "recordings"),
+	}
+	buses := fakeBuses{1: {}}
+	l, err := StartLocal(files, buses.open)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	client := NewControllerClient(l.Conn())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	h, err := healthpb.NewHealthClient(l.Conn()).Check(ctx, &healthpb.HealthCheckRequest{Service: Controller_ServiceDesc.ServiceName})
+	if err != nil || h.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("health %v, %v", h, err)
+	}
+	if r, err := client.SetAngle(ctx, &SetAngleRequest{Name: "arm.lift", Angle: 60}); err != nil || !r.Ok {
+		t.Fatalf("SetAngle: %v %v", r, err)
+	}
+	if r, err := client.SavePose(ctx, &SavePoseRequest{Name: "up"}); err != nil || !r.Ok {
+		t.Fatalf("SavePose: %v %v", r, err)
+	}
+
+	// a restart leaves the servos where they were and keeps what was saved
+	before := buses[1].sent()
+	if err := l.Restart(); err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range buses[1].since(before) {
+		if w.w[0] == 0xFA || w.w[0] == 0xFE || w.w[0] == 0x00 && len(w.w) > 1 {
+			t.Errorf("restart wrote % x to %#x; only driven outputs should change", w.w, w.addr)
+		}
+	}
+	a, err := client.GetAngles(ctx, &GetAnglesRequest{})
+	if err != nil {
+		t.Fatalf("GetAngles after restart: %v", err)
+	}
+	for _, sa := range a.Angles {
+		if sa.Name == "arm.lift" && sa.Angle != 60 {
+			t.Errorf("arm.lift at %v after restart", sa.Angle)
+		}
+	}
+	if p, err := client.ListPoses(ctx, &ListPosesRequest{}); err != nil || len(p.Poses) != 1 {
+		t.Errorf("poses after restart %v, %v", p, err)
+	}
+	// a reset would upset every board on the bus, motors' included
+	if n := buses[1].resets(); n != 1 {
+		t.Errorf("bus reset %d times, want only when first started", n)
+	}
+	l.Close()
+	if !buses[1].closed {
+		t.Error("bus left open")
+	}
+}
+
+func TestLocalBadConfig(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "servo-config.json")
+	if err := SaveCalibrations(config, Calibrations{4: {Min: 90, Max: 10}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := StartLocal(Files{Config: config}, func(int) (i2c.BusCloser, error) { return NopBus{}, nil }); err == nil {
+		t.Error("started with a bad calibration")
+	}
+}
@@ -0,0 +1,196 @@
+package servo
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"periph.io/x/conn/v3/gpio"
+	"periph.io/x/conn/v3/i2c"
+	"periph.io/x/conn/v3/physic"
+	"periph.io/x/devices/v3/pca9685"
+	"periph.io/x/host/v3/sysfs" // only sysfs host drivers (i2c, led, thermal)
+)
+
+// Board is a PCA9685 by I²C bus number and address.
+type Board struct {
+	Bus  int
+	Addr uint16
+}
+
+func (b Board) String() string { return fmt.Sprintf("PCA9685 %#x on i2c-%d", b.Addr, b.Bus) }
+
+// DefaultBoard is where channels without wiring of their own are.
+var DefaultBoard = Board{Bus: 1, Addr: pca9685.I2CAddr}
+
+// BusOpener opens I²C bus n: /dev/i2c-n on the Pi, something fake in tests
+// and the simulator.
+type BusOpener func(n int) (i2c.BusCloser, error)
+
+// pwmDev is the part of a PCA9685 the server drives: a *pca9685.Dev, or an
+// attachedPCA9685 after a restart.
+type pwmDev interface {
+	SetPwm(channel int, on, off gpio.Duty) error
+	SetAllPwm(on, off gpio.Duty) error
+}
+
+// OpenPCA9685 sets up the PCA9685 at addr on bus for 50 Hz hobby servos,
+// all outputs off. Each channel's pulse range comes from its Calibration.
+func OpenPCA9685(bus i2c.Bus, addr uint16) (*pca9685.Dev, error) {
+	pca, err := pca9685.NewI2C(bus, addr)
+	if err != nil {
+		return nil, fmt.Errorf("pca9685.NewI2C: %w", err)
+	}
+	if err := pca.SetPwmFreq(50 * physic.Hertz); err != nil {
+		return nil, fmt.Errorf("SetPwmFreq: %w", err)
+	}
+	if err := pca.SetAllPwm(0, 0); err != nil {
+		return nil, fmt.Errorf("SetAllPwm: %w", err)
+	}
+	return pca, nil
+}
+
+// PCA9685 registers attachedPCA9685 uses.
+const (
+	pcaMode1  = 0x00
+	pcaLED0   = 0x06 // LED0_ON_L; each output has four registers
+	pcaAllLED = 0xFA // ALL_LED_ON_L
+	pcaSleep  = 0x10 // MODE1 oscillator off, as after power-on
+)
+
+// attachedPCA9685 drives a PCA9685 that's already running, as a restarted
+// server finds it: unlike OpenPCA9685 it leaves the frequency, the mode
+// and every output alone until they're written.
+type attachedPCA9685 struct{ dev *i2c.Dev }
+
+// attachPCA9685 returns the board at addr as it is, or sets it up with
+// OpenPCA9685 if it's asleep because it lost power since.
+func attachPCA9685(bus i2c.Bus, addr uint16) (pwmDev, error) {
+	dev := &i2c.Dev{Bus: bus, Addr: addr}
+	mode := []byte{0}
+	if err := dev.Tx([]byte{pcaMode1}, mode); err != nil {
+		return nil, fmt.Errorf("read MODE1: %w", err)
+	}
+	if mode[0]&pcaSleep != 0 {
+		return OpenPCA9685(bus, addr)
+	}
+	return attachedPCA9685{dev}, nil
+}
+
+func (p attachedPCA9685) SetPwm(channel int, on, off gpio.Duty) error {
+	if channel < 0 || channel > 15 {
+		return fmt.Errorf("PCA9685 output %d outside 0..15", channel)
+	}
+	return p.set(pcaLED0+byte(4*channel), on, off)
+}
+
+func (p attachedPCA9685) SetAllPwm(on, off gpio.Duty) error { return p.set(pcaAllLED, on, off) }
+
+// set writes ON and OFF counts from reg, which auto-increment (set up when
+// the board was first opened) spreads over the four registers.
+func (p attachedPCA9685) set(reg byte, on, off gpio.Duty) error {
+	_, err := p.dev.Write([]byte{reg, byte(on), byte(on >> 8), byte(off), byte(off >> 8)})
+	return err
+}
+
+// boards opens buses and PCA9685s as channels need them, each bus and
+// board once. With reset, each bus gets a software reset when it's opened
+// and each board is set up from scratch; without, boards are attached as
+// they are, so outputs nobody writes keep their pulses.
+type boards struct {
+	open  BusOpener
+	reset bool
+	buses map[int]i2c.BusCloser
+	devs  map[Board]pwmDev
+}
+
+func newBoards(open BusOpener, reset bool) *boards {
+	return &boards{open: open, reset: reset, buses: map[int]i2c.BusCloser{}, devs: map[Board]pwmDev{}}
+}
+
+func (bs *boards) get(b Board) (pwmDev, error) {
+	if dev, ok := bs.devs[b]; ok {
+		return dev, nil
+	}
+	bus, ok := bs.buses[b.Bus]
+	if !ok {
+		var err error
+		if bus, err = bs.open(b.Bus); err != nil {
+			return nil, fmt.Errorf("i2c-%d: %w", b.Bus, err)
+		}
+		bs.buses[b.Bus] = bus
+		// Software reset every PCA9685 on the bus (General Call 0x06),
+		// only when it's first opened so later boards don't upset earlier ones
+		if bs.reset {
+			_ = bus.Tx(0x00, []byte{0x06}, nil)
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	var dev pwmDev
+	var err error
+	if bs.reset {
+		dev, err = OpenPCA9685(bus, b.Addr)
+	} else {
+		dev, err = attachPCA9685(bus, b.Addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", b, err)
+	}
+	bs.devs[b] = dev
+	return dev, nil
+}
+
+// each calls f on every open board in bus and address order.
+func (bs *boards) each(f func(Board, pwmDev) error) error {
+	list := make([]Board, 0, len(bs.devs))
+	for b := range bs.devs {
+		list = append(list, b)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Bus != list[j].Bus {
+			return list[i].Bus < list[j].Bus
+		}
+		return list[i].Addr < list[j].Addr
+	})
+	for _, b := range list {
+		if err := f(b, bs.devs[b]); err != nil {
+			return fmt.Errorf("%s: %w", b, err)
+		}
+	}
+	return nil
+}
+
+func (bs *boards) close() error {
+	var first error
+	for _, bus := range bs.buses {
+		if err := bus.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// NopBus is an I²C bus that accepts every transaction and reads zeros, for
+// running the servo server without a PCA9685 attached.
+type NopBus struct{}
+
+func (NopBus) Tx(addr uint16, w, r []byte) error  { return nil }
+func (NopBus) Close() error                       { return nil }
+func (NopBus) SetSpeed(hz physic.Frequency) error { return nil }
+func (NopBus) String() string                     { return "nopBus" }
+
+// SysfsBus opens /dev/i2c-n, falling back to a NopBus off the Pi.
+func SysfsBus(n int) (i2c.BusCloser, error) {
+	bus, err := sysfs.NewI2C(n)
+	if err != nil {
+		if os.IsNotExist(err) || strings.Contains(err.Error(), "no such file") {
+			log.Printf("⚠️  /dev/i2c-%d not found, falling back to no-op I²C bus", n)
+			return NopBus{}, nil
+		}
+		return nil, fmt.Errorf("sysfs.NewI2C: %w", err)
+	}
+	return bus, nil
+}
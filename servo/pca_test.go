@@ -0,0 +1,221 @@
+package servo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"periph.io/x/conn/v3/i2c"
+	"periph.io/x/conn/v3/physic"
+)
+
+// recordBus is an I²C bus that records every write and reads zeros.
+// Boards at missing addresses don't answer.
+type recordBus struct {
+	mu      sync.Mutex
+	writes  []busWrite
+	missing map[uint16]bool
+	closed  bool
+}
+
+type busWrite struct {
+	addr uint16
+	w    []byte
+}
+
+func (b *recordBus) Tx(addr uint16, w, r []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.missing[addr] {
+		return errors.New("no ACK")
+	}
+	b.writes = append(b.writes, busWrite{addr, append([]byte(nil), w...)})
+	clear(r)
+	return nil
+}
+
+func (b *recordBus) Close() error                    { b.closed = true; return nil }
+func (b *recordBus) SetSpeed(physic.Frequency) error { return nil }
+func (b *recordBus) String() string                  { return "recordBus" }
+
+// pwm is the OFF count last written to an output of the board at addr,
+// directly or through ALL_LED, or -1 if there's been none.
+func (b *recordBus) pwm(addr uint16, out int) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i := len(b.writes) - 1; i >= 0; i-- {
+		w := b.writes[i]
+		if w.addr == addr && len(w.w) == 5 && (w.w[0] == byte(0x06+4*out) || w.w[0] == 0xFA) {
+			return int(w.w[3]) | int(w.w[4])<<8
+		}
+	}
+	return -1
+}
+
+// resets counts software resets (general call 0x06).
+func (b *recordBus) resets() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := 0
+	for _, w := range b.writes {
+		if w.addr == 0 && len(w.w) == 1 && w.w[0] == 0x06 {
+			n++
+		}
+	}
+	return n
+}
+
+// sent is how many writes there have been.
+func (b *recordBus) sent() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.writes)
+}
+
+// since returns the writes after the first n.
+func (b *recordBus) since(n int) []busWrite {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]busWrite(nil), b.writes[n:]...)
+}
+
+// fakeBuses opens recording buses by number.
+type fakeBuses map[int]*recordBus
+
+func (f fakeBuses) open(n int) (i2c.BusCloser, error) {
+	b, ok := f[n]
+	if !ok {
+		return nil, fmt.Errorf("no bus %d", n)
+	}
+	return b, nil
+}
+
+var cal50to650 = Calibration{Min: 0, Max: 180, Home: 90, MinPulse: 50, MaxPulse: 650}
+
+func wiredTo(name string, bus int, addr uint16, out int) Calibration {
+	c := cal50to650
+	c.Name, c.Bus, c.Addr, c.Output = name, bus, addr, out
+	return c
+}
+
+func TestMultipleBoards(t *testing.T) {
+	buses := fakeBuses{1: {}, 3: {}}
+	s, err := NewServer(buses.open, Calibrations{
+		4:  cal50to650,
+		16: wiredTo("wrist.roll", 0, 0x41, 0),
+		17: wiredTo("camera.pan", 3, 0, 2),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	b1, b3 := buses[1], buses[3]
+
+	if b1.resets() != 1 || b3.resets() != 1 {
+		t.Errorf("resets: bus 1 %d, bus 3 %d; want one each", b1.resets(), b3.resets())
+	}
+	if b1.pwm(0x40, 4) != 350 || b1.pwm(0x41, 0) != 350 || b3.pwm(0x40, 2) != 350 {
+		t.Errorf("not at home: %d %d %d", b1.pwm(0x40, 4), b1.pwm(0x41, 0), b3.pwm(0x40, 2))
+	}
+
+	if r, _ := s.SetAngle(ctx, &SetAngleRequest{Name: "wrist.roll", Angle: 180}); !r.Ok {
+		t.Fatalf("SetAngle by name: %s", r.Err)
+	}
+	if b1.pwm(0x41, 0) != 650 || b1.pwm(0x40, 0) != 0 {
+		t.Errorf("wrist.roll: 0x41 output 0 at %d, 0x40 output 0 at %d", b1.pwm(0x41, 0), b1.pwm(0x40, 0))
+	}
+	if r, _ := s.SetAngle(ctx, &SetAngleRequest{Channel: 17, Angle: 0}); !r.Ok || b3.pwm(0x40, 2) != 50 {
+		t.Errorf("SetAngle by number: %+v, pulse %d", r, b3.pwm(0x40, 2))
+	}
+	if r, _ := s.SetAngle(ctx, &SetAngleRequest{Name: "arm.lift", Angle: 90}); r.Ok || !strings.Contains(r.Err, "arm.lift") {
+		t.Errorf("unknown name: %+v", r)
+	}
+
+	r, _ := s.Move(ctx, &MoveRequest{Name: "camera.pan", Direction: 1, Speed: 90})
+	if !r.Ok || r.Channel != 17 {
+		t.Errorf("Move by name: %+v", r)
+	}
+	s.Stop(ctx, &StopRequest{Name: "camera.pan"})
+
+	st, _ := s.GetAngles(ctx, &GetAnglesRequest{})
+	for _, a := range st.Angles {
+		if a.Channel == 16 && a.Name != "wrist.roll" {
+			t.Errorf("GetAngles: %v", a)
+		}
+	}
+
+	s.EmergencyStop(ctx, &EmergencyStopRequest{CutPwm: true})
+	if b1.pwm(0x40, 4) != 0 || b1.pwm(0x41, 0) != 0 || b3.pwm(0x40, 2) != 0 {
+		t.Error("cut_pwm missed a board")
+	}
+
+	s.Close()
+	if !b1.closed || !b3.closed {
+		t.Error("buses left open")
+	}
+}
+
+func TestMissingBoard(t *testing.T) {
+	buses := fakeBuses{1: {missing: map[uint16]bool{0x42: true}}}
+	if _, err := NewServer(buses.open, Calibrations{16: wiredTo("", 0, 0x42, 0)}); err == nil || !strings.Contains(err.Error(), "0x42") {
+		t.Errorf("missing board: %v", err)
+	}
+	if _, err := NewServer(buses.open, Calibrations{16: wiredTo("", 2, 0, 0)}); err == nil {
+		t.Error("missing bus accepted")
+	}
+
+	s, err := NewServer(buses.open, Calibrations{4: cal50to650})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.ConfigPath = filepath.Join(t.TempDir(), "servo-config.json")
+	r, _ := s.SetConfig(context.Background(), &SetConfigRequest{Channels: []*ChannelConfig{
+		toChannelConfig(16, wiredTo("", 0, 0x42, 0)),
+	}})
+	if r.Ok {
+		t.Fatal("SetConfig onto a missing board")
+	}
+	if saved, _ := LoadCalibrations(s.ConfigPath); len(saved) != len(DefaultCalibrations) {
+		t.Errorf("saved %v", saved)
+	}
+}
+
+func TestRewire(t *testing.T) {
+	buses := fakeBuses{1: {}}
+	s, err := NewServer(buses.open, Calibrations{4: cal50to650, 5: wiredTo("claw.rotate", 0, 0x41, 3)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	b := buses[1]
+
+	// swap the two outputs round
+	r, _ := s.SetConfig(ctx, &SetConfigRequest{Channels: []*ChannelConfig{
+		toChannelConfig(4, wiredTo("claw.grip", 0, 0x41, 3)),
+		toChannelConfig(5, wiredTo("claw.rotate", 1, 0x40, 4)),
+	}})
+	if !r.Ok {
+		t.Fatalf("SetConfig: %s", r.Err)
+	}
+	if b.pwm(0x41, 3) != 350 || b.pwm(0x40, 4) != 350 {
+		t.Errorf("swapped outputs at %d and %d", b.pwm(0x41, 3), b.pwm(0x40, 4))
+	}
+	if r, _ := s.SetAngle(ctx, &SetAngleRequest{Name: "claw.grip", Angle: 0}); !r.Ok || b.pwm(0x41, 3) != 50 {
+		t.Errorf("claw.grip after rewiring: %+v, pulse %d", r, b.pwm(0x41, 3))
+	}
+
+	got, _ := s.GetConfig(ctx, &GetConfigRequest{})
+	if c := got.Channels[0]; c.Name != "claw.grip" || c.Address != 0x41 || c.Output != 3 {
+		t.Errorf("GetConfig %v", c)
+	}
+
+	// moving one off an output turns that output off
+	s.SetConfig(ctx, &SetConfigRequest{Channels: []*ChannelConfig{toChannelConfig(5, wiredTo("claw.rotate", 0, 0x41, 9))}})
+	if b.pwm(0x40, 4) != 0 {
+		t.Errorf("old output left at %d", b.pwm(0x40, 4))
+	}
+}
@@ -0,0 +1,126 @@
+package servo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// PoseLibrary holds named poses and keeps them in a JSON file:
+//
+//	{"stow": {"4": 90, "5": 90, "6": 20}, "pick": {...}}
+//
+// mapping pose names to channel angles.
+type PoseLibrary struct {
+	path string
+
+	mu    sync.Mutex
+	poses map[string]map[int]float64
+}
+
+// LoadPoses reads the library at path. A missing file is an empty library;
+// an empty path keeps the poses in memory only.
+func LoadPoses(path string) (*PoseLibrary, error) {
+	l := &PoseLibrary{path: path, poses: map[string]map[int]float64{}}
+	if path == "" {
+		return l, nil
+	}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, &l.poses); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return l, nil
+}
+
+// Get returns a copy of the pose called name.
+func (l *PoseLibrary) Get(name string) (map[int]float64, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	p, ok := l.poses[name]
+	if !ok {
+		return nil, false
+	}
+	out := make(map[int]float64, len(p))
+	for ch, a := range p {
+		out[ch] = a
+	}
+	return out, true
+}
+
+// List returns every pose, sorted by name.
+func (l *PoseLibrary) List() []*Pose {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]*Pose, 0, len(l.poses))
+	for name, angles := range l.poses {
+		out = append(out, toPose(name, angles))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Save stores angles as name and writes the file.
+func (l *PoseLibrary) Save(name string, angles map[int]float64) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("pose needs a name")
+	}
+	if len(angles) == 0 {
+		return fmt.Errorf("pose %q has no channels", name)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	prev, had := l.poses[name]
+	l.poses[name] = angles
+	if err := l.write(); err != nil {
+		if had {
+			l.poses[name] = prev
+		} else {
+			delete(l.poses, name)
+		}
+		return err
+	}
+	return nil
+}
+
+// Delete removes name and writes the file.
+func (l *PoseLibrary) Delete(name string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	prev, ok := l.poses[name]
+	if !ok {
+		return fmt.Errorf("no pose %q", name)
+	}
+	delete(l.poses, name)
+	if err := l.write(); err != nil {
+		l.poses[name] = prev
+		return err
+	}
+	return nil
+}
+
+// write saves the library, if it has a file. Callers hold mu.
+func (l *PoseLibrary) write() error {
+	if l.path == "" {
+		return nil
+	}
+	return writeJSON(l.path, l.poses)
+}
+
+func toPose(name string, angles map[int]float64) *Pose {
+	p := &Pose{Name: name}
+	for ch, a := range angles {
+		p.Angles = append(p.Angles, &ServoAngle{Channel: int32(ch), Angle: float32(a)})
+	}
+	sort.Slice(p.Angles, func(i, j int) bool { return p.Angles[i].Channel < p.Angles[j].Channel })
+	return p
+}
@@ -0,0 +1,129 @@
+package servo
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPoseLibraryPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "poses.json")
+	l, err := LoadPoses(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Save("stow", map[int]float64{4: 90, 6: 20}); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Save("pick", map[int]float64{4: 30}); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Save(" ", map[int]float64{4: 30}); err == nil {
+		t.Error("saved a pose with no name")
+	}
+
+	l, err = LoadPoses(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	poses := l.List()
+	if len(poses) != 2 || poses[0].Name != "pick" || poses[1].Name != "stow" {
+		t.Fatalf("poses %v", poses)
+	}
+	if a := poses[1].Angles; len(a) != 2 || a[0].Channel != 4 || a[0].Angle != 90 || a[1].Channel != 6 {
+		t.Errorf("stow %v", a)
+	}
+
+	if err := l.Delete("pick"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Delete("pick"); err == nil {
+		t.Error("deleted a pose twice")
+	}
+	l, _ = LoadPoses(path)
+	if _, ok := l.Get("pick"); ok {
+		t.Error("deleted pose came back")
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("left temporary files behind: %v", entries)
+	}
+}
+
+func TestLoadPosesMissingFile(t *testing.T) {
+	l, err := LoadPoses(filepath.Join(t.TempDir(), "nope.json"))
+	if err != nil || len(l.List()) != 0 {
+		t.Errorf("LoadPoses = %v, %v", l.List(), err)
+	}
+}
+
+func TestSaveAndMoveToPose(t *testing.T) {
+	s := newTestServer(t)
+	s.Poses, _ = LoadPoses("")
+	ctx := context.Background()
+
+	s.SetAngle(ctx, &SetAngleRequest{Channel: 4, Angle: 40})
+	s.SetAngle(ctx, &SetAngleRequest{Channel: 6, Angle: 60})
+	r, _ := s.SavePose(ctx, &SavePoseRequest{Name: "pick"})
+	if !r.Ok || len(r.Pose.Angles) != 2 {
+		t.Fatalf("SavePose = %+v", r)
+	}
+	if r, _ := s.SavePose(ctx, &SavePoseRequest{Name: "bad", Channels: []int32{9}}); r.Ok {
+		t.Error("saved an unknown channel")
+	}
+	if r, _ := s.SavePose(ctx, &SavePoseRequest{Name: "bad", Names: []string{""}}); r.Ok {
+		t.Error("saved a blank name")
+	}
+
+	// ch4 has 100° to go and ch6 20°, so ch6 must go slower to arrive with it
+	s.SetAngle(ctx, &SetAngleRequest{Channel: 4, Angle: 140})
+	s.SetAngle(ctx, &SetAngleRequest{Channel: 6, Angle: 40})
+	if r, _ := s.MoveToPose(ctx, &MoveToPoseRequest{Name: "pick", DurationMs: 200}); !r.Ok {
+		t.Fatalf("MoveToPose: %s", r.Err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	p4 := (140 - angle(s, 4)) / 100
+	p6 := (angle(s, 6) - 40) / 20
+	if p4 < 0.2 || p4 > 0.8 || math.Abs(p4-p6) > 0.15 {
+		t.Errorf("halfway: ch4 %.0f%% of the way, ch6 %.0f%%", p4*100, p6*100)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if a4, a6 := angle(s, 4), angle(s, 6); a4 != 40 || a6 != 60 {
+		t.Errorf("ended at %v, %v", a4, a6)
+	}
+
+	if r, _ := s.MoveToPose(ctx, &MoveToPoseRequest{Name: "carry"}); r.Ok {
+		t.Error("moved to a pose that doesn't exist")
+	}
+}
+
+func TestMoveToPoseVelocity(t *testing.T) {
+	MotionTick = time.Millisecond
+	defer func() { MotionTick = 20 * time.Millisecond }()
+	s := newTestServer(t)
+	s.Poses, _ = LoadPoses("")
+	s.Poses.Save("stow", map[int]float64{4: 15, 6: 68})
+	ctx := context.Background()
+	s.SetAngle(ctx, &SetAngleRequest{Channel: 4, Angle: 115})
+
+	start := time.Now()
+	r, _ := s.MoveToPose(ctx, &MoveToPoseRequest{Name: "stow", MaxVelocity: 1000, Wait: true})
+	if !r.Ok {
+		t.Fatalf("MoveToPose: %s", r.Err)
+	}
+	// 100° at 1000°/s
+	if d := time.Since(start); d < 90*time.Millisecond || d > time.Second {
+		t.Errorf("took %v, want about 100ms", d)
+	}
+	if a := angle(s, 6); a != 68 {
+		t.Errorf("ch6 = %v", a)
+	}
+
+	// a pose saved under wider limits is refused rather than clamped
+	s.Poses.Save("wide", map[int]float64{6: 100})
+	if r, _ := s.MoveToPose(ctx, &MoveToPoseRequest{Name: "wide"}); r.Ok {
+		t.Error("moved past Max")
+	}
+}
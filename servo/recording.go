@@ -0,0 +1,236 @@
+package servo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Recording is servo motion captured by the server. Frames hold channel
+// angles as they changed: the first has every channel, the rest only those
+// that moved, plus where a channel sat just before it started moving again,
+// so playback can interpolate between frames without creeping. Commands log
+// the motion RPCs behind it; playback goes by the frames alone.
+type Recording struct {
+	Name       string          `json:"name"`
+	Started    time.Time       `json:"started"`
+	DurationMs int64           `json:"durationMs"`
+	Frames     []RecordFrame   `json:"frames"`
+	Commands   []RecordCommand `json:"commands,omitempty"`
+}
+
+type RecordFrame struct {
+	AtMs   int64           `json:"atMs"` // since Started
+	Angles map[int]float64 `json:"angles"`
+}
+
+type RecordCommand struct {
+	AtMs    int64           `json:"atMs"`
+	RPC     string          `json:"rpc"`     // "Move", "Stop", ...
+	Request json.RawMessage `json:"request"` // as protojson
+}
+
+// Validate reports the first thing wrong with r.
+func (r *Recording) Validate() error {
+	if !validName.MatchString(r.Name) {
+		return fmt.Errorf("recording name %q: use letters, digits, '.', '_' and '-', starting with a letter", r.Name)
+	}
+	if len(r.Frames) == 0 || len(r.Frames[0].Angles) == 0 {
+		return fmt.Errorf("recording %q has no servos", r.Name)
+	}
+	var prev int64
+	for i, f := range r.Frames {
+		if f.AtMs < prev || f.AtMs > r.DurationMs {
+			return fmt.Errorf("recording %q: frame %d at %dms is out of order", r.Name, i, f.AtMs)
+		}
+		prev = f.AtMs
+	}
+	return nil
+}
+
+func (r *Recording) info() *RecordingInfo {
+	info := &RecordingInfo{
+		Name:       r.Name,
+		DurationMs: uint32(r.DurationMs),
+		Frames:     uint32(len(r.Frames)),
+		Commands:   uint32(len(r.Commands)),
+		StartedMs:  r.Started.UnixMilli(),
+	}
+	for ch := range r.Frames[0].Angles {
+		info.Channels = append(info.Channels, int32(ch))
+	}
+	sort.Slice(info.Channels, func(i, j int) bool { return info.Channels[i] < info.Channels[j] })
+	return info
+}
+
+// RecordingLibrary holds recordings, keeping each in <dir>/<name>.json.
+type RecordingLibrary struct {
+	dir string
+
+	mu   sync.Mutex
+	recs map[string]*Recording
+}
+
+// LoadRecordings reads every recording in dir, creating it if need be. An
+// empty dir keeps recordings in memory only.
+func LoadRecordings(dir string) (*RecordingLibrary, error) {
+	l := &RecordingLibrary{dir: dir, recs: map[string]*Recording{}}
+	if dir == "" {
+		return l, nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		r := &Recording{}
+		if err := json.Unmarshal(raw, r); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if err := r.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if r.Name != strings.TrimSuffix(filepath.Base(path), ".json") {
+			return nil, fmt.Errorf("%s: holds recording %q", path, r.Name)
+		}
+		l.recs[r.Name] = r
+	}
+	return l, nil
+}
+
+// Get returns the recording called name, which callers mustn't change.
+func (l *RecordingLibrary) Get(name string) (*Recording, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	r, ok := l.recs[name]
+	return r, ok
+}
+
+// List describes every recording, sorted by name.
+func (l *RecordingLibrary) List() []*RecordingInfo {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make([]*RecordingInfo, 0, len(l.recs))
+	for _, r := range l.recs {
+		out = append(out, r.info())
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Save stores r, replacing any recording with its name, and writes its file.
+func (l *RecordingLibrary) Save(r *Recording) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.dir != "" {
+		if err := writeJSON(l.path(r.Name), r); err != nil {
+			return err
+		}
+	}
+	l.recs[r.Name] = r
+	return nil
+}
+
+// Delete removes name and its file.
+func (l *RecordingLibrary) Delete(name string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.recs[name]; !ok {
+		return fmt.Errorf("no recording %q", name)
+	}
+	if l.dir != "" {
+		if err := os.Remove(l.path(name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	delete(l.recs, name)
+	return nil
+}
+
+func (l *RecordingLibrary) path(name string) string {
+	return filepath.Join(l.dir, name+".json")
+}
+
+// recorder builds a Recording from angle samples.
+type recorder struct {
+	rec     *Recording
+	last    map[int]float64 // angles as last written
+	written map[int]int64   // when each channel was last written
+	prevAt  int64           // when the previous sample was taken
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+func newRecorder(name string, started time.Time) *recorder {
+	return &recorder{
+		rec:     &Recording{Name: name, Started: started},
+		last:    map[int]float64{},
+		written: map[int]int64{},
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// sample adds the angles at now, keeping only what changed.
+func (r *recorder) sample(now time.Time, angles map[int]float64) {
+	at := now.Sub(r.rec.Started).Milliseconds()
+	moved := map[int]float64{}
+	held := map[int]float64{}
+	for ch, a := range angles {
+		old, ok := r.last[ch]
+		if ok && old == a {
+			continue
+		}
+		moved[ch] = a
+		if ok && r.written[ch] < r.prevAt {
+			// it sat still until the previous sample
+			held[ch] = old
+		}
+	}
+	if len(held) > 0 {
+		r.add(r.prevAt, held)
+	}
+	if len(moved) > 0 {
+		r.add(at, moved)
+	}
+	for ch, a := range moved {
+		r.last[ch] = a
+		r.written[ch] = at
+	}
+	r.prevAt = at
+}
+
+// add writes a frame, merging it into the last one if they're at the same
+// time.
+func (r *recorder) add(at int64, angles map[int]float64) {
+	if n := len(r.rec.Frames); n > 0 && r.rec.Frames[n-1].AtMs == at {
+		for ch, a := range angles {
+			r.rec.Frames[n-1].Angles[ch] = a
+		}
+		return
+	}
+	r.rec.Frames = append(r.rec.Frames, RecordFrame{AtMs: at, Angles: angles})
+}
+
+// finish takes the last sample at now and returns the recording.
+func (r *recorder) finish(now time.Time, angles map[int]float64) *Recording {
+	r.sample(now, angles)
+	r.rec.DurationMs = now.Sub(r.rec.Started).Milliseconds()
+	return r.rec
+}
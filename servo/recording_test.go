@@ -0,0 +1,181 @@
+package servo
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecorderSample(t *testing.T) {
+	t0 := time.Now()
+	at := func(ms int) time.Time { return t0.Add(time.Duration(ms) * time.Millisecond) }
+	r := newRecorder("wave", t0)
+	r.sample(t0, map[int]float64{4: 10, 6: 20})
+	r.sample(at(20), map[int]float64{4: 10, 6: 20})
+	r.sample(at(40), map[int]float64{4: 10, 6: 20})
+	r.sample(at(60), map[int]float64{4: 12, 6: 20})
+	r.sample(at(80), map[int]float64{4: 14, 6: 20})
+	rec := r.finish(at(200), map[int]float64{4: 14, 6: 20})
+
+	want := []RecordFrame{
+		{0, map[int]float64{4: 10, 6: 20}},
+		{40, map[int]float64{4: 10}}, // held until it moved
+		{60, map[int]float64{4: 12}},
+		{80, map[int]float64{4: 14}},
+	}
+	if !reflect.DeepEqual(rec.Frames, want) {
+		t.Errorf("frames %v, want %v", rec.Frames, want)
+	}
+	if rec.DurationMs != 200 {
+		t.Errorf("duration %d", rec.DurationMs)
+	}
+}
+
+func newRecordingServer(t *testing.T) *server {
+	t.Helper()
+	s := newTestServer(t)
+	recs, err := LoadRecordings(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Recordings = recs
+	return s
+}
+
+func record(t *testing.T, s *server, name string, moves func()) {
+	t.Helper()
+	ctx := context.Background()
+	if r, _ := s.StartRecording(ctx, &StartRecordingRequest{Name: name}); !r.Ok {
+		t.Fatalf("StartRecording: %s", r.Err)
+	}
+	moves()
+	r, _ := s.StopRecording(ctx, &StopRecordingRequest{})
+	if !r.Ok {
+		t.Fatalf("StopRecording: %s", r.Err)
+	}
+}
+
+func TestRecordAndPlay(t *testing.T) {
+	MotionTick = time.Millisecond
+	defer func() { MotionTick = 20 * time.Millisecond }()
+	s := newRecordingServer(t)
+	ctx := context.Background()
+
+	if r, _ := s.StartRecording(ctx, &StartRecordingRequest{Name: "../etc/passwd"}); r.Ok {
+		t.Error("recorded under a path")
+	}
+	record(t, s, "wave", func() {
+		if r, _ := s.StartRecording(ctx, &StartRecordingRequest{Name: "other"}); r.Ok {
+			t.Error("two recordings at once")
+		}
+		s.SetAngle(ctx, &SetAngleRequest{Channel: 4, Angle: 100})
+		time.Sleep(30 * time.Millisecond)
+		s.SetAngle(ctx, &SetAngleRequest{Channel: 4, Angle: 120})
+		time.Sleep(30 * time.Millisecond)
+	})
+
+	rec, ok := s.Recordings.Get("wave")
+	if !ok || len(rec.Commands) != 2 || rec.Commands[0].RPC != "SetAngle" {
+		t.Fatalf("recording %+v", rec)
+	}
+	if last := rec.Frames[len(rec.Frames)-1]; last.Angles[4] != 120 {
+		t.Errorf("last frame %v", last)
+	}
+	reloaded, err := LoadRecordings(s.Recordings.dir)
+	if err != nil || len(reloaded.List()) != 1 {
+		t.Fatalf("reloaded %v, %v", reloaded.List(), err)
+	}
+
+	s.SetAngle(ctx, &SetAngleRequest{Channel: 4, Angle: 40})
+	if r, _ := s.PlayRecording(ctx, &PlayRecordingRequest{Name: "wave", Speed: 3}); r.Ok {
+		t.Error("played at 3x")
+	}
+	if r, _ := s.PlayRecording(ctx, &PlayRecordingRequest{Name: "wave", Speed: 2, Wait: true}); !r.Ok {
+		t.Fatalf("PlayRecording: %s", r.Err)
+	}
+	if a := angle(s, 4); a != 120 {
+		t.Errorf("ch4 at %v after playback, want 120", a)
+	}
+
+	// the same limits apply as to any other motion
+	s.SetConfig(ctx, &SetConfigRequest{Channels: []*ChannelConfig{{Channel: 4, MinAngle: 15, MaxAngle: 110, Home: 77.5, MinPulse: 50, MaxPulse: 650}}})
+	if r, _ := s.PlayRecording(ctx, &PlayRecordingRequest{Name: "wave"}); r.Ok {
+		t.Error("played past the new limit")
+	}
+
+	if r, _ := s.DeleteRecording(ctx, &DeleteRecordingRequest{Name: "wave"}); !r.Ok {
+		t.Errorf("DeleteRecording: %s", r.Err)
+	}
+	if _, err := os.Stat(filepath.Join(s.Recordings.dir, "wave.json")); !os.IsNotExist(err) {
+		t.Errorf("file left behind: %v", err)
+	}
+}
+
+func TestPlaybackLoopsAndAbort(t *testing.T) {
+	MotionTick = time.Millisecond
+	defer func() { MotionTick = 20 * time.Millisecond }()
+	s := newRecordingServer(t)
+	ctx := context.Background()
+	record(t, s, "nod", func() {
+		s.SetAngle(ctx, &SetAngleRequest{Channel: 6, Angle: 60})
+		time.Sleep(50 * time.Millisecond)
+	})
+	rec, _ := s.Recordings.Get("nod")
+
+	// each loop has to get back from 60° to 41.5° before it starts again
+	start := time.Now()
+	if r, _ := s.PlayRecording(ctx, &PlayRecordingRequest{Name: "nod", Loops: 3, Wait: true}); !r.Ok {
+		t.Fatalf("PlayRecording: %s", r.Err)
+	}
+	least := 3*time.Duration(rec.DurationMs)*time.Millisecond + 2*time.Duration(18.5/DefaultMaxVelocity*float64(time.Second))
+	if took := time.Since(start); took < least {
+		t.Errorf("3 loops took %v, want at least %v", took, least)
+	}
+
+	if r, _ := s.PlayRecording(ctx, &PlayRecordingRequest{Name: "nod", Forever: true}); !r.Ok {
+		t.Fatalf("PlayRecording: %s", r.Err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if l, _ := s.ListRecordings(ctx, &ListRecordingsRequest{}); l.Playing != "nod" {
+		t.Errorf("playing %q", l.Playing)
+	}
+	if r, _ := s.AbortPlayback(ctx, &AbortPlaybackRequest{}); !r.Ok {
+		t.Errorf("AbortPlayback: %s", r.Err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	l, _ := s.ListRecordings(ctx, &ListRecordingsRequest{})
+	s.moverMu.Lock()
+	_, moving := s.movers[6]
+	s.moverMu.Unlock()
+	if l.Playing != "" || moving {
+		t.Errorf("still playing after abort: %q, moving %v", l.Playing, moving)
+	}
+
+	// so does an emergency stop, even between passes
+	s.PlayRecording(ctx, &PlayRecordingRequest{Name: "nod", Forever: true})
+	time.Sleep(50 * time.Millisecond)
+	s.EmergencyStop(ctx, &EmergencyStopRequest{})
+	if l, _ := s.ListRecordings(ctx, &ListRecordingsRequest{}); l.Playing != "" {
+		t.Errorf("still playing %q after an emergency stop", l.Playing)
+	}
+
+	// stopping one of its channels ends it too
+	done := make(chan *PlayRecordingReply)
+	go func() {
+		r, _ := s.PlayRecording(ctx, &PlayRecordingRequest{Name: "nod", Forever: true, Wait: true})
+		done <- r
+	}()
+	time.Sleep(50 * time.Millisecond)
+	s.Stop(ctx, &StopRequest{Channel: 6})
+	select {
+	case r := <-done:
+		if r.Ok {
+			t.Error("stopped playback reported ok")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("playback didn't stop")
+	}
+}
@@ -3,64 +3,131 @@ package servo
 import (
 	"context"
 	"fmt"
+	"math"
+	"sort"
 	"sync"
 	"time"
 
 	"log"
 
-	"periph.io/x/conn/v3/physic"
-	"periph.io/x/devices/v3/pca9685" 
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"periph.io/x/conn/v3/gpio"
 )
 
 type ServoConfig struct {
-	Min   float64
-	Max   float64
-	Angle float64
+	Calibration // Min, Max, how to drive it and where it's wired
+	Angle       float64
+
+	dev pwmDev // the board it's wired to
+	out int    // and which output
 }
 
 type server struct {
 	UnimplementedControllerServer
-	pca     *pca9685.ServoGroup
+	boards  *boards
 	moverMu sync.Mutex
 	movers  map[int]chan struct{}
 	servos  map[int]*ServoConfig
+	names   map[string]int  // channels by name
+	targets map[int]float64 // where each channel is headed
+	leases  map[int]moveLease
+	stopped map[int]StopReason // why each channel's last motion ended
+	notify  chan struct{}      // closed and replaced on every change
+
+	// saved is what the config file holds for channels set temporarily,
+	// nil for those it doesn't have at all
+	saved map[int]*Calibration
+
+	recorder *recorder // the recording being made, if any
+	playback *playback // the recording being played, if any
+
+	// Poses is the pose library. Without one the pose RPCs fail.
+	Poses *PoseLibrary
+	// Recordings is where recordings are kept. Without it they can't be
+	// made or played.
+	Recordings *RecordingLibrary
+	// ConfigPath is where SetConfig saves the calibration. Empty keeps
+	// changes in memory.
+	ConfigPath string
+}
+
+// NewServer drives the servos described by cal, which must be valid,
+// opening the buses they're wired to with open and starting each at home.
+func NewServer(open BusOpener, cal Calibrations) (*server, error) {
+	return newServer(newBoards(open, true), cal, nil)
 }
 
-func NewServer(sg *pca9685.ServoGroup, servoRanges map[int][2]float64) *server {
-	servos := make(map[int]*ServoConfig)
-	for ch, rng := range servoRanges {
-		mid := (rng[0] + rng[1]) / 2
-		if ch == 15 {
-			mid = 0  
+// newServer is NewServer on bs, starting each channel at its angle in at
+// if it has one within its limits and at home otherwise.
+func newServer(bs *boards, cal Calibrations, at map[int]float64) (*server, error) {
+	s := &server{
+		boards:  bs,
+		movers:  make(map[int]chan struct{}),
+		servos:  make(map[int]*ServoConfig),
+		names:   cal.names(),
+		targets: make(map[int]float64),
+		leases:  make(map[int]moveLease),
+		stopped: make(map[int]StopReason),
+		notify:  make(chan struct{}),
+		saved:   make(map[int]*Calibration),
+	}
+	for _, ch := range cal.channels() {
+		c := cal[ch]
+		cfg := &ServoConfig{Calibration: c, Angle: c.Home}
+		if err := s.wire(ch, cfg); err != nil {
+			s.boards.close()
+			return nil, err
 		}
-		fmt.Println("rng:", rng[0], rng[1], "mid:", mid)
-		servos[ch] = &ServoConfig{
-			Min:   rng[0],
-			Max:   rng[1],
-			Angle: mid,
+		b, out := c.Location(ch)
+		log.Printf("servo %d %s on %s output %d, range %g–%g, home %g", ch, c.Name, b, out, c.Min, c.Max, c.Home)
+		if a, ok := at[ch]; ok && a >= c.Min && a <= c.Max {
+			cfg.Angle = a
 		}
-		// Set physical servo to mid
-		servo := sg.GetServo(ch)
-		servo.SetAngle(physic.Angle(mid))
+		s.servos[ch] = cfg
+		s.targets[ch] = cfg.Angle
+		s.write(s.pulse(ch, cfg.Angle))
+	}
+	return s, nil
+}
+
+// Close stops anything moving or playing, drops a recording in progress
+// and closes the I²C buses.
+func (s *server) Close() error {
+	s.moverMu.Lock()
+	defer s.moverMu.Unlock()
+	if s.playback != nil {
+		s.abort(s.playback)
 	}
-	return &server{
-		pca:    sg,
-		movers: make(map[int]chan struct{}),
-		servos: servos,
+	for _, stop := range s.movers {
+		s.halt(stop, StopReason_STOP_REQUESTED)
 	}
+	if s.recorder != nil {
+		close(s.recorder.stop)
+		s.recorder = nil
+	}
+	return s.boards.close()
 }
 
 func (s *server) Move(ctx context.Context, req *MoveRequest) (*MoveReply, error) {
-	fmt.Printf("Move request: %+v\n", req)
-	ch := int(req.Channel)
+	log.Printf("servo Move: %v", req)
+	s.note("Move", req)
 	dir := int(req.Direction)
 	speed := float64(req.Speed)
 
 	if dir != 1 && dir != -1 {
 		return &MoveReply{Ok: false, Err: "direction must be +1 or -1"}, nil
 	}
+	if speed <= 0 {
+		return &MoveReply{Ok: false, Err: "speed must be above zero"}, nil
+	}
 
 	s.moverMu.Lock()
+	ch, err := s.channel(req.Channel, req.Name)
+	if err != "" {
+		s.moverMu.Unlock()
+		return &MoveReply{Ok: false, Err: err}, nil
+	}
 	cfg, exists := s.servos[ch]
 	if !exists {
 		s.moverMu.Unlock()
@@ -70,10 +137,25 @@ func (s *server) Move(ctx context.Context, req *MoveRequest) (*MoveReply, error)
 		s.moverMu.Unlock()
 		return &MoveReply{Ok: false, Err: "already moving"}, nil
 	}
+	maxDur := MaxMoveDuration
+	if d := time.Duration(req.MaxDurationMs) * time.Millisecond; d > 0 && d < maxDur {
+		maxDur = d
+	}
 	stop := make(chan struct{})
 	s.movers[ch] = stop
+	delete(s.stopped, ch)
+	if dir > 0 {
+		s.targets[ch] = cfg.Max
+	} else {
+		s.targets[ch] = cfg.Min
+	}
+	if ttl := time.Duration(req.LeaseMs) * time.Millisecond; ttl > 0 {
+		s.leases[ch] = moveLease{ttl: ttl, expires: time.Now().Add(ttl)}
+	}
+	s.changed()
 	s.moverMu.Unlock()
 
+	started := time.Now()
 	go func() {
 		ticker := time.NewTicker(50 * time.Millisecond)
 		defer ticker.Stop()
@@ -81,8 +163,18 @@ func (s *server) Move(ctx context.Context, req *MoveRequest) (*MoveReply, error)
 			select {
 			case <-stop:
 				return
-			case <-ticker.C:
+			case now := <-ticker.C:
 				s.moverMu.Lock()
+				if lease, ok := s.leases[ch]; ok && now.After(lease.expires) {
+					s.halt(stop, StopReason_STOP_LEASE_EXPIRED)
+					s.moverMu.Unlock()
+					return
+				}
+				if now.Sub(started) >= maxDur {
+					s.halt(stop, StopReason_STOP_TIMEOUT)
+					s.moverMu.Unlock()
+					return
+				}
 				newAng := cfg.Angle + float64(dir)*speed*0.05
 				if newAng > cfg.Max {
 					newAng = cfg.Max
@@ -90,27 +182,39 @@ func (s *server) Move(ctx context.Context, req *MoveRequest) (*MoveReply, error)
 				if newAng < cfg.Min {
 					newAng = cfg.Min
 				}
-				cfg.Angle = newAng
-				fmt.Println("Setting servo", ch, "to angle", newAng)
+				if newAng != cfg.Angle {
+					cfg.Angle = newAng
+					s.changed()
+				}
+				// set the hardware before letting go, so an emergency stop
+				// can't be undone by a tick already on its way
+				s.write(s.pulse(ch, newAng))
+				atLimit := newAng == cfg.Max && dir > 0 || newAng == cfg.Min && dir < 0
+				if atLimit {
+					// don't leave it pushing against the end stop
+					s.halt(stop, StopReason_STOP_LIMIT)
+				}
 				s.moverMu.Unlock()
-				// set the hardware
-				servo := s.pca.GetServo(ch)
-				if err := servo.SetAngle(physic.Angle(newAng)); err != nil {
-					log.Printf("servo %d set angle error: %v", ch, err)
+				if atLimit {
+					return
 				}
 			}
 		}
 	}()
 
-	return &MoveReply{Ok: true}, nil
+	return &MoveReply{Ok: true, Channel: int32(ch)}, nil
 }
 
 func (s *server) Stop(ctx context.Context, req *StopRequest) (*StopReply, error) {
-	ch := int(req.Channel)
+	s.note("Stop", req)
 	s.moverMu.Lock()
+	ch, err := s.channel(req.Channel, req.Name)
+	if err != "" {
+		s.moverMu.Unlock()
+		return &StopReply{Ok: false, Err: err}, nil
+	}
 	if c, ok := s.movers[ch]; ok {
-		close(c)
-		delete(s.movers, ch)
+		s.halt(c, StopReason_STOP_REQUESTED)
 	}
 	s.moverMu.Unlock()
 	return &StopReply{Ok: true}, nil
@@ -124,7 +228,1022 @@ func (s *server) GetAngles(ctx context.Context, req *GetAnglesRequest) (*GetAngl
 		result = append(result, &ServoAngle{
 			Channel: int32(ch),
 			Angle:   float32(cfg.Angle),
+			Name:    cfg.Name,
 		})
 	}
 	return &GetAnglesReply{Angles: result}, nil
 }
+
+// --- Absolute positioning ---------------------------------------------------
+
+// MotionTick is how often MoveTo and ExecuteTrajectory update the servos.
+var MotionTick = 20 * time.Millisecond
+
+// DefaultMaxVelocity is MoveTo's speed in degrees/sec when the request
+// doesn't give one.
+var DefaultMaxVelocity = 90.0
+
+// SetAngle puts a servo straight at angle.
+func (s *server) SetAngle(ctx context.Context, req *SetAngleRequest) (*SetAngleReply, error) {
+	s.note("SetAngle", req)
+	s.moverMu.Lock()
+	ch, err := s.channel(req.Channel, req.Name)
+	var cfg *ServoConfig
+	if err == "" {
+		cfg, err = s.check(ch, req.Angle)
+	}
+	if err != "" {
+		s.moverMu.Unlock()
+		return &SetAngleReply{Ok: false, Err: err}, nil
+	}
+	if c, ok := s.movers[ch]; ok {
+		s.cancel(c)
+	}
+	cfg.Angle = req.Angle
+	s.targets[ch] = req.Angle
+	s.changed()
+	s.write(s.pulse(ch, req.Angle))
+	s.moverMu.Unlock()
+	return &SetAngleReply{Ok: true}, nil
+}
+
+// MoveTo drives a servo to angle along a trapezoidal profile: accelerating
+// up to max_velocity and slowing in time to stop on the target.
+func (s *server) MoveTo(ctx context.Context, req *MoveToRequest) (*MoveToReply, error) {
+	s.note("MoveTo", req)
+	vmax := req.MaxVelocity
+	if vmax == 0 {
+		vmax = DefaultMaxVelocity
+	}
+	if vmax < 0 || req.Acceleration < 0 {
+		return &MoveToReply{Ok: false, Err: "max_velocity and acceleration must not be negative"}, nil
+	}
+
+	s.moverMu.Lock()
+	ch, err := s.channel(req.Channel, req.Name)
+	var cfg *ServoConfig
+	if err == "" {
+		cfg, err = s.check(ch, req.Angle)
+	}
+	if err != "" {
+		s.moverMu.Unlock()
+		return &MoveToReply{Ok: false, Err: err}, nil
+	}
+	stop := s.claim(ch)
+	s.targets[ch] = req.Angle
+	s.changed()
+	s.moverMu.Unlock()
+
+	prof := &profile{pos: cfg.Angle, target: req.Angle, vmax: vmax, accel: req.Acceleration}
+	done := s.animate(stop, []int{ch}, func(dt time.Duration) (map[int]float64, bool) {
+		finished := prof.step(dt.Seconds())
+		return map[int]float64{ch: prof.pos}, finished
+	})
+	if req.Wait {
+		if err := s.await(ctx, stop, done); err != "" {
+			return &MoveToReply{Ok: false, Err: err}, nil
+		}
+	}
+	return &MoveToReply{Ok: true}, nil
+}
+
+// ExecuteTrajectory plays timed keyframes across several servos,
+// interpolating between them here rather than making the caller stream
+// angles. Every target is checked before anything moves.
+func (s *server) ExecuteTrajectory(ctx context.Context, req *TrajectoryRequest) (*TrajectoryReply, error) {
+	s.note("ExecuteTrajectory", req)
+	if len(req.Keyframes) == 0 {
+		return &TrajectoryReply{Ok: false, Err: "no keyframes"}, nil
+	}
+
+	s.moverMu.Lock()
+	tracks := map[int][]waypoint{}
+	var prev uint32
+	for i, kf := range req.Keyframes {
+		if i > 0 && kf.AtMs <= prev {
+			s.moverMu.Unlock()
+			return &TrajectoryReply{Ok: false, Err: fmt.Sprintf("keyframe %d: at_ms must increase", i)}, nil
+		}
+		prev = kf.AtMs
+		for _, t := range kf.Targets {
+			ch, err := s.channel(t.Channel, t.Name)
+			if err == "" {
+				_, err = s.check(ch, float64(t.Angle))
+			}
+			if err != "" {
+				s.moverMu.Unlock()
+				return &TrajectoryReply{Ok: false, Err: fmt.Sprintf("keyframe %d: %s", i, err)}, nil
+			}
+			if tracks[ch] == nil {
+				tracks[ch] = []waypoint{{0, s.servos[ch].Angle}}
+			}
+			tracks[ch] = append(tracks[ch], waypoint{float64(kf.AtMs) / 1000, float64(t.Angle)})
+		}
+	}
+	if len(tracks) == 0 {
+		s.moverMu.Unlock()
+		return &TrajectoryReply{Ok: false, Err: "no targets"}, nil
+	}
+	stop, done := s.play(tracks, float64(prev)/1000)
+	s.moverMu.Unlock()
+
+	if req.Wait {
+		if err := s.await(ctx, stop, done); err != "" {
+			return &TrajectoryReply{Ok: false, Err: err}, nil
+		}
+	}
+	return &TrajectoryReply{Ok: true}, nil
+}
+
+// play moves each channel along its waypoints, finishing end seconds from
+// now. Callers hold moverMu and have checked every angle.
+func (s *server) play(tracks map[int][]waypoint, end float64) (chan struct{}, <-chan struct{}) {
+	chs := make([]int, 0, len(tracks))
+	for ch := range tracks {
+		chs = append(chs, ch)
+	}
+	sort.Ints(chs)
+	stop := s.claim(chs...)
+	for ch, pts := range tracks {
+		s.targets[ch] = pts[len(pts)-1].angle
+	}
+	s.changed()
+
+	var elapsed float64
+	done := s.animate(stop, chs, func(dt time.Duration) (map[int]float64, bool) {
+		elapsed += dt.Seconds()
+		angles := make(map[int]float64, len(tracks))
+		for ch, pts := range tracks {
+			angles[ch] = interpolate(pts, elapsed)
+		}
+		return angles, elapsed >= end
+	})
+	return stop, done
+}
+
+// channel is the channel a request means: the one called name, or num
+// when there's no name. Callers hold moverMu.
+func (s *server) channel(num int32, name string) (int, string) {
+	if name == "" {
+		return int(num), ""
+	}
+	ch, ok := s.names[name]
+	if !ok {
+		return 0, fmt.Sprintf("no servo called %q", name)
+	}
+	return ch, ""
+}
+
+// check returns ch's config, or why angle can't be sent to it. Callers hold
+// moverMu.
+func (s *server) check(ch int, angle float64) (*ServoConfig, string) {
+	cfg, ok := s.servos[ch]
+	if !ok {
+		return nil, "invalid servo channel"
+	}
+	if math.IsNaN(angle) || angle < cfg.Min || angle > cfg.Max {
+		return nil, fmt.Sprintf("channel %d: angle %g outside %g–%g", ch, angle, cfg.Min, cfg.Max)
+	}
+	return cfg, ""
+}
+
+// claim cancels whatever chs are doing and registers one stop channel for
+// all of them, so stopping any one stops the lot. Callers hold moverMu.
+func (s *server) claim(chs ...int) chan struct{} {
+	for _, ch := range chs {
+		if c, ok := s.movers[ch]; ok {
+			s.cancel(c)
+		}
+	}
+	stop := make(chan struct{})
+	for _, ch := range chs {
+		s.movers[ch] = stop
+		delete(s.stopped, ch)
+	}
+	return stop
+}
+
+// cancel stops a mover and forgets every channel it was driving. Callers
+// hold moverMu.
+func (s *server) cancel(stop chan struct{}) {
+	close(stop)
+	s.release(stop)
+}
+
+// release forgets a mover that has finished or been cancelled, leaving
+// its channels where they are. Callers hold moverMu.
+func (s *server) release(stop chan struct{}) {
+	for ch, c := range s.movers {
+		if c == stop {
+			delete(s.movers, ch)
+			delete(s.leases, ch)
+			s.targets[ch] = s.servos[ch].Angle
+		}
+	}
+	s.changed()
+}
+
+// animate calls next every MotionTick and sends the angles it returns to
+// the hardware until it reports done or stop closes. The returned channel
+// closes when the motion finishes on its own.
+func (s *server) animate(stop chan struct{}, chs []int, next func(dt time.Duration) (map[int]float64, bool)) <-chan struct{} {
+	done := make(chan struct{})
+	tick := MotionTick
+	go func() {
+		ticker := time.NewTicker(tick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				angles, finished := next(tick)
+				s.moverMu.Lock()
+				select {
+				case <-stop:
+					s.moverMu.Unlock()
+					return // cancelled while we were computing
+				default:
+				}
+				for _, ch := range chs {
+					s.servos[ch].Angle = angles[ch]
+					s.write(s.pulse(ch, angles[ch]))
+				}
+				if finished {
+					s.release(stop)
+				} else {
+					s.changed()
+				}
+				s.moverMu.Unlock()
+				if finished {
+					close(done)
+					return
+				}
+			}
+		}
+	}()
+	return done
+}
+
+// await blocks until a motion finishes, returning why it didn't.
+func (s *server) await(ctx context.Context, stop chan struct{}, done <-chan struct{}) string {
+	select {
+	case <-done:
+		return ""
+	case <-stop:
+		return "stopped"
+	case <-ctx.Done():
+		return ctx.Err().Error()
+	}
+}
+
+// output is a pulse for one channel's servo. It's worked out and written
+// under moverMu, so nothing reaches the hardware after an emergency stop
+// has cut it.
+type output struct {
+	ch, out, pulse int
+	dev            pwmDev
+}
+
+// pulse works out what puts ch at angle, and where to send it. Callers
+// hold moverMu.
+func (s *server) pulse(ch int, angle float64) output {
+	cfg := s.servos[ch]
+	return output{ch: ch, out: cfg.out, pulse: cfg.Pulse(angle), dev: cfg.dev}
+}
+
+func (s *server) write(o output) {
+	if err := o.dev.SetPwm(o.out, 0, gpio.Duty(o.pulse)); err != nil {
+		log.Printf("servo %d set angle error: %v", o.ch, err)
+	}
+}
+
+// wire points cfg at the board and output its calibration names for ch,
+// opening the board if nothing else uses it yet. Callers hold moverMu.
+func (s *server) wire(ch int, cfg *ServoConfig) error {
+	b, out := cfg.Location(ch)
+	dev, err := s.boards.get(b)
+	if err != nil {
+		return fmt.Errorf("channel %d: %w", ch, err)
+	}
+	cfg.dev, cfg.out = dev, out
+	return nil
+}
+
+// profile is a trapezoidal velocity profile in degrees and seconds. With no
+// acceleration it runs at vmax throughout.
+type profile struct {
+	pos, vel, target float64
+	vmax, accel      float64
+}
+
+// step advances the profile by dt seconds and reports whether it arrived.
+func (p *profile) step(dt float64) bool {
+	dist := math.Abs(p.target - p.pos)
+	v := p.vmax
+	if p.accel > 0 {
+		v = math.Min(v, p.vel+p.accel*dt)
+		v = math.Min(v, math.Sqrt(2*p.accel*dist)) // leave room to stop
+		v = math.Max(v, p.accel*dt)                // don't stall just short
+	}
+	if v*dt >= dist {
+		p.pos, p.vel = p.target, 0
+		return true
+	}
+	p.pos += math.Copysign(v*dt, p.target-p.pos)
+	p.vel = v
+	return false
+}
+
+type waypoint struct {
+	at    float64 // seconds
+	angle float64
+}
+
+// interpolate finds the angle at t along pts, holding the last one after
+// the end.
+func interpolate(pts []waypoint, t float64) float64 {
+	for i := 1; i < len(pts); i++ {
+		if t < pts[i].at {
+			a, b := pts[i-1], pts[i]
+			return a.angle + (b.angle-a.angle)*(t-a.at)/(b.at-a.at)
+		}
+	}
+	return pts[len(pts)-1].angle
+}
+
+// --- State streaming --------------------------------------------------------
+
+// WatchState rates are in updates per second.
+var (
+	DefaultWatchRate = 10.0
+	MaxWatchRate     = 50.0
+)
+
+// WatchState streams every channel's state: once straight away, then after
+// each change, no more often than the requested rate. Changes in between
+// are folded into the next update.
+func (s *server) WatchState(req *WatchStateRequest, stream Controller_WatchStateServer) error {
+	rate := req.MaxRateHz
+	if rate <= 0 {
+		rate = DefaultWatchRate
+	}
+	rate = math.Min(rate, MaxWatchRate)
+	interval := time.Duration(float64(time.Second) / rate)
+	ctx := stream.Context()
+
+	var last *ServoState
+	for {
+		s.moverMu.Lock()
+		st, wake := s.state(), s.notify
+		s.moverMu.Unlock()
+
+		if last == nil || !proto.Equal(st, last) {
+			last = st
+			msg := proto.Clone(st).(*ServoState)
+			msg.TimeMs = time.Now().UnixMilli()
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+			select {
+			case <-time.After(interval):
+			case <-ctx.Done():
+				return nil
+			}
+		}
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// changed wakes every watcher. Callers hold moverMu.
+func (s *server) changed() {
+	close(s.notify)
+	s.notify = make(chan struct{})
+}
+
+// state describes every channel, without a timestamp so two can be
+// compared. Callers hold moverMu.
+func (s *server) state() *ServoState {
+	st := &ServoState{}
+	for ch, cfg := range s.servos {
+		cs := &ChannelState{Channel: int32(ch), Name: cfg.Name, Angle: cfg.Angle, Target: s.targets[ch], Stopped: s.stopped[ch]}
+		_, cs.Moving = s.movers[ch]
+		switch {
+		case cfg.Angle <= cfg.Min:
+			cs.AtLimit = Limit_LIMIT_MIN
+		case cfg.Angle >= cfg.Max:
+			cs.AtLimit = Limit_LIMIT_MAX
+		}
+		st.Channels = append(st.Channels, cs)
+	}
+	sort.Slice(st.Channels, func(i, j int) bool { return st.Channels[i].Channel < st.Channels[j].Channel })
+	return st
+}
+
+// --- Poses ------------------------------------------------------------------
+
+func (s *server) SavePose(ctx context.Context, req *SavePoseRequest) (*SavePoseReply, error) {
+	if s.Poses == nil {
+		return &SavePoseReply{Ok: false, Err: "no pose library"}, nil
+	}
+	angles := map[int]float64{}
+	s.moverMu.Lock()
+	if len(req.Channels) == 0 && len(req.Names) == 0 {
+		for ch, cfg := range s.servos {
+			angles[ch] = cfg.Angle
+		}
+	}
+	for _, c := range req.Channels {
+		cfg, ok := s.servos[int(c)]
+		if !ok {
+			s.moverMu.Unlock()
+			return &SavePoseReply{Ok: false, Err: fmt.Sprintf("invalid servo channel %d", c)}, nil
+		}
+		angles[int(c)] = cfg.Angle
+	}
+	for _, name := range req.Names {
+		ch, ok := s.names[name]
+		if !ok {
+			s.moverMu.Unlock()
+			return &SavePoseReply{Ok: false, Err: fmt.Sprintf("no servo called %q", name)}, nil
+		}
+		angles[ch] = s.servos[ch].Angle
+	}
+	s.moverMu.Unlock()
+
+	if err := s.Poses.Save(req.Name, angles); err != nil {
+		return &SavePoseReply{Ok: false, Err: err.Error()}, nil
+	}
+	log.Printf("saved pose %q: %v", req.Name, angles)
+	return &SavePoseReply{Ok: true, Pose: toPose(req.Name, angles)}, nil
+}
+
+func (s *server) ListPoses(ctx context.Context, req *ListPosesRequest) (*ListPosesReply, error) {
+	if s.Poses == nil {
+		return &ListPosesReply{}, nil
+	}
+	return &ListPosesReply{Poses: s.Poses.List()}, nil
+}
+
+func (s *server) DeletePose(ctx context.Context, req *DeletePoseRequest) (*DeletePoseReply, error) {
+	if s.Poses == nil {
+		return &DeletePoseReply{Ok: false, Err: "no pose library"}, nil
+	}
+	if err := s.Poses.Delete(req.Name); err != nil {
+		return &DeletePoseReply{Ok: false, Err: err.Error()}, nil
+	}
+	return &DeletePoseReply{Ok: true}, nil
+}
+
+// MoveToPose moves every channel in a pose in a straight line from where it
+// is, all finishing together.
+func (s *server) MoveToPose(ctx context.Context, req *MoveToPoseRequest) (*MoveToPoseReply, error) {
+	s.note("MoveToPose", req)
+	if s.Poses == nil {
+		return &MoveToPoseReply{Ok: false, Err: "no pose library"}, nil
+	}
+	pose, ok := s.Poses.Get(req.Name)
+	if !ok {
+		return &MoveToPoseReply{Ok: false, Err: fmt.Sprintf("no pose %q", req.Name)}, nil
+	}
+	vmax := req.MaxVelocity
+	if vmax == 0 {
+		vmax = DefaultMaxVelocity
+	}
+	if vmax < 0 {
+		return &MoveToPoseReply{Ok: false, Err: "max_velocity must not be negative"}, nil
+	}
+
+	s.moverMu.Lock()
+	end := float64(req.DurationMs) / 1000
+	tracks := map[int][]waypoint{}
+	for ch, angle := range pose {
+		cfg, err := s.check(ch, angle)
+		if err != "" {
+			// calibration may have changed since the pose was saved
+			s.moverMu.Unlock()
+			return &MoveToPoseReply{Ok: false, Err: fmt.Sprintf("pose %q: %s", req.Name, err)}, nil
+		}
+		if req.DurationMs == 0 {
+			end = math.Max(end, math.Abs(angle-cfg.Angle)/vmax)
+		}
+		tracks[ch] = []waypoint{{0, cfg.Angle}, {end, angle}}
+	}
+	for _, pts := range tracks {
+		pts[1].at = end
+	}
+	stop, done := s.play(tracks, end)
+	s.moverMu.Unlock()
+
+	if req.Wait {
+		if err := s.await(ctx, stop, done); err != "" {
+			return &MoveToPoseReply{Ok: false, Err: err}, nil
+		}
+	}
+	return &MoveToPoseReply{Ok: true}, nil
+}
+
+// --- Calibration ------------------------------------------------------------
+
+func (s *server) GetConfig(ctx context.Context, req *GetConfigRequest) (*GetConfigReply, error) {
+	s.moverMu.Lock()
+	defer s.moverMu.Unlock()
+	reply := &GetConfigReply{}
+	for _, ch := range s.calibrations().channels() {
+		reply.Channels = append(reply.Channels, toChannelConfig(ch, s.servos[ch].Calibration))
+	}
+	return reply, nil
+}
+
+// SetConfig checks and saves new calibration, then applies it. Channels
+// that were moving stop; any left outside their new limits, or asked to go
+// home, jump there. A channel rewired to another output lets go of the
+// old one. Temporary calibration is applied but never saved, so the config
+// file keeps what it had for those channels until they're set for good.
+func (s *server) SetConfig(ctx context.Context, req *SetConfigRequest) (*SetConfigReply, error) {
+	s.moverMu.Lock()
+	defer s.moverMu.Unlock()
+
+	cal := s.calibrations()
+	for _, cc := range req.Channels {
+		if cc.Address > math.MaxUint16 {
+			return &SetConfigReply{Ok: false, Err: fmt.Sprintf("channel %d: address %#x out of range", cc.Channel, cc.Address)}, nil
+		}
+		cal[int(cc.Channel)] = fromChannelConfig(cc)
+	}
+	if err := cal.Validate(); err != nil {
+		return &SetConfigReply{Ok: false, Err: err.Error()}, nil
+	}
+	// find every board before anything is saved or changes
+	wired := make(map[int]*ServoConfig, len(req.Channels))
+	for _, cc := range req.Channels {
+		ch := int(cc.Channel)
+		w := &ServoConfig{Calibration: cal[ch]}
+		if err := s.wire(ch, w); err != nil {
+			return &SetConfigReply{Ok: false, Err: err.Error()}, nil
+		}
+		wired[ch] = w
+	}
+	if req.Temporary {
+		for _, cc := range req.Channels {
+			ch := int(cc.Channel)
+			if _, ok := s.saved[ch]; ok {
+				continue
+			}
+			var was *Calibration
+			if cfg, ok := s.servos[ch]; ok {
+				c := cfg.Calibration
+				was = &c
+			}
+			s.saved[ch] = was
+		}
+	} else {
+		for _, cc := range req.Channels {
+			delete(s.saved, int(cc.Channel))
+		}
+		if s.ConfigPath != "" {
+			if err := SaveCalibrations(s.ConfigPath, s.persisted(cal)); err != nil {
+				return &SetConfigReply{Ok: false, Err: err.Error()}, nil
+			}
+		}
+	}
+
+	// off with any old outputs first, in case another channel takes one over
+	for ch, w := range wired {
+		if cfg, ok := s.servos[ch]; ok && (cfg.dev != w.dev || cfg.out != w.out) {
+			if err := cfg.dev.SetPwm(cfg.out, 0, 0); err != nil {
+				log.Printf("servo %d releasing old output: %v", ch, err)
+			}
+		}
+	}
+	for _, cc := range req.Channels {
+		ch := int(cc.Channel)
+		c := cal[ch]
+		if stop, ok := s.movers[ch]; ok {
+			s.cancel(stop)
+		}
+		cfg, ok := s.servos[ch]
+		if !ok {
+			cfg = &ServoConfig{Angle: c.Home}
+			s.servos[ch] = cfg
+		}
+		cfg.Calibration, cfg.dev, cfg.out = c, wired[ch].dev, wired[ch].out
+		if req.GoHome {
+			cfg.Angle = c.Home
+		}
+		cfg.Angle = math.Max(c.Min, math.Min(c.Max, cfg.Angle))
+		s.targets[ch] = cfg.Angle
+		log.Printf("servo %d calibrated: %+v", ch, c)
+		// new pulse range, trim, direction or output: resend even if the
+		// angle held
+		s.write(s.pulse(ch, cfg.Angle))
+	}
+	s.names = cal.names()
+	s.changed()
+	return &SetConfigReply{Ok: true}, nil
+}
+
+// persisted is cal as it belongs in the config file: channels set
+// temporarily go back to what they were. Callers hold moverMu.
+func (s *server) persisted(cal Calibrations) Calibrations {
+	out := make(Calibrations, len(cal))
+	for ch, c := range cal {
+		out[ch] = c
+	}
+	for ch, was := range s.saved {
+		if was == nil {
+			delete(out, ch)
+		} else {
+			out[ch] = *was
+		}
+	}
+	return out
+}
+
+// calibrations copies every channel's calibration. Callers hold moverMu.
+func (s *server) calibrations() Calibrations {
+	cal := make(Calibrations, len(s.servos))
+	for ch, cfg := range s.servos {
+		cal[ch] = cfg.Calibration
+	}
+	return cal
+}
+
+func toChannelConfig(ch int, c Calibration) *ChannelConfig {
+	return &ChannelConfig{
+		Channel:  int32(ch),
+		MinAngle: c.Min,
+		MaxAngle: c.Max,
+		Home:     c.Home,
+		MinPulse: uint32(c.MinPulse),
+		MaxPulse: uint32(c.MaxPulse),
+		Invert:   c.Invert,
+		Trim:     c.Trim,
+		Name:     c.Name,
+		Bus:      int32(c.Bus),
+		Address:  uint32(c.Addr),
+		Output:   int32(c.Output),
+	}
+}
+
+func fromChannelConfig(cc *ChannelConfig) Calibration {
+	return Calibration{
+		Min:      cc.MinAngle,
+		Max:      cc.MaxAngle,
+		Home:     cc.Home,
+		MinPulse: int(cc.MinPulse),
+		MaxPulse: int(cc.MaxPulse),
+		Invert:   cc.Invert,
+		Trim:     cc.Trim,
+		Name:     cc.Name,
+		Bus:      int(cc.Bus),
+		Addr:     uint16(cc.Address),
+		Output:   int(cc.Output),
+	}
+}
+
+// --- Safety -----------------------------------------------------------------
+
+// MaxMoveDuration is the longest a Move runs, whatever it asks for.
+var MaxMoveDuration = 10 * time.Second
+
+type moveLease struct {
+	ttl     time.Duration
+	expires time.Time
+}
+
+// halt stops a mover, recording why against every channel it drove.
+// Callers hold moverMu.
+func (s *server) halt(stop chan struct{}, why StopReason) {
+	var chs []int
+	for ch, c := range s.movers {
+		if c == stop {
+			s.stopped[ch] = why
+			chs = append(chs, ch)
+		}
+	}
+	s.cancel(stop)
+	if why != StopReason_STOP_REQUESTED {
+		sort.Ints(chs)
+		log.Printf("servo %v stopped: %s", chs, why)
+	}
+}
+
+func (s *server) RenewMove(ctx context.Context, req *RenewMoveRequest) (*RenewMoveReply, error) {
+	s.moverMu.Lock()
+	defer s.moverMu.Unlock()
+	reply := &RenewMoveReply{}
+	now := time.Now()
+	for _, c := range req.Channels {
+		lease, ok := s.leases[int(c)]
+		if !ok {
+			reply.NotMoving = append(reply.NotMoving, c)
+			continue
+		}
+		lease.expires = now.Add(lease.ttl)
+		s.leases[int(c)] = lease
+	}
+	return reply, nil
+}
+
+// EmergencyStop halts every channel, and with cut_pwm turns the outputs off.
+func (s *server) EmergencyStop(ctx context.Context, req *EmergencyStopRequest) (*EmergencyStopReply, error) {
+	s.note("EmergencyStop", req)
+	s.moverMu.Lock()
+	defer s.moverMu.Unlock()
+	for _, stop := range s.movers {
+		s.halt(stop, StopReason_STOP_EMERGENCY)
+	}
+	// between passes a looping playback has nothing in movers
+	if s.playback != nil {
+		s.abort(s.playback)
+	}
+	log.Printf("🛑 servo emergency stop (cut PWM: %v)", req.CutPwm)
+	if req.CutPwm {
+		err := s.boards.each(func(_ Board, dev pwmDev) error { return dev.SetAllPwm(0, 0) })
+		if err != nil {
+			return &EmergencyStopReply{Ok: false, Err: err.Error()}, nil
+		}
+	}
+	return &EmergencyStopReply{Ok: true}, nil
+}
+
+// --- Recording --------------------------------------------------------------
+
+// MinPlaybackSpeed and MaxPlaybackSpeed bound how much slower or faster
+// than recorded a recording may be played.
+var (
+	MinPlaybackSpeed = 0.5
+	MaxPlaybackSpeed = 2.0
+)
+
+func (s *server) StartRecording(ctx context.Context, req *StartRecordingRequest) (*StartRecordingReply, error) {
+	if s.Recordings == nil {
+		return &StartRecordingReply{Ok: false, Err: "no recording library"}, nil
+	}
+	if !validName.MatchString(req.Name) {
+		return &StartRecordingReply{Ok: false, Err: fmt.Sprintf("recording name %q: use letters, digits, '.', '_' and '-', starting with a letter", req.Name)}, nil
+	}
+	s.moverMu.Lock()
+	if s.recorder != nil {
+		name := s.recorder.rec.Name
+		s.moverMu.Unlock()
+		return &StartRecordingReply{Ok: false, Err: fmt.Sprintf("already recording %q", name)}, nil
+	}
+	r := newRecorder(req.Name, time.Now())
+	r.sample(r.rec.Started, s.angles())
+	s.recorder = r
+	s.moverMu.Unlock()
+
+	log.Printf("⏺ recording %q", req.Name)
+	go s.record(r)
+	return &StartRecordingReply{Ok: true}, nil
+}
+
+func (s *server) StopRecording(ctx context.Context, req *StopRecordingRequest) (*StopRecordingReply, error) {
+	s.moverMu.Lock()
+	r := s.recorder
+	s.recorder = nil
+	s.moverMu.Unlock()
+	if r == nil {
+		return &StopRecordingReply{Ok: false, Err: "not recording"}, nil
+	}
+	close(r.stop)
+	<-r.done
+
+	s.moverMu.Lock()
+	rec := r.finish(time.Now(), s.angles())
+	s.moverMu.Unlock()
+	if req.Discard {
+		log.Printf("recording %q discarded", rec.Name)
+		return &StopRecordingReply{Ok: true, Recording: rec.info()}, nil
+	}
+	if err := s.Recordings.Save(rec); err != nil {
+		return &StopRecordingReply{Ok: false, Err: err.Error()}, nil
+	}
+	log.Printf("⏹ recorded %q: %dms, %d frames, %d commands", rec.Name, rec.DurationMs, len(rec.Frames), len(rec.Commands))
+	return &StopRecordingReply{Ok: true, Recording: rec.info()}, nil
+}
+
+func (s *server) ListRecordings(ctx context.Context, req *ListRecordingsRequest) (*ListRecordingsReply, error) {
+	reply := &ListRecordingsReply{}
+	if s.Recordings != nil {
+		reply.Recordings = s.Recordings.List()
+	}
+	s.moverMu.Lock()
+	defer s.moverMu.Unlock()
+	if s.recorder != nil {
+		reply.Recording = s.recorder.rec.Name
+	}
+	if s.playback != nil {
+		reply.Playing = s.playback.name
+	}
+	return reply, nil
+}
+
+func (s *server) DeleteRecording(ctx context.Context, req *DeleteRecordingRequest) (*DeleteRecordingReply, error) {
+	if s.Recordings == nil {
+		return &DeleteRecordingReply{Ok: false, Err: "no recording library"}, nil
+	}
+	if err := s.Recordings.Delete(req.Name); err != nil {
+		return &DeleteRecordingReply{Ok: false, Err: err.Error()}, nil
+	}
+	return &DeleteRecordingReply{Ok: true}, nil
+}
+
+// record samples every channel's angle each MotionTick until r stops.
+func (s *server) record(r *recorder) {
+	defer close(r.done)
+	ticker := time.NewTicker(MotionTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case now := <-ticker.C:
+			s.moverMu.Lock()
+			angles := s.angles()
+			s.moverMu.Unlock()
+			r.sample(now, angles)
+		}
+	}
+}
+
+// note logs a motion command in the recording, if one is being made.
+func (s *server) note(rpc string, req proto.Message) {
+	s.moverMu.Lock()
+	defer s.moverMu.Unlock()
+	if s.recorder == nil {
+		return
+	}
+	raw, err := protojson.Marshal(req)
+	if err != nil {
+		return
+	}
+	rec := s.recorder.rec
+	rec.Commands = append(rec.Commands, RecordCommand{AtMs: time.Since(rec.Started).Milliseconds(), RPC: rpc, Request: raw})
+}
+
+// angles copies every channel's angle. Callers hold moverMu.
+func (s *server) angles() map[int]float64 {
+	out := make(map[int]float64, len(s.servos))
+	for ch, cfg := range s.servos {
+		out[ch] = cfg.Angle
+	}
+	return out
+}
+
+// playback is a recording being played, one pass at a time.
+type playback struct {
+	name string
+	stop chan struct{} // the current pass's
+	done chan struct{} // closed when the playback ends
+	err  string        // why it ended early, set before done closes
+}
+
+func (s *server) PlayRecording(ctx context.Context, req *PlayRecordingRequest) (*PlayRecordingReply, error) {
+	if s.Recordings == nil {
+		return &PlayRecordingReply{Ok: false, Err: "no recording library"}, nil
+	}
+	rec, ok := s.Recordings.Get(req.Name)
+	if !ok {
+		return &PlayRecordingReply{Ok: false, Err: fmt.Sprintf("no recording %q", req.Name)}, nil
+	}
+	speed := req.Speed
+	if speed == 0 {
+		speed = 1
+	}
+	if !(speed >= MinPlaybackSpeed && speed <= MaxPlaybackSpeed) {
+		return &PlayRecordingReply{Ok: false, Err: fmt.Sprintf("speed %g outside %g–%g", speed, MinPlaybackSpeed, MaxPlaybackSpeed)}, nil
+	}
+	loops := max(int(req.Loops), 1)
+
+	s.moverMu.Lock()
+	tracks, end, err := s.playbackTracks(rec, speed)
+	if err != "" {
+		s.moverMu.Unlock()
+		return &PlayRecordingReply{Ok: false, Err: err}, nil
+	}
+	if s.playback != nil {
+		s.abort(s.playback)
+	}
+	p := &playback{name: rec.Name, done: make(chan struct{})}
+	var passDone <-chan struct{}
+	p.stop, passDone = s.play(tracks, end)
+	s.playback = p
+	s.moverMu.Unlock()
+
+	log.Printf("▶ playing %q at %gx, %d loops (forever: %v)", rec.Name, speed, loops, req.Forever)
+	go func() {
+		for pass := 1; ; pass++ {
+			select {
+			case <-passDone:
+			case <-p.stop:
+				s.endPlayback(p, "stopped")
+				return
+			}
+			if !req.Forever && pass >= loops {
+				s.endPlayback(p, "")
+				return
+			}
+			s.moverMu.Lock()
+			if s.playback != p {
+				s.moverMu.Unlock()
+				s.endPlayback(p, "stopped")
+				return
+			}
+			// calibration may have changed since the last pass
+			tracks, end, err := s.playbackTracks(rec, speed)
+			if err != "" {
+				s.moverMu.Unlock()
+				s.endPlayback(p, err)
+				return
+			}
+			p.stop, passDone = s.play(tracks, end)
+			s.moverMu.Unlock()
+		}
+	}()
+
+	if req.Wait {
+		select {
+		case <-p.done:
+			if p.err != "" {
+				return &PlayRecordingReply{Ok: false, Err: p.err}, nil
+			}
+		case <-ctx.Done():
+			return &PlayRecordingReply{Ok: false, Err: ctx.Err().Error()}, nil
+		}
+	}
+	return &PlayRecordingReply{Ok: true}, nil
+}
+
+// AbortPlayback stops the recording being played, leaving the servos where
+// they are.
+func (s *server) AbortPlayback(ctx context.Context, req *AbortPlaybackRequest) (*AbortPlaybackReply, error) {
+	s.moverMu.Lock()
+	defer s.moverMu.Unlock()
+	if s.playback == nil {
+		return &AbortPlaybackReply{Ok: false, Err: "nothing playing"}, nil
+	}
+	log.Printf("playback of %q aborted", s.playback.name)
+	s.abort(s.playback)
+	return &AbortPlaybackReply{Ok: true}, nil
+}
+
+// abort halts p's current pass, unless something already has, and forgets
+// it. Callers hold moverMu.
+func (s *server) abort(p *playback) {
+	select {
+	case <-p.stop:
+	default:
+		s.halt(p.stop, StopReason_STOP_REQUESTED)
+	}
+	s.playback = nil
+}
+
+func (s *server) endPlayback(p *playback, err string) {
+	s.moverMu.Lock()
+	if s.playback == p {
+		s.playback = nil
+	}
+	s.moverMu.Unlock()
+	if err != "" {
+		log.Printf("playback of %q ended: %s", p.name, err)
+	}
+	p.err = err
+	close(p.done)
+}
+
+// playbackTracks lays out one pass through rec at speed: a lead-in from
+// where each channel is to where the recording starts, at
+// DefaultMaxVelocity, then the frames. Callers hold moverMu.
+func (s *server) playbackTracks(rec *Recording, speed float64) (map[int][]waypoint, float64, string) {
+	var lead float64
+	for ch, a := range rec.Frames[0].Angles {
+		cfg, err := s.check(ch, a)
+		if err != "" {
+			return nil, 0, fmt.Sprintf("recording %q: %s", rec.Name, err)
+		}
+		lead = math.Max(lead, math.Abs(a-cfg.Angle)/DefaultMaxVelocity)
+	}
+	tracks := map[int][]waypoint{}
+	for ch, a := range rec.Frames[0].Angles {
+		tracks[ch] = []waypoint{{0, s.servos[ch].Angle}, {lead, a}}
+	}
+	for _, f := range rec.Frames[1:] {
+		for ch, a := range f.Angles {
+			if _, err := s.check(ch, a); err != "" {
+				return nil, 0, fmt.Sprintf("recording %q at %dms: %s", rec.Name, f.AtMs, err)
+			}
+			if tracks[ch] == nil {
+				tracks[ch] = []waypoint{{0, s.servos[ch].Angle}}
+			}
+			tracks[ch] = append(tracks[ch], waypoint{lead + float64(f.AtMs)/1000/speed, a})
+		}
+	}
+	return tracks, lead + float64(rec.DurationMs)/1000/speed, ""
+}
@@ -0,0 +1,431 @@
+package servo
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"periph.io/x/conn/v3/i2c"
+)
+
+func newTestServer(t *testing.T) *server {
+	t.Helper()
+	s, err := NewServer(func(int) (i2c.BusCloser, error) { return NopBus{}, nil }, Calibrations{
+		4: {Min: 15, Max: 140, Home: 77.5, MinPulse: 50, MaxPulse: 650},
+		6: {Min: 15, Max: 68, Home: 41.5, MinPulse: 50, MaxPulse: 650},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func angle(s *server, ch int) float64 {
+	s.moverMu.Lock()
+	defer s.moverMu.Unlock()
+	return s.servos[ch].Angle
+}
+
+func TestSetAngle(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	if r, _ := s.SetAngle(ctx, &SetAngleRequest{Channel: 4, Angle: 100}); !r.Ok {
+		t.Fatalf("SetAngle: %s", r.Err)
+	}
+	if a := angle(s, 4); a != 100 {
+		t.Errorf("angle = %v, want 100", a)
+	}
+	for _, req := range []*SetAngleRequest{
+		{Channel: 4, Angle: 141},
+		{Channel: 6, Angle: 10},
+		{Channel: 9, Angle: 90},
+		{Channel: 4, Angle: math.NaN()},
+	} {
+		if r, _ := s.SetAngle(ctx, req); r.Ok {
+			t.Errorf("SetAngle(%v) accepted", req)
+		}
+	}
+	if a := angle(s, 4); a != 100 {
+		t.Errorf("refused request moved the servo to %v", a)
+	}
+}
+
+func TestSetAngleCancelsMove(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	s.Move(ctx, &MoveRequest{Channel: 4, Direction: 1, Speed: 100})
+	s.SetAngle(ctx, &SetAngleRequest{Channel: 4, Angle: 20})
+	time.Sleep(120 * time.Millisecond)
+	if a := angle(s, 4); a != 20 {
+		t.Errorf("angle = %v; Move kept going after SetAngle", a)
+	}
+	if r, _ := s.Move(ctx, &MoveRequest{Channel: 4, Direction: 1, Speed: 10}); !r.Ok {
+		t.Errorf("Move after SetAngle: %s", r.Err)
+	}
+	s.Stop(ctx, &StopRequest{Channel: 4})
+}
+
+func TestProfile(t *testing.T) {
+	p := &profile{pos: 20, target: 120, vmax: 100, accel: 200}
+	var elapsed, peak float64
+	for !p.step(0.01) {
+		elapsed += 0.01
+		peak = math.Max(peak, p.vel)
+		if elapsed > 5 {
+			t.Fatal("never arrived")
+		}
+	}
+	if p.pos != 120 {
+		t.Errorf("pos = %v, want 120", p.pos)
+	}
+	if peak > 100 {
+		t.Errorf("peak velocity %v over max", peak)
+	}
+	// 0.5s up, 0.5s down and 0.5s cruising
+	if elapsed < 1.4 || elapsed > 1.6 {
+		t.Errorf("took %.2fs, want about 1.5s", elapsed)
+	}
+
+	p = &profile{pos: 60, target: 50, vmax: 20}
+	p.step(0.1)
+	if p.pos != 58 {
+		t.Errorf("constant velocity: pos = %v, want 58", p.pos)
+	}
+}
+
+func TestMoveTo(t *testing.T) {
+	MotionTick = time.Millisecond
+	defer func() { MotionTick = 20 * time.Millisecond }()
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	r, _ := s.MoveTo(ctx, &MoveToRequest{Channel: 6, Angle: 60, MaxVelocity: 5000, Acceleration: 50000, Wait: true})
+	if !r.Ok {
+		t.Fatalf("MoveTo: %s", r.Err)
+	}
+	if a := angle(s, 6); a != 60 {
+		t.Errorf("angle = %v, want 60", a)
+	}
+	if r, _ := s.MoveTo(ctx, &MoveToRequest{Channel: 6, Angle: 90}); r.Ok {
+		t.Error("MoveTo past Max accepted")
+	}
+	if r, _ := s.MoveTo(ctx, &MoveToRequest{Channel: 6, Angle: 30, MaxVelocity: -1}); r.Ok {
+		t.Error("negative velocity accepted")
+	}
+}
+
+func TestMoveToStop(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	go func() {
+		time.Sleep(60 * time.Millisecond)
+		s.Stop(ctx, &StopRequest{Channel: 4})
+	}()
+	r, _ := s.MoveTo(ctx, &MoveToRequest{Channel: 4, Angle: 140, MaxVelocity: 10, Wait: true})
+	if r.Ok || r.Err != "stopped" {
+		t.Errorf("reply = %+v, want stopped", r)
+	}
+	if a := angle(s, 4); a >= 140 {
+		t.Errorf("angle = %v; stop didn't stop it", a)
+	}
+}
+
+func TestExecuteTrajectory(t *testing.T) {
+	MotionTick = time.Millisecond
+	defer func() { MotionTick = 20 * time.Millisecond }()
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	r, _ := s.ExecuteTrajectory(ctx, &TrajectoryRequest{Wait: true, Keyframes: []*Keyframe{
+		{AtMs: 20, Targets: []*ServoAngle{{Channel: 4, Angle: 30}, {Channel: 6, Angle: 20}}},
+		{AtMs: 40, Targets: []*ServoAngle{{Channel: 4, Angle: 120}}},
+	}})
+	if !r.Ok {
+		t.Fatalf("ExecuteTrajectory: %s", r.Err)
+	}
+	if a := angle(s, 4); a != 120 {
+		t.Errorf("ch4 = %v, want 120", a)
+	}
+	if a := angle(s, 6); a != 20 {
+		t.Errorf("ch6 = %v, want 20", a)
+	}
+	s.moverMu.Lock()
+	if len(s.movers) != 0 {
+		t.Errorf("movers left behind: %v", s.movers)
+	}
+	s.moverMu.Unlock()
+}
+
+func TestExecuteTrajectoryRejects(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+	before := angle(s, 4)
+
+	for name, kfs := range map[string][]*Keyframe{
+		"empty":        nil,
+		"out of range": {{AtMs: 10, Targets: []*ServoAngle{{Channel: 4, Angle: 50}}}, {AtMs: 20, Targets: []*ServoAngle{{Channel: 6, Angle: 100}}}},
+		"unordered":    {{AtMs: 20, Targets: []*ServoAngle{{Channel: 4, Angle: 50}}}, {AtMs: 20, Targets: []*ServoAngle{{Channel: 4, Angle: 60}}}},
+		"bad channel":  {{AtMs: 10, Targets: []*ServoAngle{{Channel: 3, Angle: 50}}}},
+	} {
+		if r, _ := s.ExecuteTrajectory(ctx, &TrajectoryRequest{Keyframes: kfs}); r.Ok {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+	if a := angle(s, 4); a != before {
+		t.Errorf("rejected trajectory moved ch4 to %v", a)
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	pts := []waypoint{{0, 10}, {1, 20}, {3, 0}}
+	for _, c := range []struct{ t, want float64 }{
+		{0, 10}, {0.5, 15}, {1, 20}, {2, 10}, {3, 0}, {9, 0},
+	} {
+		if got := interpolate(pts, c.t); math.Abs(got-c.want) > 1e-9 {
+			t.Errorf("interpolate(%v) = %v, want %v", c.t, got, c.want)
+		}
+	}
+}
+
+type fakeWatchStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent chan *ServoState
+}
+
+func (f *fakeWatchStream) Context() context.Context { return f.ctx }
+
+func (f *fakeWatchStream) Send(st *ServoState) error {
+	f.sent <- st
+	return nil
+}
+
+func watch(t *testing.T, s *server, rate float64) *fakeWatchStream {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	f := &fakeWatchStream{ctx: ctx, sent: make(chan *ServoState, 100)}
+	done := make(chan struct{})
+	go func() {
+		s.WatchState(&WatchStateRequest{MaxRateHz: rate}, f)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return f
+}
+
+// next waits for an update in which ch satisfies ok.
+func next(t *testing.T, f *fakeWatchStream, ch int32, ok func(*ChannelState) bool) *ChannelState {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case st := <-f.sent:
+			for _, c := range st.Channels {
+				if c.Channel == ch && ok(c) {
+					return c
+				}
+			}
+		case <-timeout:
+			t.Fatalf("channel %d never got there", ch)
+		}
+	}
+}
+
+func TestWatchState(t *testing.T) {
+	MotionTick = time.Millisecond
+	defer func() { MotionTick = 20 * time.Millisecond }()
+	s := newTestServer(t)
+	ctx := context.Background()
+	f := watch(t, s, 50)
+
+	first := <-f.sent
+	if len(first.Channels) != 2 || first.Channels[0].Channel != 4 || first.Channels[1].Channel != 6 {
+		t.Fatalf("first update %v", first.Channels)
+	}
+	if first.TimeMs == 0 {
+		t.Error("no timestamp")
+	}
+
+	s.SetAngle(ctx, &SetAngleRequest{Channel: 4, Angle: 30})
+	next(t, f, 4, func(c *ChannelState) bool { return c.Angle == 30 && c.Target == 30 && !c.Moving })
+
+	s.MoveTo(ctx, &MoveToRequest{Channel: 4, Angle: 140, MaxVelocity: 1000})
+	next(t, f, 4, func(c *ChannelState) bool { return c.Moving && c.Target == 140 })
+	c := next(t, f, 4, func(c *ChannelState) bool { return !c.Moving })
+	if c.Angle != 140 || c.AtLimit != Limit_LIMIT_MAX {
+		t.Errorf("after MoveTo: %v", c)
+	}
+
+	s.Move(ctx, &MoveRequest{Channel: 6, Direction: -1, Speed: 100})
+	next(t, f, 6, func(c *ChannelState) bool { return c.Moving && c.Target == 15 })
+	next(t, f, 6, func(c *ChannelState) bool {
+		return !c.Moving && c.Angle == 15 && c.AtLimit == Limit_LIMIT_MIN && c.Stopped == StopReason_STOP_LIMIT
+	})
+}
+
+func TestWatchStateRate(t *testing.T) {
+	MotionTick = time.Millisecond
+	defer func() { MotionTick = 20 * time.Millisecond }()
+	s := newTestServer(t)
+	f := watch(t, s, 10)
+	<-f.sent
+
+	s.MoveTo(context.Background(), &MoveToRequest{Channel: 4, Angle: 15, MaxVelocity: 100})
+	time.Sleep(350 * time.Millisecond)
+	if n := len(f.sent); n < 2 || n > 4 {
+		t.Errorf("%d updates in 350ms at 10 Hz", n)
+	}
+
+	// nothing changing, nothing sent
+	s.Stop(context.Background(), &StopRequest{Channel: 4})
+	time.Sleep(150 * time.Millisecond)
+	for len(f.sent) > 0 {
+		<-f.sent
+	}
+	time.Sleep(200 * time.Millisecond)
+	if n := len(f.sent); n != 0 {
+		t.Errorf("%d updates while idle", n)
+	}
+}
+
+func stoppedFor(s *server, ch int) (StopReason, bool) {
+	s.moverMu.Lock()
+	defer s.moverMu.Unlock()
+	_, moving := s.movers[ch]
+	return s.stopped[ch], moving
+}
+
+func TestMoveStopsAtLimit(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	s.SetAngle(ctx, &SetAngleRequest{Channel: 6, Angle: 60})
+	s.Move(ctx, &MoveRequest{Channel: 6, Direction: 1, Speed: 1000})
+	time.Sleep(150 * time.Millisecond)
+	if why, moving := stoppedFor(s, 6); moving || why != StopReason_STOP_LIMIT {
+		t.Errorf("moving %v, stopped %v", moving, why)
+	}
+	if a := angle(s, 6); a != 68 {
+		t.Errorf("angle %v, want Max", a)
+	}
+
+	// a new move clears the reason
+	s.Move(ctx, &MoveRequest{Channel: 6, Direction: -1, Speed: 1})
+	if why, moving := stoppedFor(s, 6); !moving || why != StopReason_STOP_NONE {
+		t.Errorf("moving %v, stopped %v", moving, why)
+	}
+	s.Stop(ctx, &StopRequest{Channel: 6})
+	if why, _ := stoppedFor(s, 6); why != StopReason_STOP_REQUESTED {
+		t.Errorf("stopped %v", why)
+	}
+}
+
+func TestMoveMaxDuration(t *testing.T) {
+	defer func(d time.Duration) { MaxMoveDuration = d }(MaxMoveDuration)
+	MaxMoveDuration = 300 * time.Millisecond
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	s.Move(ctx, &MoveRequest{Channel: 4, Direction: 1, Speed: 1, MaxDurationMs: 100})
+	s.Move(ctx, &MoveRequest{Channel: 6, Direction: 1, Speed: 1, MaxDurationMs: 60000})
+	time.Sleep(200 * time.Millisecond)
+	if why, moving := stoppedFor(s, 4); moving || why != StopReason_STOP_TIMEOUT {
+		t.Errorf("ch4 moving %v, stopped %v", moving, why)
+	}
+	if _, moving := stoppedFor(s, 6); !moving {
+		t.Error("ch6 stopped early")
+	}
+	time.Sleep(200 * time.Millisecond)
+	if why, moving := stoppedFor(s, 6); moving || why != StopReason_STOP_TIMEOUT {
+		t.Errorf("ch6 ran past MaxMoveDuration: moving %v, stopped %v", moving, why)
+	}
+}
+
+func TestMoveLease(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	s.Move(ctx, &MoveRequest{Channel: 4, Direction: 1, Speed: 1, LeaseMs: 150})
+	s.Move(ctx, &MoveRequest{Channel: 6, Direction: 1, Speed: 1})
+	for i := 0; i < 4; i++ {
+		time.Sleep(80 * time.Millisecond)
+		if r, _ := s.RenewMove(ctx, &RenewMoveRequest{Channels: []int32{4}}); len(r.NotMoving) != 0 {
+			t.Fatalf("lease lapsed while renewed: %v", r.NotMoving)
+		}
+	}
+	time.Sleep(300 * time.Millisecond)
+	if why, moving := stoppedFor(s, 4); moving || why != StopReason_STOP_LEASE_EXPIRED {
+		t.Errorf("moving %v, stopped %v", moving, why)
+	}
+	r, _ := s.RenewMove(ctx, &RenewMoveRequest{Channels: []int32{4, 6}})
+	if len(r.NotMoving) != 2 {
+		t.Errorf("not moving %v; ch6 has no lease to renew", r.NotMoving)
+	}
+	if _, moving := stoppedFor(s, 6); !moving {
+		t.Error("unleased move stopped")
+	}
+	s.Stop(ctx, &StopRequest{Channel: 6})
+}
+
+func TestEmergencyStop(t *testing.T) {
+	s := newTestServer(t)
+	ctx := context.Background()
+
+	s.Move(ctx, &MoveRequest{Channel: 4, Direction: 1, Speed: 1})
+	s.ExecuteTrajectory(ctx, &TrajectoryRequest{Keyframes: []*Keyframe{
+		{AtMs: 5000, Targets: []*ServoAngle{{Channel: 6, Angle: 60}}},
+	}})
+	if r, _ := s.EmergencyStop(ctx, &EmergencyStopRequest{CutPwm: true}); !r.Ok {
+		t.Fatalf("EmergencyStop: %s", r.Err)
+	}
+	for _, ch := range []int{4, 6} {
+		if why, moving := stoppedFor(s, ch); moving || why != StopReason_STOP_EMERGENCY {
+			t.Errorf("ch%d moving %v, stopped %v", ch, moving, why)
+		}
+	}
+	if r, _ := s.SetAngle(ctx, &SetAngleRequest{Channel: 4, Angle: 90}); !r.Ok {
+		t.Errorf("SetAngle after emergency stop: %s", r.Err)
+	}
+}
+
+func TestEmergencyStopCutStaysCut(t *testing.T) {
+	MotionTick = time.Millisecond
+	defer func() { MotionTick = 20 * time.Millisecond }()
+	buses := fakeBuses{1: {}}
+	s, err := NewServer(buses.open, Calibrations{4: cal50to650, 6: cal50to650})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	s.Move(ctx, &MoveRequest{Channel: 4, Direction: 1, Speed: 1})
+	s.ExecuteTrajectory(ctx, &TrajectoryRequest{Keyframes: []*Keyframe{
+		{AtMs: 5000, Targets: []*ServoAngle{{Channel: 6, Angle: 170}}},
+	}})
+	time.Sleep(60 * time.Millisecond)
+	if r, _ := s.EmergencyStop(ctx, &EmergencyStopRequest{CutPwm: true}); !r.Ok {
+		t.Fatalf("EmergencyStop: %s", r.Err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	b := buses[1]
+	if b.pwm(0x40, 4) != 0 || b.pwm(0x40, 6) != 0 {
+		t.Errorf("outputs powered again after the cut: %d %d", b.pwm(0x40, 4), b.pwm(0x40, 6))
+	}
+}
+
+func TestMoveNeedsSpeed(t *testing.T) {
+	s := newTestServer(t)
+	for _, speed := range []float64{0, -30} {
+		if r, _ := s.Move(context.Background(), &MoveRequest{Channel: 4, Direction: 1, Speed: speed}); r.Ok {
+			t.Errorf("Move at speed %v accepted", speed)
+		}
+	}
+}
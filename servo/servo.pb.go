@@ -21,11 +21,123 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type Limit int32
+
+const (
+	Limit_LIMIT_NONE Limit = 0
+	Limit_LIMIT_MIN  Limit = 1 // sitting on the channel's Min
+	Limit_LIMIT_MAX  Limit = 2 // sitting on the channel's Max
+)
+
+// Enum value maps for Limit.
+var (
+	Limit_name = map[int32]string{
+		0: "LIMIT_NONE",
+		1: "LIMIT_MIN",
+		2: "LIMIT_MAX",
+	}
+	Limit_value = map[string]int32{
+		"LIMIT_NONE": 0,
+		"LIMIT_MIN":  1,
+		"LIMIT_MAX":  2,
+	}
+)
+
+func (x Limit) Enum() *Limit {
+	p := new(Limit)
+	*p = x
+	return p
+}
+
+func (x Limit) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Limit) Descriptor() protoreflect.EnumDescriptor {
+	return file_servo_proto_enumTypes[0].Descriptor()
+}
+
+func (Limit) Type() protoreflect.EnumType {
+	return &file_servo_proto_enumTypes[0]
+}
+
+func (x Limit) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Limit.Descriptor instead.
+func (Limit) EnumDescriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{0}
+}
+
+type StopReason int32
+
+const (
+	StopReason_STOP_NONE          StopReason = 0
+	StopReason_STOP_REQUESTED     StopReason = 1 // Stop
+	StopReason_STOP_LIMIT         StopReason = 2 // a Move reached Min or Max
+	StopReason_STOP_TIMEOUT       StopReason = 3 // a Move ran for its maximum duration
+	StopReason_STOP_LEASE_EXPIRED StopReason = 4 // a leased Move wasn't renewed in time
+	StopReason_STOP_EMERGENCY     StopReason = 5 // EmergencyStop
+)
+
+// Enum value maps for StopReason.
+var (
+	StopReason_name = map[int32]string{
+		0: "STOP_NONE",
+		1: "STOP_REQUESTED",
+		2: "STOP_LIMIT",
+		3: "STOP_TIMEOUT",
+		4: "STOP_LEASE_EXPIRED",
+		5: "STOP_EMERGENCY",
+	}
+	StopReason_value = map[string]int32{
+		"STOP_NONE":          0,
+		"STOP_REQUESTED":     1,
+		"STOP_LIMIT":         2,
+		"STOP_TIMEOUT":       3,
+		"STOP_LEASE_EXPIRED": 4,
+		"STOP_EMERGENCY":     5,
+	}
+)
+
+func (x StopReason) Enum() *StopReason {
+	p := new(StopReason)
+	*p = x
+	return p
+}
+
+func (x StopReason) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (StopReason) Descriptor() protoreflect.EnumDescriptor {
+	return file_servo_proto_enumTypes[1].Descriptor()
+}
+
+func (StopReason) Type() protoreflect.EnumType {
+	return &file_servo_proto_enumTypes[1]
+}
+
+func (x StopReason) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use StopReason.Descriptor instead.
+func (StopReason) EnumDescriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{1}
+}
+
+// Move jogs a channel until Stop, its limit, max_duration_ms (capped by the
+// server), or, with a lease, until the caller stops renewing it.
 type MoveRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Channel       int32                  `protobuf:"varint,1,opt,name=channel,proto3" json:"channel,omitempty"`
-	Direction     int32                  `protobuf:"varint,2,opt,name=direction,proto3" json:"direction,omitempty"` // +1 or -1
-	Speed         float64                `protobuf:"fixed64,3,opt,name=speed,proto3" json:"speed,omitempty"`        // degrees/sec
+	Direction     int32                  `protobuf:"varint,2,opt,name=direction,proto3" json:"direction,omitempty"`                                // +1 or -1
+	Speed         float64                `protobuf:"fixed64,3,opt,name=speed,proto3" json:"speed,omitempty"`                                       // degrees/sec
+	MaxDurationMs uint32                 `protobuf:"varint,4,opt,name=max_duration_ms,json=maxDurationMs,proto3" json:"max_duration_ms,omitempty"` // 0 for the server's maximum
+	LeaseMs       uint32                 `protobuf:"varint,5,opt,name=lease_ms,json=leaseMs,proto3" json:"lease_ms,omitempty"`                     // stop unless RenewMove comes this often; 0 for no lease
+	Name          string                 `protobuf:"bytes,6,opt,name=name,proto3" json:"name,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -81,10 +193,32 @@ func (x *MoveRequest) GetSpeed() float64 {
 	return 0
 }
 
+func (x *MoveRequest) GetMaxDurationMs() uint32 {
+	if x != nil {
+		return x.MaxDurationMs
+	}
+	return 0
+}
+
+func (x *MoveRequest) GetLeaseMs() uint32 {
+	if x != nil {
+		return x.LeaseMs
+	}
+	return 0
+}
+
+func (x *MoveRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
 type MoveReply struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
 	Err           string                 `protobuf:"bytes,2,opt,name=err,proto3" json:"err,omitempty"`
+	Channel       int32                  `protobuf:"varint,3,opt,name=channel,proto3" json:"channel,omitempty"` // the channel moving, for RenewMove
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -133,9 +267,17 @@ func (x *MoveReply) GetErr() string {
 	return ""
 }
 
+func (x *MoveReply) GetChannel() int32 {
+	if x != nil {
+		return x.Channel
+	}
+	return 0
+}
+
 type StopRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Channel       int32                  `protobuf:"varint,1,opt,name=channel,proto3" json:"channel,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -177,6 +319,13 @@ func (x *StopRequest) GetChannel() int32 {
 	return 0
 }
 
+func (x *StopRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
 type StopReply struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
@@ -269,6 +418,7 @@ type ServoAngle struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Channel       int32                  `protobuf:"varint,1,opt,name=channel,proto3" json:"channel,omitempty"`
 	Angle         float32                `protobuf:"fixed32,2,opt,name=angle,proto3" json:"angle,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -317,6 +467,13 @@ func (x *ServoAngle) GetAngle() float32 {
 	return 0
 }
 
+func (x *ServoAngle) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
 type GetAnglesReply struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Angles        []*ServoAngle          `protobuf:"bytes,1,rep,name=angles,proto3" json:"angles,omitempty"`
@@ -361,35 +518,2521 @@ func (x *GetAnglesReply) GetAngles() []*ServoAngle {
 	return nil
 }
 
-var File_servo_proto protoreflect.FileDescriptor
+// Absolute positioning. Angles outside a channel's Min/Max are refused, and
+// each of these cancels whatever motion the channel was already making.
+type SetAngleRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Channel       int32                  `protobuf:"varint,1,opt,name=channel,proto3" json:"channel,omitempty"`
+	Angle         float64                `protobuf:"fixed64,2,opt,name=angle,proto3" json:"angle,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
 
-const file_servo_proto_rawDesc = "" +
-	"\n" +
-	"\vservo.proto\x12\x05servo\"[\n" +
-	"\vMoveRequest\x12\x18\n" +
-	"\achannel\x18\x01 \x01(\x05R\achannel\x12\x1c\n" +
-	"\tdirection\x18\x02 \x01(\x05R\tdirection\x12\x14\n" +
-	"\x05speed\x18\x03 \x01(\x01R\x05speed\"-\n" +
-	"\tMoveReply\x12\x0e\n" +
-	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x10\n" +
-	"\x03err\x18\x02 \x01(\tR\x03err\"'\n" +
-	"\vStopRequest\x12\x18\n" +
-	"\achannel\x18\x01 \x01(\x05R\achannel\"-\n" +
-	"\tStopReply\x12\x0e\n" +
-	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x10\n" +
-	"\x03err\x18\x02 \x01(\tR\x03err\"\x12\n" +
-	"\x10GetAnglesRequest\"<\n" +
-	"\n" +
-	"ServoAngle\x12\x18\n" +
-	"\achannel\x18\x01 \x01(\x05R\achannel\x12\x14\n" +
-	"\x05angle\x18\x02 \x01(\x02R\x05angle\";\n" +
-	"\x0eGetAnglesReply\x12)\n" +
-	"\x06angles\x18\x01 \x03(\v2\x11.servo.ServoAngleR\x06angles2\xa5\x01\n" +
-	"\n" +
-	"Controller\x12,\n" +
-	"\x04Move\x12\x12.servo.MoveRequest\x1a\x10.servo.MoveReply\x12,\n" +
-	"\x04Stop\x12\x12.servo.StopRequest\x1a\x10.servo.StopReply\x12;\n" +
-	"\tGetAngles\x12\x17.servo.GetAnglesRequest\x1a\x15.servo.GetAnglesReplyB-Z+github.com/n0remac/robot-webrtc/servo;servob\x06proto3"
+func (x *SetAngleRequest) Reset() {
+	*x = SetAngleRequest{}
+	mi := &file_servo_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetAngleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetAngleRequest) ProtoMessage() {}
+
+func (x *SetAngleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetAngleRequest.ProtoReflect.Descriptor instead.
+func (*SetAngleRequest) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SetAngleRequest) GetChannel() int32 {
+	if x != nil {
+		return x.Channel
+	}
+	return 0
+}
+
+func (x *SetAngleRequest) GetAngle() float64 {
+	if x != nil {
+		return x.Angle
+	}
+	return 0
+}
+
+func (x *SetAngleRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type SetAngleReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Err           string                 `protobuf:"bytes,2,opt,name=err,proto3" json:"err,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetAngleReply) Reset() {
+	*x = SetAngleReply{}
+	mi := &file_servo_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetAngleReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetAngleReply) ProtoMessage() {}
+
+func (x *SetAngleReply) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetAngleReply.ProtoReflect.Descriptor instead.
+func (*SetAngleReply) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SetAngleReply) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *SetAngleReply) GetErr() string {
+	if x != nil {
+		return x.Err
+	}
+	return ""
+}
+
+type MoveToRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Channel       int32                  `protobuf:"varint,1,opt,name=channel,proto3" json:"channel,omitempty"`
+	Angle         float64                `protobuf:"fixed64,2,opt,name=angle,proto3" json:"angle,omitempty"`
+	MaxVelocity   float64                `protobuf:"fixed64,3,opt,name=max_velocity,json=maxVelocity,proto3" json:"max_velocity,omitempty"` // degrees/sec, 0 for the default
+	Acceleration  float64                `protobuf:"fixed64,4,opt,name=acceleration,proto3" json:"acceleration,omitempty"`                  // degrees/sec², 0 to start and stop at full speed
+	Wait          bool                   `protobuf:"varint,5,opt,name=wait,proto3" json:"wait,omitempty"`                                   // reply once the servo gets there
+	Name          string                 `protobuf:"bytes,6,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MoveToRequest) Reset() {
+	*x = MoveToRequest{}
+	mi := &file_servo_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MoveToRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MoveToRequest) ProtoMessage() {}
+
+func (x *MoveToRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MoveToRequest.ProtoReflect.Descriptor instead.
+func (*MoveToRequest) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *MoveToRequest) GetChannel() int32 {
+	if x != nil {
+		return x.Channel
+	}
+	return 0
+}
+
+func (x *MoveToRequest) GetAngle() float64 {
+	if x != nil {
+		return x.Angle
+	}
+	return 0
+}
+
+func (x *MoveToRequest) GetMaxVelocity() float64 {
+	if x != nil {
+		return x.MaxVelocity
+	}
+	return 0
+}
+
+func (x *MoveToRequest) GetAcceleration() float64 {
+	if x != nil {
+		return x.Acceleration
+	}
+	return 0
+}
+
+func (x *MoveToRequest) GetWait() bool {
+	if x != nil {
+		return x.Wait
+	}
+	return false
+}
+
+func (x *MoveToRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type MoveToReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Err           string                 `protobuf:"bytes,2,opt,name=err,proto3" json:"err,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MoveToReply) Reset() {
+	*x = MoveToReply{}
+	mi := &file_servo_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MoveToReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MoveToReply) ProtoMessage() {}
+
+func (x *MoveToReply) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MoveToReply.ProtoReflect.Descriptor instead.
+func (*MoveToReply) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *MoveToReply) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *MoveToReply) GetErr() string {
+	if x != nil {
+		return x.Err
+	}
+	return ""
+}
+
+// A keyframe sets some channels' angles at_ms after the trajectory starts.
+// Between keyframes each channel moves linearly from its previous angle,
+// starting from where it is now.
+type Keyframe struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AtMs          uint32                 `protobuf:"varint,1,opt,name=at_ms,json=atMs,proto3" json:"at_ms,omitempty"`
+	Targets       []*ServoAngle          `protobuf:"bytes,2,rep,name=targets,proto3" json:"targets,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Keyframe) Reset() {
+	*x = Keyframe{}
+	mi := &file_servo_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Keyframe) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Keyframe) ProtoMessage() {}
+
+func (x *Keyframe) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Keyframe.ProtoReflect.Descriptor instead.
+func (*Keyframe) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Keyframe) GetAtMs() uint32 {
+	if x != nil {
+		return x.AtMs
+	}
+	return 0
+}
+
+func (x *Keyframe) GetTargets() []*ServoAngle {
+	if x != nil {
+		return x.Targets
+	}
+	return nil
+}
+
+type TrajectoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Keyframes     []*Keyframe            `protobuf:"bytes,1,rep,name=keyframes,proto3" json:"keyframes,omitempty"`
+	Wait          bool                   `protobuf:"varint,2,opt,name=wait,proto3" json:"wait,omitempty"` // reply once the last keyframe is reached
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TrajectoryRequest) Reset() {
+	*x = TrajectoryRequest{}
+	mi := &file_servo_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TrajectoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrajectoryRequest) ProtoMessage() {}
+
+func (x *TrajectoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrajectoryRequest.ProtoReflect.Descriptor instead.
+func (*TrajectoryRequest) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *TrajectoryRequest) GetKeyframes() []*Keyframe {
+	if x != nil {
+		return x.Keyframes
+	}
+	return nil
+}
+
+func (x *TrajectoryRequest) GetWait() bool {
+	if x != nil {
+		return x.Wait
+	}
+	return false
+}
+
+type TrajectoryReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Err           string                 `protobuf:"bytes,2,opt,name=err,proto3" json:"err,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TrajectoryReply) Reset() {
+	*x = TrajectoryReply{}
+	mi := &file_servo_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TrajectoryReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrajectoryReply) ProtoMessage() {}
+
+func (x *TrajectoryReply) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrajectoryReply.ProtoReflect.Descriptor instead.
+func (*TrajectoryReply) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *TrajectoryReply) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *TrajectoryReply) GetErr() string {
+	if x != nil {
+		return x.Err
+	}
+	return ""
+}
+
+// WatchState sends every channel's state straight away and again whenever
+// anything changes, at most max_rate_hz times a second.
+type WatchStateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MaxRateHz     float64                `protobuf:"fixed64,1,opt,name=max_rate_hz,json=maxRateHz,proto3" json:"max_rate_hz,omitempty"` // 0 for the server's default
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchStateRequest) Reset() {
+	*x = WatchStateRequest{}
+	mi := &file_servo_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchStateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchStateRequest) ProtoMessage() {}
+
+func (x *WatchStateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchStateRequest.ProtoReflect.Descriptor instead.
+func (*WatchStateRequest) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *WatchStateRequest) GetMaxRateHz() float64 {
+	if x != nil {
+		return x.MaxRateHz
+	}
+	return 0
+}
+
+type ChannelState struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Channel       int32                  `protobuf:"varint,1,opt,name=channel,proto3" json:"channel,omitempty"`
+	Angle         float64                `protobuf:"fixed64,2,opt,name=angle,proto3" json:"angle,omitempty"`
+	Target        float64                `protobuf:"fixed64,3,opt,name=target,proto3" json:"target,omitempty"` // where the current motion is headed; angle when idle
+	Moving        bool                   `protobuf:"varint,4,opt,name=moving,proto3" json:"moving,omitempty"`
+	AtLimit       Limit                  `protobuf:"varint,5,opt,name=at_limit,json=atLimit,proto3,enum=servo.Limit" json:"at_limit,omitempty"`
+	Stopped       StopReason             `protobuf:"varint,6,opt,name=stopped,proto3,enum=servo.StopReason" json:"stopped,omitempty"` // why its last motion ended, until the next starts
+	Name          string                 `protobuf:"bytes,7,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChannelState) Reset() {
+	*x = ChannelState{}
+	mi := &file_servo_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChannelState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChannelState) ProtoMessage() {}
+
+func (x *ChannelState) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChannelState.ProtoReflect.Descriptor instead.
+func (*ChannelState) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ChannelState) GetChannel() int32 {
+	if x != nil {
+		return x.Channel
+	}
+	return 0
+}
+
+func (x *ChannelState) GetAngle() float64 {
+	if x != nil {
+		return x.Angle
+	}
+	return 0
+}
+
+func (x *ChannelState) GetTarget() float64 {
+	if x != nil {
+		return x.Target
+	}
+	return 0
+}
+
+func (x *ChannelState) GetMoving() bool {
+	if x != nil {
+		return x.Moving
+	}
+	return false
+}
+
+func (x *ChannelState) GetAtLimit() Limit {
+	if x != nil {
+		return x.AtLimit
+	}
+	return Limit_LIMIT_NONE
+}
+
+func (x *ChannelState) GetStopped() StopReason {
+	if x != nil {
+		return x.Stopped
+	}
+	return StopReason_STOP_NONE
+}
+
+func (x *ChannelState) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type ServoState struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Channels      []*ChannelState        `protobuf:"bytes,1,rep,name=channels,proto3" json:"channels,omitempty"`            // sorted by channel
+	TimeMs        int64                  `protobuf:"varint,2,opt,name=time_ms,json=timeMs,proto3" json:"time_ms,omitempty"` // unix ms
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ServoState) Reset() {
+	*x = ServoState{}
+	mi := &file_servo_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServoState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServoState) ProtoMessage() {}
+
+func (x *ServoState) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServoState.ProtoReflect.Descriptor instead.
+func (*ServoState) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ServoState) GetChannels() []*ChannelState {
+	if x != nil {
+		return x.Channels
+	}
+	return nil
+}
+
+func (x *ServoState) GetTimeMs() int64 {
+	if x != nil {
+		return x.TimeMs
+	}
+	return 0
+}
+
+// A pose is a named set of channel angles, kept by the server in a JSON
+// file so it survives restarts.
+type Pose struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Angles        []*ServoAngle          `protobuf:"bytes,2,rep,name=angles,proto3" json:"angles,omitempty"` // sorted by channel
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Pose) Reset() {
+	*x = Pose{}
+	mi := &file_servo_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Pose) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Pose) ProtoMessage() {}
+
+func (x *Pose) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Pose.ProtoReflect.Descriptor instead.
+func (*Pose) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *Pose) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Pose) GetAngles() []*ServoAngle {
+	if x != nil {
+		return x.Angles
+	}
+	return nil
+}
+
+// SavePose stores the channels' current angles under name, replacing any
+// pose already called that.
+type SavePoseRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Channels      []int32                `protobuf:"varint,2,rep,packed,name=channels,proto3" json:"channels,omitempty"` // empty, with no names, for every channel
+	Names         []string               `protobuf:"bytes,3,rep,name=names,proto3" json:"names,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SavePoseRequest) Reset() {
+	*x = SavePoseRequest{}
+	mi := &file_servo_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SavePoseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SavePoseRequest) ProtoMessage() {}
+
+func (x *SavePoseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SavePoseRequest.ProtoReflect.Descriptor instead.
+func (*SavePoseRequest) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *SavePoseRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SavePoseRequest) GetChannels() []int32 {
+	if x != nil {
+		return x.Channels
+	}
+	return nil
+}
+
+func (x *SavePoseRequest) GetNames() []string {
+	if x != nil {
+		return x.Names
+	}
+	return nil
+}
+
+type SavePoseReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Err           string                 `protobuf:"bytes,2,opt,name=err,proto3" json:"err,omitempty"`
+	Pose          *Pose                  `protobuf:"bytes,3,opt,name=pose,proto3" json:"pose,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SavePoseReply) Reset() {
+	*x = SavePoseReply{}
+	mi := &file_servo_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SavePoseReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SavePoseReply) ProtoMessage() {}
+
+func (x *SavePoseReply) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SavePoseReply.ProtoReflect.Descriptor instead.
+func (*SavePoseReply) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *SavePoseReply) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *SavePoseReply) GetErr() string {
+	if x != nil {
+		return x.Err
+	}
+	return ""
+}
+
+func (x *SavePoseReply) GetPose() *Pose {
+	if x != nil {
+		return x.Pose
+	}
+	return nil
+}
+
+type ListPosesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPosesRequest) Reset() {
+	*x = ListPosesRequest{}
+	mi := &file_servo_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPosesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPosesRequest) ProtoMessage() {}
+
+func (x *ListPosesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPosesRequest.ProtoReflect.Descriptor instead.
+func (*ListPosesRequest) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{20}
+}
+
+type ListPosesReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Poses         []*Pose                `protobuf:"bytes,1,rep,name=poses,proto3" json:"poses,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPosesReply) Reset() {
+	*x = ListPosesReply{}
+	mi := &file_servo_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPosesReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPosesReply) ProtoMessage() {}
+
+func (x *ListPosesReply) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPosesReply.ProtoReflect.Descriptor instead.
+func (*ListPosesReply) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ListPosesReply) GetPoses() []*Pose {
+	if x != nil {
+		return x.Poses
+	}
+	return nil
+}
+
+type DeletePoseRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeletePoseRequest) Reset() {
+	*x = DeletePoseRequest{}
+	mi := &file_servo_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeletePoseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeletePoseRequest) ProtoMessage() {}
+
+func (x *DeletePoseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeletePoseRequest.ProtoReflect.Descriptor instead.
+func (*DeletePoseRequest) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *DeletePoseRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type DeletePoseReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Err           string                 `protobuf:"bytes,2,opt,name=err,proto3" json:"err,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeletePoseReply) Reset() {
+	*x = DeletePoseReply{}
+	mi := &file_servo_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeletePoseReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeletePoseReply) ProtoMessage() {}
+
+func (x *DeletePoseReply) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeletePoseReply.ProtoReflect.Descriptor instead.
+func (*DeletePoseReply) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *DeletePoseReply) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *DeletePoseReply) GetErr() string {
+	if x != nil {
+		return x.Err
+	}
+	return ""
+}
+
+// MoveToPose moves every channel in the pose at once, timed so they all
+// arrive together: over duration_ms, or as fast as the channel with the
+// furthest to go can manage at max_velocity.
+type MoveToPoseRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	DurationMs    uint32                 `protobuf:"varint,2,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`     // 0 to go by max_velocity
+	MaxVelocity   float64                `protobuf:"fixed64,3,opt,name=max_velocity,json=maxVelocity,proto3" json:"max_velocity,omitempty"` // degrees/sec, 0 for the default
+	Wait          bool                   `protobuf:"varint,4,opt,name=wait,proto3" json:"wait,omitempty"`                                   // reply once the pose is reached
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MoveToPoseRequest) Reset() {
+	*x = MoveToPoseRequest{}
+	mi := &file_servo_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MoveToPoseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MoveToPoseRequest) ProtoMessage() {}
+
+func (x *MoveToPoseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MoveToPoseRequest.ProtoReflect.Descriptor instead.
+func (*MoveToPoseRequest) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *MoveToPoseRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *MoveToPoseRequest) GetDurationMs() uint32 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *MoveToPoseRequest) GetMaxVelocity() float64 {
+	if x != nil {
+		return x.MaxVelocity
+	}
+	return 0
+}
+
+func (x *MoveToPoseRequest) GetWait() bool {
+	if x != nil {
+		return x.Wait
+	}
+	return false
+}
+
+type MoveToPoseReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Err           string                 `protobuf:"bytes,2,opt,name=err,proto3" json:"err,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MoveToPoseReply) Reset() {
+	*x = MoveToPoseReply{}
+	mi := &file_servo_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MoveToPoseReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MoveToPoseReply) ProtoMessage() {}
+
+func (x *MoveToPoseReply) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MoveToPoseReply.ProtoReflect.Descriptor instead.
+func (*MoveToPoseReply) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *MoveToPoseReply) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *MoveToPoseReply) GetErr() string {
+	if x != nil {
+		return x.Err
+	}
+	return ""
+}
+
+// Per-channel calibration and wiring, as kept in the server's config file.
+// A channel with no bus or address is output <channel> of the PCA9685 at
+// 0x40 on bus 1.
+type ChannelConfig struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Channel       int32                  `protobuf:"varint,1,opt,name=channel,proto3" json:"channel,omitempty"`
+	MinAngle      float64                `protobuf:"fixed64,2,opt,name=min_angle,json=minAngle,proto3" json:"min_angle,omitempty"`
+	MaxAngle      float64                `protobuf:"fixed64,3,opt,name=max_angle,json=maxAngle,proto3" json:"max_angle,omitempty"`
+	Home          float64                `protobuf:"fixed64,4,opt,name=home,proto3" json:"home,omitempty"`                        // where the server puts it on start-up
+	MinPulse      uint32                 `protobuf:"varint,5,opt,name=min_pulse,json=minPulse,proto3" json:"min_pulse,omitempty"` // PCA9685 counts at 0°
+	MaxPulse      uint32                 `protobuf:"varint,6,opt,name=max_pulse,json=maxPulse,proto3" json:"max_pulse,omitempty"` // PCA9685 counts at 180°
+	Invert        bool                   `protobuf:"varint,7,opt,name=invert,proto3" json:"invert,omitempty"`
+	Trim          float64                `protobuf:"fixed64,8,opt,name=trim,proto3" json:"trim,omitempty"` // degrees added before the pulse is worked out
+	Name          string                 `protobuf:"bytes,9,opt,name=name,proto3" json:"name,omitempty"`
+	Bus           int32                  `protobuf:"varint,10,opt,name=bus,proto3" json:"bus,omitempty"`         // I²C bus number, 0 for bus 1
+	Address       uint32                 `protobuf:"varint,11,opt,name=address,proto3" json:"address,omitempty"` // board's I²C address, 0 for 0x40
+	Output        int32                  `protobuf:"varint,12,opt,name=output,proto3" json:"output,omitempty"`   // 0–15 on that board; used with bus or address
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChannelConfig) Reset() {
+	*x = ChannelConfig{}
+	mi := &file_servo_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChannelConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChannelConfig) ProtoMessage() {}
+
+func (x *ChannelConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChannelConfig.ProtoReflect.Descriptor instead.
+func (*ChannelConfig) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *ChannelConfig) GetChannel() int32 {
+	if x != nil {
+		return x.Channel
+	}
+	return 0
+}
+
+func (x *ChannelConfig) GetMinAngle() float64 {
+	if x != nil {
+		return x.MinAngle
+	}
+	return 0
+}
+
+func (x *ChannelConfig) GetMaxAngle() float64 {
+	if x != nil {
+		return x.MaxAngle
+	}
+	return 0
+}
+
+func (x *ChannelConfig) GetHome() float64 {
+	if x != nil {
+		return x.Home
+	}
+	return 0
+}
+
+func (x *ChannelConfig) GetMinPulse() uint32 {
+	if x != nil {
+		return x.MinPulse
+	}
+	return 0
+}
+
+func (x *ChannelConfig) GetMaxPulse() uint32 {
+	if x != nil {
+		return x.MaxPulse
+	}
+	return 0
+}
+
+func (x *ChannelConfig) GetInvert() bool {
+	if x != nil {
+		return x.Invert
+	}
+	return false
+}
+
+func (x *ChannelConfig) GetTrim() float64 {
+	if x != nil {
+		return x.Trim
+	}
+	return 0
+}
+
+func (x *ChannelConfig) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ChannelConfig) GetBus() int32 {
+	if x != nil {
+		return x.Bus
+	}
+	return 0
+}
+
+func (x *ChannelConfig) GetAddress() uint32 {
+	if x != nil {
+		return x.Address
+	}
+	return 0
+}
+
+func (x *ChannelConfig) GetOutput() int32 {
+	if x != nil {
+		return x.Output
+	}
+	return 0
+}
+
+type GetConfigRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetConfigRequest) Reset() {
+	*x = GetConfigRequest{}
+	mi := &file_servo_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetConfigRequest) ProtoMessage() {}
+
+func (x *GetConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetConfigRequest.ProtoReflect.Descriptor instead.
+func (*GetConfigRequest) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{27}
+}
+
+type GetConfigReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Channels      []*ChannelConfig       `protobuf:"bytes,1,rep,name=channels,proto3" json:"channels,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetConfigReply) Reset() {
+	*x = GetConfigReply{}
+	mi := &file_servo_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetConfigReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetConfigReply) ProtoMessage() {}
+
+func (x *GetConfigReply) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetConfigReply.ProtoReflect.Descriptor instead.
+func (*GetConfigReply) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *GetConfigReply) GetChannels() []*ChannelConfig {
+	if x != nil {
+		return x.Channels
+	}
+	return nil
+}
+
+// SetConfig replaces the listed channels' calibration, adding any that are
+// new, and saves the config file unless temporary. Channels are matched by
+// number here; name is just another setting. Nothing changes unless every
+// channel is valid. Channels already moving stop, and any sitting outside
+// their new limits move inside them.
+type SetConfigRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Channels      []*ChannelConfig       `protobuf:"bytes,1,rep,name=channels,proto3" json:"channels,omitempty"`
+	GoHome        bool                   `protobuf:"varint,2,opt,name=go_home,json=goHome,proto3" json:"go_home,omitempty"` // then move the listed channels home
+	Temporary     bool                   `protobuf:"varint,3,opt,name=temporary,proto3" json:"temporary,omitempty"`         // apply without saving to the config file
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetConfigRequest) Reset() {
+	*x = SetConfigRequest{}
+	mi := &file_servo_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetConfigRequest) ProtoMessage() {}
+
+func (x *SetConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetConfigRequest.ProtoReflect.Descriptor instead.
+func (*SetConfigRequest) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *SetConfigRequest) GetChannels() []*ChannelConfig {
+	if x != nil {
+		return x.Channels
+	}
+	return nil
+}
+
+func (x *SetConfigRequest) GetGoHome() bool {
+	if x != nil {
+		return x.GoHome
+	}
+	return false
+}
+
+func (x *SetConfigRequest) GetTemporary() bool {
+	if x != nil {
+		return x.Temporary
+	}
+	return false
+}
+
+type SetConfigReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Err           string                 `protobuf:"bytes,2,opt,name=err,proto3" json:"err,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetConfigReply) Reset() {
+	*x = SetConfigReply{}
+	mi := &file_servo_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetConfigReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetConfigReply) ProtoMessage() {}
+
+func (x *SetConfigReply) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetConfigReply.ProtoReflect.Descriptor instead.
+func (*SetConfigReply) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *SetConfigReply) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *SetConfigReply) GetErr() string {
+	if x != nil {
+		return x.Err
+	}
+	return ""
+}
+
+// RenewMove extends the leases of the channels' Moves. Channels with no
+// leased Move running, because it stopped or expired, come back in
+// not_moving.
+type RenewMoveRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Channels      []int32                `protobuf:"varint,1,rep,packed,name=channels,proto3" json:"channels,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RenewMoveRequest) Reset() {
+	*x = RenewMoveRequest{}
+	mi := &file_servo_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RenewMoveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenewMoveRequest) ProtoMessage() {}
+
+func (x *RenewMoveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenewMoveRequest.ProtoReflect.Descriptor instead.
+func (*RenewMoveRequest) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *RenewMoveRequest) GetChannels() []int32 {
+	if x != nil {
+		return x.Channels
+	}
+	return nil
+}
+
+type RenewMoveReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NotMoving     []int32                `protobuf:"varint,1,rep,packed,name=not_moving,json=notMoving,proto3" json:"not_moving,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RenewMoveReply) Reset() {
+	*x = RenewMoveReply{}
+	mi := &file_servo_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RenewMoveReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RenewMoveReply) ProtoMessage() {}
+
+func (x *RenewMoveReply) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RenewMoveReply.ProtoReflect.Descriptor instead.
+func (*RenewMoveReply) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *RenewMoveReply) GetNotMoving() []int32 {
+	if x != nil {
+		return x.NotMoving
+	}
+	return nil
+}
+
+// EmergencyStop halts every channel at once. With cut_pwm the PCA9685
+// outputs go off too, so the servos stop holding; the next move on a
+// channel powers it again.
+type EmergencyStopRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	CutPwm        bool                   `protobuf:"varint,1,opt,name=cut_pwm,json=cutPwm,proto3" json:"cut_pwm,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EmergencyStopRequest) Reset() {
+	*x = EmergencyStopRequest{}
+	mi := &file_servo_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EmergencyStopRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EmergencyStopRequest) ProtoMessage() {}
+
+func (x *EmergencyStopRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EmergencyStopRequest.ProtoReflect.Descriptor instead.
+func (*EmergencyStopRequest) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *EmergencyStopRequest) GetCutPwm() bool {
+	if x != nil {
+		return x.CutPwm
+	}
+	return false
+}
+
+type EmergencyStopReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Err           string                 `protobuf:"bytes,2,opt,name=err,proto3" json:"err,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EmergencyStopReply) Reset() {
+	*x = EmergencyStopReply{}
+	mi := &file_servo_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EmergencyStopReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EmergencyStopReply) ProtoMessage() {}
+
+func (x *EmergencyStopReply) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EmergencyStopReply.ProtoReflect.Descriptor instead.
+func (*EmergencyStopReply) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *EmergencyStopReply) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *EmergencyStopReply) GetErr() string {
+	if x != nil {
+		return x.Err
+	}
+	return ""
+}
+
+// Recordings capture every channel's angle as it changes, with the motion
+// commands that moved them, so the arm can be taught by demonstration. The
+// server keeps each in its own JSON file.
+type RecordingInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	DurationMs    uint32                 `protobuf:"varint,2,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	Frames        uint32                 `protobuf:"varint,3,opt,name=frames,proto3" json:"frames,omitempty"`     // angle samples kept
+	Commands      uint32                 `protobuf:"varint,4,opt,name=commands,proto3" json:"commands,omitempty"` // motion RPCs logged
+	Channels      []int32                `protobuf:"varint,5,rep,packed,name=channels,proto3" json:"channels,omitempty"`
+	StartedMs     int64                  `protobuf:"varint,6,opt,name=started_ms,json=startedMs,proto3" json:"started_ms,omitempty"` // unix ms
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecordingInfo) Reset() {
+	*x = RecordingInfo{}
+	mi := &file_servo_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordingInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordingInfo) ProtoMessage() {}
+
+func (x *RecordingInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordingInfo.ProtoReflect.Descriptor instead.
+func (*RecordingInfo) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *RecordingInfo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *RecordingInfo) GetDurationMs() uint32 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *RecordingInfo) GetFrames() uint32 {
+	if x != nil {
+		return x.Frames
+	}
+	return 0
+}
+
+func (x *RecordingInfo) GetCommands() uint32 {
+	if x != nil {
+		return x.Commands
+	}
+	return 0
+}
+
+func (x *RecordingInfo) GetChannels() []int32 {
+	if x != nil {
+		return x.Channels
+	}
+	return nil
+}
+
+func (x *RecordingInfo) GetStartedMs() int64 {
+	if x != nil {
+		return x.StartedMs
+	}
+	return 0
+}
+
+// StartRecording starts capturing under name, which is saved, replacing any
+// recording called that, by StopRecording. One recording runs at a time.
+type StartRecordingRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartRecordingRequest) Reset() {
+	*x = StartRecordingRequest{}
+	mi := &file_servo_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartRecordingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartRecordingRequest) ProtoMessage() {}
+
+func (x *StartRecordingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartRecordingRequest.ProtoReflect.Descriptor instead.
+func (*StartRecordingRequest) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *StartRecordingRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type StartRecordingReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Err           string                 `protobuf:"bytes,2,opt,name=err,proto3" json:"err,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartRecordingReply) Reset() {
+	*x = StartRecordingReply{}
+	mi := &file_servo_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartRecordingReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartRecordingReply) ProtoMessage() {}
+
+func (x *StartRecordingReply) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartRecordingReply.ProtoReflect.Descriptor instead.
+func (*StartRecordingReply) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *StartRecordingReply) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *StartRecordingReply) GetErr() string {
+	if x != nil {
+		return x.Err
+	}
+	return ""
+}
+
+type StopRecordingRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Discard       bool                   `protobuf:"varint,1,opt,name=discard,proto3" json:"discard,omitempty"` // throw it away instead of saving it
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StopRecordingRequest) Reset() {
+	*x = StopRecordingRequest{}
+	mi := &file_servo_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StopRecordingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StopRecordingRequest) ProtoMessage() {}
+
+func (x *StopRecordingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StopRecordingRequest.ProtoReflect.Descriptor instead.
+func (*StopRecordingRequest) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *StopRecordingRequest) GetDiscard() bool {
+	if x != nil {
+		return x.Discard
+	}
+	return false
+}
+
+type StopRecordingReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Err           string                 `protobuf:"bytes,2,opt,name=err,proto3" json:"err,omitempty"`
+	Recording     *RecordingInfo         `protobuf:"bytes,3,opt,name=recording,proto3" json:"recording,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StopRecordingReply) Reset() {
+	*x = StopRecordingReply{}
+	mi := &file_servo_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StopRecordingReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StopRecordingReply) ProtoMessage() {}
+
+func (x *StopRecordingReply) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StopRecordingReply.ProtoReflect.Descriptor instead.
+func (*StopRecordingReply) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *StopRecordingReply) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *StopRecordingReply) GetErr() string {
+	if x != nil {
+		return x.Err
+	}
+	return ""
+}
+
+func (x *StopRecordingReply) GetRecording() *RecordingInfo {
+	if x != nil {
+		return x.Recording
+	}
+	return nil
+}
+
+type ListRecordingsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRecordingsRequest) Reset() {
+	*x = ListRecordingsRequest{}
+	mi := &file_servo_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRecordingsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRecordingsRequest) ProtoMessage() {}
+
+func (x *ListRecordingsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRecordingsRequest.ProtoReflect.Descriptor instead.
+func (*ListRecordingsRequest) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{40}
+}
+
+type ListRecordingsReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Recordings    []*RecordingInfo       `protobuf:"bytes,1,rep,name=recordings,proto3" json:"recordings,omitempty"` // sorted by name
+	Recording     string                 `protobuf:"bytes,2,opt,name=recording,proto3" json:"recording,omitempty"`   // being recorded now, if any
+	Playing       string                 `protobuf:"bytes,3,opt,name=playing,proto3" json:"playing,omitempty"`       // being played now, if any
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRecordingsReply) Reset() {
+	*x = ListRecordingsReply{}
+	mi := &file_servo_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRecordingsReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRecordingsReply) ProtoMessage() {}
+
+func (x *ListRecordingsReply) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRecordingsReply.ProtoReflect.Descriptor instead.
+func (*ListRecordingsReply) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *ListRecordingsReply) GetRecordings() []*RecordingInfo {
+	if x != nil {
+		return x.Recordings
+	}
+	return nil
+}
+
+func (x *ListRecordingsReply) GetRecording() string {
+	if x != nil {
+		return x.Recording
+	}
+	return ""
+}
+
+func (x *ListRecordingsReply) GetPlaying() string {
+	if x != nil {
+		return x.Playing
+	}
+	return ""
+}
+
+type DeleteRecordingRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRecordingRequest) Reset() {
+	*x = DeleteRecordingRequest{}
+	mi := &file_servo_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRecordingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRecordingRequest) ProtoMessage() {}
+
+func (x *DeleteRecordingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRecordingRequest.ProtoReflect.Descriptor instead.
+func (*DeleteRecordingRequest) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *DeleteRecordingRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type DeleteRecordingReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Err           string                 `protobuf:"bytes,2,opt,name=err,proto3" json:"err,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRecordingReply) Reset() {
+	*x = DeleteRecordingReply{}
+	mi := &file_servo_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRecordingReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRecordingReply) ProtoMessage() {}
+
+func (x *DeleteRecordingReply) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRecordingReply.ProtoReflect.Descriptor instead.
+func (*DeleteRecordingReply) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *DeleteRecordingReply) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *DeleteRecordingReply) GetErr() string {
+	if x != nil {
+		return x.Err
+	}
+	return ""
+}
+
+// PlayRecording moves every channel in the recording to where it started,
+// then replays the recorded angles. Every angle is checked against the
+// channels' limits first, and again before each loop. Stopping any of its
+// channels, EmergencyStop or AbortPlayback ends it.
+type PlayRecordingRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Speed         float64                `protobuf:"fixed64,2,opt,name=speed,proto3" json:"speed,omitempty"`    // 0.5–2, 0 for 1
+	Loops         uint32                 `protobuf:"varint,3,opt,name=loops,proto3" json:"loops,omitempty"`     // times through, 0 for once
+	Forever       bool                   `protobuf:"varint,4,opt,name=forever,proto3" json:"forever,omitempty"` // loop until aborted
+	Wait          bool                   `protobuf:"varint,5,opt,name=wait,proto3" json:"wait,omitempty"`       // reply once it finishes
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlayRecordingRequest) Reset() {
+	*x = PlayRecordingRequest{}
+	mi := &file_servo_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlayRecordingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlayRecordingRequest) ProtoMessage() {}
+
+func (x *PlayRecordingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlayRecordingRequest.ProtoReflect.Descriptor instead.
+func (*PlayRecordingRequest) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *PlayRecordingRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PlayRecordingRequest) GetSpeed() float64 {
+	if x != nil {
+		return x.Speed
+	}
+	return 0
+}
+
+func (x *PlayRecordingRequest) GetLoops() uint32 {
+	if x != nil {
+		return x.Loops
+	}
+	return 0
+}
+
+func (x *PlayRecordingRequest) GetForever() bool {
+	if x != nil {
+		return x.Forever
+	}
+	return false
+}
+
+func (x *PlayRecordingRequest) GetWait() bool {
+	if x != nil {
+		return x.Wait
+	}
+	return false
+}
+
+type PlayRecordingReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Err           string                 `protobuf:"bytes,2,opt,name=err,proto3" json:"err,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlayRecordingReply) Reset() {
+	*x = PlayRecordingReply{}
+	mi := &file_servo_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlayRecordingReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlayRecordingReply) ProtoMessage() {}
+
+func (x *PlayRecordingReply) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlayRecordingReply.ProtoReflect.Descriptor instead.
+func (*PlayRecordingReply) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *PlayRecordingReply) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *PlayRecordingReply) GetErr() string {
+	if x != nil {
+		return x.Err
+	}
+	return ""
+}
+
+type AbortPlaybackRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AbortPlaybackRequest) Reset() {
+	*x = AbortPlaybackRequest{}
+	mi := &file_servo_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AbortPlaybackRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AbortPlaybackRequest) ProtoMessage() {}
+
+func (x *AbortPlaybackRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AbortPlaybackRequest.ProtoReflect.Descriptor instead.
+func (*AbortPlaybackRequest) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{46}
+}
+
+type AbortPlaybackReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ok            bool                   `protobuf:"varint,1,opt,name=ok,proto3" json:"ok,omitempty"`
+	Err           string                 `protobuf:"bytes,2,opt,name=err,proto3" json:"err,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AbortPlaybackReply) Reset() {
+	*x = AbortPlaybackReply{}
+	mi := &file_servo_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AbortPlaybackReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AbortPlaybackReply) ProtoMessage() {}
+
+func (x *AbortPlaybackReply) ProtoReflect() protoreflect.Message {
+	mi := &file_servo_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AbortPlaybackReply.ProtoReflect.Descriptor instead.
+func (*AbortPlaybackReply) Descriptor() ([]byte, []int) {
+	return file_servo_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *AbortPlaybackReply) GetOk() bool {
+	if x != nil {
+		return x.Ok
+	}
+	return false
+}
+
+func (x *AbortPlaybackReply) GetErr() string {
+	if x != nil {
+		return x.Err
+	}
+	return ""
+}
+
+var File_servo_proto protoreflect.FileDescriptor
+
+const file_servo_proto_rawDesc = "" +
+	"\n" +
+	"\vservo.proto\x12\x05servo\"\xb2\x01\n" +
+	"\vMoveRequest\x12\x18\n" +
+	"\achannel\x18\x01 \x01(\x05R\achannel\x12\x1c\n" +
+	"\tdirection\x18\x02 \x01(\x05R\tdirection\x12\x14\n" +
+	"\x05speed\x18\x03 \x01(\x01R\x05speed\x12&\n" +
+	"\x0fmax_duration_ms\x18\x04 \x01(\rR\rmaxDurationMs\x12\x19\n" +
+	"\blease_ms\x18\x05 \x01(\rR\aleaseMs\x12\x12\n" +
+	"\x04name\x18\x06 \x01(\tR\x04name\"G\n" +
+	"\tMoveReply\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x10\n" +
+	"\x03err\x18\x02 \x01(\tR\x03err\x12\x18\n" +
+	"\achannel\x18\x03 \x01(\x05R\achannel\";\n" +
+	"\vStopRequest\x12\x18\n" +
+	"\achannel\x18\x01 \x01(\x05R\achannel\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\"-\n" +
+	"\tStopReply\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x10\n" +
+	"\x03err\x18\x02 \x01(\tR\x03err\"\x12\n" +
+	"\x10GetAnglesRequest\"P\n" +
+	"\n" +
+	"ServoAngle\x12\x18\n" +
+	"\achannel\x18\x01 \x01(\x05R\achannel\x12\x14\n" +
+	"\x05angle\x18\x02 \x01(\x02R\x05angle\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\";\n" +
+	"\x0eGetAnglesReply\x12)\n" +
+	"\x06angles\x18\x01 \x03(\v2\x11.servo.ServoAngleR\x06angles\"U\n" +
+	"\x0fSetAngleRequest\x12\x18\n" +
+	"\achannel\x18\x01 \x01(\x05R\achannel\x12\x14\n" +
+	"\x05angle\x18\x02 \x01(\x01R\x05angle\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\"1\n" +
+	"\rSetAngleReply\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x10\n" +
+	"\x03err\x18\x02 \x01(\tR\x03err\"\xae\x01\n" +
+	"\rMoveToRequest\x12\x18\n" +
+	"\achannel\x18\x01 \x01(\x05R\achannel\x12\x14\n" +
+	"\x05angle\x18\x02 \x01(\x01R\x05angle\x12!\n" +
+	"\fmax_velocity\x18\x03 \x01(\x01R\vmaxVelocity\x12\"\n" +
+	"\facceleration\x18\x04 \x01(\x01R\facceleration\x12\x12\n" +
+	"\x04wait\x18\x05 \x01(\bR\x04wait\x12\x12\n" +
+	"\x04name\x18\x06 \x01(\tR\x04name\"/\n" +
+	"\vMoveToReply\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x10\n" +
+	"\x03err\x18\x02 \x01(\tR\x03err\"L\n" +
+	"\bKeyframe\x12\x13\n" +
+	"\x05at_ms\x18\x01 \x01(\rR\x04atMs\x12+\n" +
+	"\atargets\x18\x02 \x03(\v2\x11.servo.ServoAngleR\atargets\"V\n" +
+	"\x11TrajectoryRequest\x12-\n" +
+	"\tkeyframes\x18\x01 \x03(\v2\x0f.servo.KeyframeR\tkeyframes\x12\x12\n" +
+	"\x04wait\x18\x02 \x01(\bR\x04wait\"3\n" +
+	"\x0fTrajectoryReply\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x10\n" +
+	"\x03err\x18\x02 \x01(\tR\x03err\"3\n" +
+	"\x11WatchStateRequest\x12\x1e\n" +
+	"\vmax_rate_hz\x18\x01 \x01(\x01R\tmaxRateHz\"\xd8\x01\n" +
+	"\fChannelState\x12\x18\n" +
+	"\achannel\x18\x01 \x01(\x05R\achannel\x12\x14\n" +
+	"\x05angle\x18\x02 \x01(\x01R\x05angle\x12\x16\n" +
+	"\x06target\x18\x03 \x01(\x01R\x06target\x12\x16\n" +
+	"\x06moving\x18\x04 \x01(\bR\x06moving\x12'\n" +
+	"\bat_limit\x18\x05 \x01(\x0e2\f.servo.LimitR\aatLimit\x12+\n" +
+	"\astopped\x18\x06 \x01(\x0e2\x11.servo.StopReasonR\astopped\x12\x12\n" +
+	"\x04name\x18\a \x01(\tR\x04name\"V\n" +
+	"\n" +
+	"ServoState\x12/\n" +
+	"\bchannels\x18\x01 \x03(\v2\x13.servo.ChannelStateR\bchannels\x12\x17\n" +
+	"\atime_ms\x18\x02 \x01(\x03R\x06timeMs\"E\n" +
+	"\x04Pose\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12)\n" +
+	"\x06angles\x18\x02 \x03(\v2\x11.servo.ServoAngleR\x06angles\"W\n" +
+	"\x0fSavePoseRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1a\n" +
+	"\bchannels\x18\x02 \x03(\x05R\bchannels\x12\x14\n" +
+	"\x05names\x18\x03 \x03(\tR\x05names\"R\n" +
+	"\rSavePoseReply\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x10\n" +
+	"\x03err\x18\x02 \x01(\tR\x03err\x12\x1f\n" +
+	"\x04pose\x18\x03 \x01(\v2\v.servo.PoseR\x04pose\"\x12\n" +
+	"\x10ListPosesRequest\"3\n" +
+	"\x0eListPosesReply\x12!\n" +
+	"\x05poses\x18\x01 \x03(\v2\v.servo.PoseR\x05poses\"'\n" +
+	"\x11DeletePoseRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"3\n" +
+	"\x0fDeletePoseReply\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x10\n" +
+	"\x03err\x18\x02 \x01(\tR\x03err\"\x7f\n" +
+	"\x11MoveToPoseRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1f\n" +
+	"\vduration_ms\x18\x02 \x01(\rR\n" +
+	"durationMs\x12!\n" +
+	"\fmax_velocity\x18\x03 \x01(\x01R\vmaxVelocity\x12\x12\n" +
+	"\x04wait\x18\x04 \x01(\bR\x04wait\"3\n" +
+	"\x0fMoveToPoseReply\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x10\n" +
+	"\x03err\x18\x02 \x01(\tR\x03err\"\xb5\x02\n" +
+	"\rChannelConfig\x12\x18\n" +
+	"\achannel\x18\x01 \x01(\x05R\achannel\x12\x1b\n" +
+	"\tmin_angle\x18\x02 \x01(\x01R\bminAngle\x12\x1b\n" +
+	"\tmax_angle\x18\x03 \x01(\x01R\bmaxAngle\x12\x12\n" +
+	"\x04home\x18\x04 \x01(\x01R\x04home\x12\x1b\n" +
+	"\tmin_pulse\x18\x05 \x01(\rR\bminPulse\x12\x1b\n" +
+	"\tmax_pulse\x18\x06 \x01(\rR\bmaxPulse\x12\x16\n" +
+	"\x06invert\x18\a \x01(\bR\x06invert\x12\x12\n" +
+	"\x04trim\x18\b \x01(\x01R\x04trim\x12\x12\n" +
+	"\x04name\x18\t \x01(\tR\x04name\x12\x10\n" +
+	"\x03bus\x18\n" +
+	" \x01(\x05R\x03bus\x12\x18\n" +
+	"\aaddress\x18\v \x01(\rR\aaddress\x12\x16\n" +
+	"\x06output\x18\f \x01(\x05R\x06output\"\x12\n" +
+	"\x10GetConfigRequest\"B\n" +
+	"\x0eGetConfigReply\x120\n" +
+	"\bchannels\x18\x01 \x03(\v2\x14.servo.ChannelConfigR\bchannels\"{\n" +
+	"\x10SetConfigRequest\x120\n" +
+	"\bchannels\x18\x01 \x03(\v2\x14.servo.ChannelConfigR\bchannels\x12\x17\n" +
+	"\ago_home\x18\x02 \x01(\bR\x06goHome\x12\x1c\n" +
+	"\ttemporary\x18\x03 \x01(\bR\ttemporary\"2\n" +
+	"\x0eSetConfigReply\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x10\n" +
+	"\x03err\x18\x02 \x01(\tR\x03err\".\n" +
+	"\x10RenewMoveRequest\x12\x1a\n" +
+	"\bchannels\x18\x01 \x03(\x05R\bchannels\"/\n" +
+	"\x0eRenewMoveReply\x12\x1d\n" +
+	"\n" +
+	"not_moving\x18\x01 \x03(\x05R\tnotMoving\"/\n" +
+	"\x14EmergencyStopRequest\x12\x17\n" +
+	"\acut_pwm\x18\x01 \x01(\bR\x06cutPwm\"6\n" +
+	"\x12EmergencyStopReply\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x10\n" +
+	"\x03err\x18\x02 \x01(\tR\x03err\"\xb3\x01\n" +
+	"\rRecordingInfo\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1f\n" +
+	"\vduration_ms\x18\x02 \x01(\rR\n" +
+	"durationMs\x12\x16\n" +
+	"\x06frames\x18\x03 \x01(\rR\x06frames\x12\x1a\n" +
+	"\bcommands\x18\x04 \x01(\rR\bcommands\x12\x1a\n" +
+	"\bchannels\x18\x05 \x03(\x05R\bchannels\x12\x1d\n" +
+	"\n" +
+	"started_ms\x18\x06 \x01(\x03R\tstartedMs\"+\n" +
+	"\x15StartRecordingRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"7\n" +
+	"\x13StartRecordingReply\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x10\n" +
+	"\x03err\x18\x02 \x01(\tR\x03err\"0\n" +
+	"\x14StopRecordingRequest\x12\x18\n" +
+	"\adiscard\x18\x01 \x01(\bR\adiscard\"j\n" +
+	"\x12StopRecordingReply\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x10\n" +
+	"\x03err\x18\x02 \x01(\tR\x03err\x122\n" +
+	"\trecording\x18\x03 \x01(\v2\x14.servo.RecordingInfoR\trecording\"\x17\n" +
+	"\x15ListRecordingsRequest\"\x83\x01\n" +
+	"\x13ListRecordingsReply\x124\n" +
+	"\n" +
+	"recordings\x18\x01 \x03(\v2\x14.servo.RecordingInfoR\n" +
+	"recordings\x12\x1c\n" +
+	"\trecording\x18\x02 \x01(\tR\trecording\x12\x18\n" +
+	"\aplaying\x18\x03 \x01(\tR\aplaying\",\n" +
+	"\x16DeleteRecordingRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"8\n" +
+	"\x14DeleteRecordingReply\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x10\n" +
+	"\x03err\x18\x02 \x01(\tR\x03err\"\x84\x01\n" +
+	"\x14PlayRecordingRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05speed\x18\x02 \x01(\x01R\x05speed\x12\x14\n" +
+	"\x05loops\x18\x03 \x01(\rR\x05loops\x12\x18\n" +
+	"\aforever\x18\x04 \x01(\bR\aforever\x12\x12\n" +
+	"\x04wait\x18\x05 \x01(\bR\x04wait\"6\n" +
+	"\x12PlayRecordingReply\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x10\n" +
+	"\x03err\x18\x02 \x01(\tR\x03err\"\x16\n" +
+	"\x14AbortPlaybackRequest\"6\n" +
+	"\x12AbortPlaybackReply\x12\x0e\n" +
+	"\x02ok\x18\x01 \x01(\bR\x02ok\x12\x10\n" +
+	"\x03err\x18\x02 \x01(\tR\x03err*5\n" +
+	"\x05Limit\x12\x0e\n" +
+	"\n" +
+	"LIMIT_NONE\x10\x00\x12\r\n" +
+	"\tLIMIT_MIN\x10\x01\x12\r\n" +
+	"\tLIMIT_MAX\x10\x02*}\n" +
+	"\n" +
+	"StopReason\x12\r\n" +
+	"\tSTOP_NONE\x10\x00\x12\x12\n" +
+	"\x0eSTOP_REQUESTED\x10\x01\x12\x0e\n" +
+	"\n" +
+	"STOP_LIMIT\x10\x02\x12\x10\n" +
+	"\fSTOP_TIMEOUT\x10\x03\x12\x16\n" +
+	"\x12STOP_LEASE_EXPIRED\x10\x04\x12\x12\n" +
+	"\x0eSTOP_EMERGENCY\x10\x052\xd0\n" +
+	"\n" +
+	"\n" +
+	"Controller\x12,\n" +
+	"\x04Move\x12\x12.servo.MoveRequest\x1a\x10.servo.MoveReply\x12,\n" +
+	"\x04Stop\x12\x12.servo.StopRequest\x1a\x10.servo.StopReply\x12;\n" +
+	"\tGetAngles\x12\x17.servo.GetAnglesRequest\x1a\x15.servo.GetAnglesReply\x128\n" +
+	"\bSetAngle\x12\x16.servo.SetAngleRequest\x1a\x14.servo.SetAngleReply\x122\n" +
+	"\x06MoveTo\x12\x14.servo.MoveToRequest\x1a\x12.servo.MoveToReply\x12E\n" +
+	"\x11ExecuteTrajectory\x12\x18.servo.TrajectoryRequest\x1a\x16.servo.TrajectoryReply\x12;\n" +
+	"\n" +
+	"WatchState\x12\x18.servo.WatchStateRequest\x1a\x11.servo.ServoState0\x01\x128\n" +
+	"\bSavePose\x12\x16.servo.SavePoseRequest\x1a\x14.servo.SavePoseReply\x12;\n" +
+	"\tListPoses\x12\x17.servo.ListPosesRequest\x1a\x15.servo.ListPosesReply\x12>\n" +
+	"\n" +
+	"DeletePose\x12\x18.servo.DeletePoseRequest\x1a\x16.servo.DeletePoseReply\x12>\n" +
+	"\n" +
+	"MoveToPose\x12\x18.servo.MoveToPoseRequest\x1a\x16.servo.MoveToPoseReply\x12;\n" +
+	"\tGetConfig\x12\x17.servo.GetConfigRequest\x1a\x15.servo.GetConfigReply\x12;\n" +
+	"\tSetConfig\x12\x17.servo.SetConfigRequest\x1a\x15.servo.SetConfigReply\x12;\n" +
+	"\tRenewMove\x12\x17.servo.RenewMoveRequest\x1a\x15.servo.RenewMoveReply\x12G\n" +
+	"\rEmergencyStop\x12\x1b.servo.EmergencyStopRequest\x1a\x19.servo.EmergencyStopReply\x12J\n" +
+	"\x0eStartRecording\x12\x1c.servo.StartRecordingRequest\x1a\x1a.servo.StartRecordingReply\x12G\n" +
+	"\rStopRecording\x12\x1b.servo.StopRecordingRequest\x1a\x19.servo.StopRecordingReply\x12J\n" +
+	"\x0eListRecordings\x12\x1c.servo.ListRecordingsRequest\x1a\x1a.servo.ListRecordingsReply\x12M\n" +
+	"\x0fDeleteRecording\x12\x1d.servo.DeleteRecordingRequest\x1a\x1b.servo.DeleteRecordingReply\x12G\n" +
+	"\rPlayRecording\x12\x1b.servo.PlayRecordingRequest\x1a\x19.servo.PlayRecordingReply\x12G\n" +
+	"\rAbortPlayback\x12\x1b.servo.AbortPlaybackRequest\x1a\x19.servo.AbortPlaybackReplyB-Z+github.com/n0remac/robot-webrtc/servo;servob\x06proto3"
 
 var (
 	file_servo_proto_rawDescOnce sync.Once
@@ -403,29 +3046,121 @@ func file_servo_proto_rawDescGZIP() []byte {
 	return file_servo_proto_rawDescData
 }
 
-var file_servo_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_servo_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_servo_proto_msgTypes = make([]protoimpl.MessageInfo, 48)
 var file_servo_proto_goTypes = []any{
-	(*MoveRequest)(nil),      // 0: servo.MoveRequest
-	(*MoveReply)(nil),        // 1: servo.MoveReply
-	(*StopRequest)(nil),      // 2: servo.StopRequest
-	(*StopReply)(nil),        // 3: servo.StopReply
-	(*GetAnglesRequest)(nil), // 4: servo.GetAnglesRequest
-	(*ServoAngle)(nil),       // 5: servo.ServoAngle
-	(*GetAnglesReply)(nil),   // 6: servo.GetAnglesReply
+	(Limit)(0),                     // 0: servo.Limit
+	(StopReason)(0),                // 1: servo.StopReason
+	(*MoveRequest)(nil),            // 2: servo.MoveRequest
+	(*MoveReply)(nil),              // 3: servo.MoveReply
+	(*StopRequest)(nil),            // 4: servo.StopRequest
+	(*StopReply)(nil),              // 5: servo.StopReply
+	(*GetAnglesRequest)(nil),       // 6: servo.GetAnglesRequest
+	(*ServoAngle)(nil),             // 7: servo.ServoAngle
+	(*GetAnglesReply)(nil),         // 8: servo.GetAnglesReply
+	(*SetAngleRequest)(nil),        // 9: servo.SetAngleRequest
+	(*SetAngleReply)(nil),          // 10: servo.SetAngleReply
+	(*MoveToRequest)(nil),          // 11: servo.MoveToRequest
+	(*MoveToReply)(nil),            // 12: servo.MoveToReply
+	(*Keyframe)(nil),               // 13: servo.Keyframe
+	(*TrajectoryRequest)(nil),      // 14: servo.TrajectoryRequest
+	(*TrajectoryReply)(nil),        // 15: servo.TrajectoryReply
+	(*WatchStateRequest)(nil),      // 16: servo.WatchStateRequest
+	(*ChannelState)(nil),           // 17: servo.ChannelState
+	(*ServoState)(nil),             // 18: servo.ServoState
+	(*Pose)(nil),                   // 19: servo.Pose
+	(*SavePoseRequest)(nil),        // 20: servo.SavePoseRequest
+	(*SavePoseReply)(nil),          // 21: servo.SavePoseReply
+	(*ListPosesRequest)(nil),       // 22: servo.ListPosesRequest
+	(*ListPosesReply)(nil),         // 23: servo.ListPosesReply
+	(*DeletePoseRequest)(nil),      // 24: servo.DeletePoseRequest
+	(*DeletePoseReply)(nil),        // 25: servo.DeletePoseReply
+	(*MoveToPoseRequest)(nil),      // 26: servo.MoveToPoseRequest
+	(*MoveToPoseReply)(nil),        // 27: servo.MoveToPoseReply
+	(*ChannelConfig)(nil),          // 28: servo.ChannelConfig
+	(*GetConfigRequest)(nil),       // 29: servo.GetConfigRequest
+	(*GetConfigReply)(nil),         // 30: servo.GetConfigReply
+	(*SetConfigRequest)(nil),       // 31: servo.SetConfigRequest
+	(*SetConfigReply)(nil),         // 32: servo.SetConfigReply
+	(*RenewMoveRequest)(nil),       // 33: servo.RenewMoveRequest
+	(*RenewMoveReply)(nil),         // 34: servo.RenewMoveReply
+	(*EmergencyStopRequest)(nil),   // 35: servo.EmergencyStopRequest
+	(*EmergencyStopReply)(nil),     // 36: servo.EmergencyStopReply
+	(*RecordingInfo)(nil),          // 37: servo.RecordingInfo
+	(*StartRecordingRequest)(nil),  // 38: servo.StartRecordingRequest
+	(*StartRecordingReply)(nil),    // 39: servo.StartRecordingReply
+	(*StopRecordingRequest)(nil),   // 40: servo.StopRecordingRequest
+	(*StopRecordingReply)(nil),     // 41: servo.StopRecordingReply
+	(*ListRecordingsRequest)(nil),  // 42: servo.ListRecordingsRequest
+	(*ListRecordingsReply)(nil),    // 43: servo.ListRecordingsReply
+	(*DeleteRecordingRequest)(nil), // 44: servo.DeleteRecordingRequest
+	(*DeleteRecordingReply)(nil),   // 45: servo.DeleteRecordingReply
+	(*PlayRecordingRequest)(nil),   // 46: servo.PlayRecordingRequest
+	(*PlayRecordingReply)(nil),     // 47: servo.PlayRecordingReply
+	(*AbortPlaybackRequest)(nil),   // 48: servo.AbortPlaybackRequest
+	(*AbortPlaybackReply)(nil),     // 49: servo.AbortPlaybackReply
 }
 var file_servo_proto_depIdxs = []int32{
-	5, // 0: servo.GetAnglesReply.angles:type_name -> servo.ServoAngle
-	0, // 1: servo.Controller.Move:input_type -> servo.MoveRequest
-	2, // 2: servo.Controller.Stop:input_type -> servo.StopRequest
-	4, // 3: servo.Controller.GetAngles:input_type -> servo.GetAnglesRequest
-	1, // 4: servo.Controller.Move:output_type -> servo.MoveReply
-	3, // 5: servo.Controller.Stop:output_type -> servo.StopReply
-	6, // 6: servo.Controller.GetAngles:output_type -> servo.GetAnglesReply
-	4, // [4:7] is the sub-list for method output_type
-	1, // [1:4] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	7,  // 0: servo.GetAnglesReply.angles:type_name -> servo.ServoAngle
+	7,  // 1: servo.Keyframe.targets:type_name -> servo.ServoAngle
+	13, // 2: servo.TrajectoryRequest.keyframes:type_name -> servo.Keyframe
+	0,  // 3: servo.ChannelState.at_limit:type_name -> servo.Limit
+	1,  // 4: servo.ChannelState.stopped:type_name -> servo.StopReason
+	17, // 5: servo.ServoState.channels:type_name -> servo.ChannelState
+	7,  // 6: servo.Pose.angles:type_name -> servo.ServoAngle
+	19, // 7: servo.SavePoseReply.pose:type_name -> servo.Pose
+	19, // 8: servo.ListPosesReply.poses:type_name -> servo.Pose
+	28, // 9: servo.GetConfigReply.channels:type_name -> servo.ChannelConfig
+	28, // 10: servo.SetConfigRequest.channels:type_name -> servo.ChannelConfig
+	37, // 11: servo.StopRecordingReply.recording:type_name -> servo.RecordingInfo
+	37, // 12: servo.ListRecordingsReply.recordings:type_name -> servo.RecordingInfo
+	2,  // 13: servo.Controller.Move:input_type -> servo.MoveRequest
+	4,  // 14: servo.Controller.Stop:input_type -> servo.StopRequest
+	6,  // 15: servo.Controller.GetAngles:input_type -> servo.GetAnglesRequest
+	9,  // 16: servo.Controller.SetAngle:input_type -> servo.SetAngleRequest
+	11, // 17: servo.Controller.MoveTo:input_type -> servo.MoveToRequest
+	14, // 18: servo.Controller.ExecuteTrajectory:input_type -> servo.TrajectoryRequest
+	16, // 19: servo.Controller.WatchState:input_type -> servo.WatchStateRequest
+	20, // 20: servo.Controller.SavePose:input_type -> servo.SavePoseRequest
+	22, // 21: servo.Controller.ListPoses:input_type -> servo.ListPosesRequest
+	24, // 22: servo.Controller.DeletePose:input_type -> servo.DeletePoseRequest
+	26, // 23: servo.Controller.MoveToPose:input_type -> servo.MoveToPoseRequest
+	29, // 24: servo.Controller.GetConfig:input_type -> servo.GetConfigRequest
+	31, // 25: servo.Controller.SetConfig:input_type -> servo.SetConfigRequest
+	33, // 26: servo.Controller.RenewMove:input_type -> servo.RenewMoveRequest
+	35, // 27: servo.Controller.EmergencyStop:input_type -> servo.EmergencyStopRequest
+	38, // 28: servo.Controller.StartRecording:input_type -> servo.StartRecordingRequest
+	40, // 29: servo.Controller.StopRecording:input_type -> servo.StopRecordingRequest
+	42, // 30: servo.Controller.ListRecordings:input_type -> servo.ListRecordingsRequest
+	44, // 31: servo.Controller.DeleteRecording:input_type -> servo.DeleteRecordingRequest
+	46, // 32: servo.Controller.PlayRecording:input_type -> servo.PlayRecordingRequest
+	48, // 33: servo.Controller.AbortPlayback:input_type -> servo.AbortPlaybackRequest
+	3,  // 34: servo.Controller.Move:output_type -> servo.MoveReply
+	5,  // 35: servo.Controller.Stop:output_type -> servo.StopReply
+	8,  // 36: servo.Controller.GetAngles:output_type -> servo.GetAnglesReply
+	10, // 37: servo.Controller.SetAngle:output_type -> servo.SetAngleReply
+	12, // 38: servo.Controller.MoveTo:output_type -> servo.MoveToReply
+	15, // 39: servo.Controller.ExecuteTrajectory:output_type -> servo.TrajectoryReply
+	18, // 40: servo.Controller.WatchState:output_type -> servo.ServoState
+	21, // 41: servo.Controller.SavePose:output_type -> servo.SavePoseReply
+	23, // 42: servo.Controller.ListPoses:output_type -> servo.ListPosesReply
+	25, // 43: servo.Controller.DeletePose:output_type -> servo.DeletePoseReply
+	27, // 44: servo.Controller.MoveToPose:output_type -> servo.MoveToPoseReply
+	30, // 45: servo.Controller.GetConfig:output_type -> servo.GetConfigReply
+	32, // 46: servo.Controller.SetConfig:output_type -> servo.SetConfigReply
+	34, // 47: servo.Controller.RenewMove:output_type -> servo.RenewMoveReply
+	36, // 48: servo.Controller.EmergencyStop:output_type -> servo.EmergencyStopReply
+	39, // 49: servo.Controller.StartRecording:output_type -> servo.StartRecordingReply
+	41, // 50: servo.Controller.StopRecording:output_type -> servo.StopRecordingReply
+	43, // 51: servo.Controller.ListRecordings:output_type -> servo.ListRecordingsReply
+	45, // 52: servo.Controller.DeleteRecording:output_type -> servo.DeleteRecordingReply
+	47, // 53: servo.Controller.PlayRecording:output_type -> servo.PlayRecordingReply
+	49, // 54: servo.Controller.AbortPlayback:output_type -> servo.AbortPlaybackReply
+	34, // [34:55] is the sub-list for method output_type
+	13, // [13:34] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
 }
 
 func init() { file_servo_proto_init() }
@@ -438,13 +3173,14 @@ func file_servo_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_servo_proto_rawDesc), len(file_servo_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   7,
+			NumEnums:      2,
+			NumMessages:   48,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_servo_proto_goTypes,
 		DependencyIndexes: file_servo_proto_depIdxs,
+		EnumInfos:         file_servo_proto_enumTypes,
 		MessageInfos:      file_servo_proto_msgTypes,
 	}.Build()
 	File_servo_proto = out.File
@@ -19,9 +19,27 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	Controller_Move_FullMethodName      = "/servo.Controller/Move"
-	Controller_Stop_FullMethodName      = "/servo.Controller/Stop"
-	Controller_GetAngles_FullMethodName = "/servo.Controller/GetAngles"
+	Controller_Move_FullMethodName              = "/servo.Controller/Move"
+	Controller_Stop_FullMethodName              = "/servo.Controller/Stop"
+	Controller_GetAngles_FullMethodName         = "/servo.Controller/GetAngles"
+	Controller_SetAngle_FullMethodName          = "/servo.Controller/SetAngle"
+	Controller_MoveTo_FullMethodName            = "/servo.Controller/MoveTo"
+	Controller_ExecuteTrajectory_FullMethodName = "/servo.Controller/ExecuteTrajectory"
+	Controller_WatchState_FullMethodName        = "/servo.Controller/WatchState"
+	Controller_SavePose_FullMethodName          = "/servo.Controller/SavePose"
+	Controller_ListPoses_FullMethodName         = "/servo.Controller/ListPoses"
+	Controller_DeletePose_FullMethodName        = "/servo.Controller/DeletePose"
+	Controller_MoveToPose_FullMethodName        = "/servo.Controller/MoveToPose"
+	Controller_GetConfig_FullMethodName         = "/servo.Controller/GetConfig"
+	Controller_SetConfig_FullMethodName         = "/servo.Controller/SetConfig"
+	Controller_RenewMove_FullMethodName         = "/servo.Controller/RenewMove"
+	Controller_EmergencyStop_FullMethodName     = "/servo.Controller/EmergencyStop"
+	Controller_StartRecording_FullMethodName    = "/servo.Controller/StartRecording"
+	Controller_StopRecording_FullMethodName     = "/servo.Controller/StopRecording"
+	Controller_ListRecordings_FullMethodName    = "/servo.Controller/ListRecordings"
+	Controller_DeleteRecording_FullMethodName   = "/servo.Controller/DeleteRecording"
+	Controller_PlayRecording_FullMethodName     = "/servo.Controller/PlayRecording"
+	Controller_AbortPlayback_FullMethodName     = "/servo.Controller/AbortPlayback"
 )
 
 // ControllerClient is the client API for Controller service.
@@ -31,6 +49,24 @@ type ControllerClient interface {
 	Move(ctx context.Context, in *MoveRequest, opts ...grpc.CallOption) (*MoveReply, error)
 	Stop(ctx context.Context, in *StopRequest, opts ...grpc.CallOption) (*StopReply, error)
 	GetAngles(ctx context.Context, in *GetAnglesRequest, opts ...grpc.CallOption) (*GetAnglesReply, error)
+	SetAngle(ctx context.Context, in *SetAngleRequest, opts ...grpc.CallOption) (*SetAngleReply, error)
+	MoveTo(ctx context.Context, in *MoveToRequest, opts ...grpc.CallOption) (*MoveToReply, error)
+	ExecuteTrajectory(ctx context.Context, in *TrajectoryRequest, opts ...grpc.CallOption) (*TrajectoryReply, error)
+	WatchState(ctx context.Context, in *WatchStateRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ServoState], error)
+	SavePose(ctx context.Context, in *SavePoseRequest, opts ...grpc.CallOption) (*SavePoseReply, error)
+	ListPoses(ctx context.Context, in *ListPosesRequest, opts ...grpc.CallOption) (*ListPosesReply, error)
+	DeletePose(ctx context.Context, in *DeletePoseRequest, opts ...grpc.CallOption) (*DeletePoseReply, error)
+	MoveToPose(ctx context.Context, in *MoveToPoseRequest, opts ...grpc.CallOption) (*MoveToPoseReply, error)
+	GetConfig(ctx context.Context, in *GetConfigRequest, opts ...grpc.CallOption) (*GetConfigReply, error)
+	SetConfig(ctx context.Context, in *SetConfigRequest, opts ...grpc.CallOption) (*SetConfigReply, error)
+	RenewMove(ctx context.Context, in *RenewMoveRequest, opts ...grpc.CallOption) (*RenewMoveReply, error)
+	EmergencyStop(ctx context.Context, in *EmergencyStopRequest, opts ...grpc.CallOption) (*EmergencyStopReply, error)
+	StartRecording(ctx context.Context, in *StartRecordingRequest, opts ...grpc.CallOption) (*StartRecordingReply, error)
+	StopRecording(ctx context.Context, in *StopRecordingRequest, opts ...grpc.CallOption) (*StopRecordingReply, error)
+	ListRecordings(ctx context.Context, in *ListRecordingsRequest, opts ...grpc.CallOption) (*ListRecordingsReply, error)
+	DeleteRecording(ctx context.Context, in *DeleteRecordingRequest, opts ...grpc.CallOption) (*DeleteRecordingReply, error)
+	PlayRecording(ctx context.Context, in *PlayRecordingRequest, opts ...grpc.CallOption) (*PlayRecordingReply, error)
+	AbortPlayback(ctx context.Context, in *AbortPlaybackRequest, opts ...grpc.CallOption) (*AbortPlaybackReply, error)
 }
 
 type controllerClient struct {
@@ -71,6 +107,195 @@ func (c *controllerClient) GetAngles(ctx context.Context, in *GetAnglesRequest,
 	return out, nil
 }
 
+func (c *controllerClient) SetAngle(ctx context.Context, in *SetAngleRequest, opts ...grpc.CallOption) (*SetAngleReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetAngleReply)
+	err := c.cc.Invoke(ctx, Controller_SetAngle_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controllerClient) MoveTo(ctx context.Context, in *MoveToRequest, opts ...grpc.CallOption) (*MoveToReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MoveToReply)
+	err := c.cc.Invoke(ctx, Controller_MoveTo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controllerClient) ExecuteTrajectory(ctx context.Context, in *TrajectoryRequest, opts ...grpc.CallOption) (*TrajectoryReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TrajectoryReply)
+	err := c.cc.Invoke(ctx, Controller_ExecuteTrajectory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controllerClient) WatchState(ctx context.Context, in *WatchStateRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ServoState], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Controller_ServiceDesc.Streams[0], Controller_WatchState_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchStateRequest, ServoState]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Controller_WatchStateClient = grpc.ServerStreamingClient[ServoState]
+
+func (c *controllerClient) SavePose(ctx context.Context, in *SavePoseRequest, opts ...grpc.CallOption) (*SavePoseReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SavePoseReply)
+	err := c.cc.Invoke(ctx, Controller_SavePose_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controllerClient) ListPoses(ctx context.Context, in *ListPosesRequest, opts ...grpc.CallOption) (*ListPosesReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListPosesReply)
+	err := c.cc.Invoke(ctx, Controller_ListPoses_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controllerClient) DeletePose(ctx context.Context, in *DeletePoseRequest, opts ...grpc.CallOption) (*DeletePoseReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeletePoseReply)
+	err := c.cc.Invoke(ctx, Controller_DeletePose_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controllerClient) MoveToPose(ctx context.Context, in *MoveToPoseRequest, opts ...grpc.CallOption) (*MoveToPoseReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MoveToPoseReply)
+	err := c.cc.Invoke(ctx, Controller_MoveToPose_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controllerClient) GetConfig(ctx context.Context, in *GetConfigRequest, opts ...grpc.CallOption) (*GetConfigReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetConfigReply)
+	err := c.cc.Invoke(ctx, Controller_GetConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controllerClient) SetConfig(ctx context.Context, in *SetConfigRequest, opts ...grpc.CallOption) (*SetConfigReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetConfigReply)
+	err := c.cc.Invoke(ctx, Controller_SetConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controllerClient) RenewMove(ctx context.Context, in *RenewMoveRequest, opts ...grpc.CallOption) (*RenewMoveReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RenewMoveReply)
+	err := c.cc.Invoke(ctx, Controller_RenewMove_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controllerClient) EmergencyStop(ctx context.Context, in *EmergencyStopRequest, opts ...grpc.CallOption) (*EmergencyStopReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EmergencyStopReply)
+	err := c.cc.Invoke(ctx, Controller_EmergencyStop_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controllerClient) StartRecording(ctx context.Context, in *StartRecordingRequest, opts ...grpc.CallOption) (*StartRecordingReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StartRecordingReply)
+	err := c.cc.Invoke(ctx, Controller_StartRecording_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controllerClient) StopRecording(ctx context.Context, in *StopRecordingRequest, opts ...grpc.CallOption) (*StopRecordingReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StopRecordingReply)
+	err := c.cc.Invoke(ctx, Controller_StopRecording_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controllerClient) ListRecordings(ctx context.Context, in *ListRecordingsRequest, opts ...grpc.CallOption) (*ListRecordingsReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListRecordingsReply)
+	err := c.cc.Invoke(ctx, Controller_ListRecordings_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controllerClient) DeleteRecording(ctx context.Context, in *DeleteRecordingRequest, opts ...grpc.CallOption) (*DeleteRecordingReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteRecordingReply)
+	err := c.cc.Invoke(ctx, Controller_DeleteRecording_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controllerClient) PlayRecording(ctx context.Context, in *PlayRecordingRequest, opts ...grpc.CallOption) (*PlayRecordingReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PlayRecordingReply)
+	err := c.cc.Invoke(ctx, Controller_PlayRecording_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controllerClient) AbortPlayback(ctx context.Context, in *AbortPlaybackRequest, opts ...grpc.CallOption) (*AbortPlaybackReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AbortPlaybackReply)
+	err := c.cc.Invoke(ctx, Controller_AbortPlayback_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ControllerServer is the server API for Controller service.
 // All implementations must embed UnimplementedControllerServer
 // for forward compatibility.
@@ -78,6 +303,24 @@ type ControllerServer interface {
 	Move(context.Context, *MoveRequest) (*MoveReply, error)
 	Stop(context.Context, *StopRequest) (*StopReply, error)
 	GetAngles(context.Context, *GetAnglesRequest) (*GetAnglesReply, error)
+	SetAngle(context.Context, *SetAngleRequest) (*SetAngleReply, error)
+	MoveTo(context.Context, *MoveToRequest) (*MoveToReply, error)
+	ExecuteTrajectory(context.Context, *TrajectoryRequest) (*TrajectoryReply, error)
+	WatchState(*WatchStateRequest, grpc.ServerStreamingServer[ServoState]) error
+	SavePose(context.Context, *SavePoseRequest) (*SavePoseReply, error)
+	ListPoses(context.Context, *ListPosesRequest) (*ListPosesReply, error)
+	DeletePose(context.Context, *DeletePoseRequest) (*DeletePoseReply, error)
+	MoveToPose(context.Context, *MoveToPoseRequest) (*MoveToPoseReply, error)
+	GetConfig(context.Context, *GetConfigRequest) (*GetConfigReply, error)
+	SetConfig(context.Context, *SetConfigRequest) (*SetConfigReply, error)
+	RenewMove(context.Context, *RenewMoveRequest) (*RenewMoveReply, error)
+	EmergencyStop(context.Context, *EmergencyStopRequest) (*EmergencyStopReply, error)
+	StartRecording(context.Context, *StartRecordingRequest) (*StartRecordingReply, error)
+	StopRecording(context.Context, *StopRecordingRequest) (*StopRecordingReply, error)
+	ListRecordings(context.Context, *ListRecordingsRequest) (*ListRecordingsReply, error)
+	DeleteRecording(context.Context, *DeleteRecordingRequest) (*DeleteRecordingReply, error)
+	PlayRecording(context.Context, *PlayRecordingRequest) (*PlayRecordingReply, error)
+	AbortPlayback(context.Context, *AbortPlaybackRequest) (*AbortPlaybackReply, error)
 	mustEmbedUnimplementedControllerServer()
 }
 
@@ -97,6 +340,60 @@ func (UnimplementedControllerServer) Stop(context.Context, *StopRequest) (*StopR
 func (UnimplementedControllerServer) GetAngles(context.Context, *GetAnglesRequest) (*GetAnglesReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetAngles not implemented")
 }
+func (UnimplementedControllerServer) SetAngle(context.Context, *SetAngleRequest) (*SetAngleReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetAngle not implemented")
+}
+func (UnimplementedControllerServer) MoveTo(context.Context, *MoveToRequest) (*MoveToReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MoveTo not implemented")
+}
+func (UnimplementedControllerServer) ExecuteTrajectory(context.Context, *TrajectoryRequest) (*TrajectoryReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExecuteTrajectory not implemented")
+}
+func (UnimplementedControllerServer) WatchState(*WatchStateRequest, grpc.ServerStreamingServer[ServoState]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchState not implemented")
+}
+func (UnimplementedControllerServer) SavePose(context.Context, *SavePoseRequest) (*SavePoseReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SavePose not implemented")
+}
+func (UnimplementedControllerServer) ListPoses(context.Context, *ListPosesRequest) (*ListPosesReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPoses not implemented")
+}
+func (UnimplementedControllerServer) DeletePose(context.Context, *DeletePoseRequest) (*DeletePoseReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeletePose not implemented")
+}
+func (UnimplementedControllerServer) MoveToPose(context.Context, *MoveToPoseRequest) (*MoveToPoseReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MoveToPose not implemented")
+}
+func (UnimplementedControllerServer) GetConfig(context.Context, *GetConfigRequest) (*GetConfigReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetConfig not implemented")
+}
+func (UnimplementedControllerServer) SetConfig(context.Context, *SetConfigRequest) (*SetConfigReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetConfig not implemented")
+}
+func (UnimplementedControllerServer) RenewMove(context.Context, *RenewMoveRequest) (*RenewMoveReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenewMove not implemented")
+}
+func (UnimplementedControllerServer) EmergencyStop(context.Context, *EmergencyStopRequest) (*EmergencyStopReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EmergencyStop not implemented")
+}
+func (UnimplementedControllerServer) StartRecording(context.Context, *StartRecordingRequest) (*StartRecordingReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartRecording not implemented")
+}
+func (UnimplementedControllerServer) StopRecording(context.Context, *StopRecordingRequest) (*StopRecordingReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StopRecording not implemented")
+}
+func (UnimplementedControllerServer) ListRecordings(context.Context, *ListRecordingsRequest) (*ListRecordingsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRecordings not implemented")
+}
+func (UnimplementedControllerServer) DeleteRecording(context.Context, *DeleteRecordingRequest) (*DeleteRecordingReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteRecording not implemented")
+}
+func (UnimplementedControllerServer) PlayRecording(context.Context, *PlayRecordingRequest) (*PlayRecordingReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PlayRecording not implemented")
+}
+func (UnimplementedControllerServer) AbortPlayback(context.Context, *AbortPlaybackRequest) (*AbortPlaybackReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AbortPlayback not implemented")
+}
 func (UnimplementedControllerServer) mustEmbedUnimplementedControllerServer() {}
 func (UnimplementedControllerServer) testEmbeddedByValue()                    {}
 
@@ -172,6 +469,323 @@ func _Controller_GetAngles_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Controller_SetAngle_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetAngleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControllerServer).SetAngle(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Controller_SetAngle_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControllerServer).SetAngle(ctx, req.(*SetAngleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Controller_MoveTo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MoveToRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControllerServer).MoveTo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Controller_MoveTo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControllerServer).MoveTo(ctx, req.(*MoveToRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Controller_ExecuteTrajectory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TrajectoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControllerServer).ExecuteTrajectory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Controller_ExecuteTrajectory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControllerServer).ExecuteTrajectory(ctx, req.(*TrajectoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Controller_WatchState_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchStateRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ControllerServer).WatchState(m, &grpc.GenericServerStream[WatchStateRequest, ServoState]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Controller_WatchStateServer = grpc.ServerStreamingServer[ServoState]
+
+func _Controller_SavePose_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SavePoseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControllerServer).SavePose(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Controller_SavePose_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControllerServer).SavePose(ctx, req.(*SavePoseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Controller_ListPoses_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPosesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControllerServer).ListPoses(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Controller_ListPoses_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControllerServer).ListPoses(ctx, req.(*ListPosesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Controller_DeletePose_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeletePoseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControllerServer).DeletePose(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Controller_DeletePose_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControllerServer).DeletePose(ctx, req.(*DeletePoseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Controller_MoveToPose_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MoveToPoseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControllerServer).MoveToPose(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Controller_MoveToPose_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControllerServer).MoveToPose(ctx, req.(*MoveToPoseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Controller_GetConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControllerServer).GetConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Controller_GetConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControllerServer).GetConfig(ctx, req.(*GetConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Controller_SetConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControllerServer).SetConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Controller_SetConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControllerServer).SetConfig(ctx, req.(*SetConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Controller_RenewMove_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenewMoveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControllerServer).RenewMove(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Controller_RenewMove_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControllerServer).RenewMove(ctx, req.(*RenewMoveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Controller_EmergencyStop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EmergencyStopRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControllerServer).EmergencyStop(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Controller_EmergencyStop_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControllerServer).EmergencyStop(ctx, req.(*EmergencyStopRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Controller_StartRecording_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartRecordingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControllerServer).StartRecording(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Controller_StartRecording_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControllerServer).StartRecording(ctx, req.(*StartRecordingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Controller_StopRecording_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StopRecordingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControllerServer).StopRecording(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Controller_StopRecording_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControllerServer).StopRecording(ctx, req.(*StopRecordingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Controller_ListRecordings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRecordingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControllerServer).ListRecordings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Controller_ListRecordings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControllerServer).ListRecordings(ctx, req.(*ListRecordingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Controller_DeleteRecording_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRecordingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControllerServer).DeleteRecording(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Controller_DeleteRecording_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControllerServer).DeleteRecording(ctx, req.(*DeleteRecordingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Controller_PlayRecording_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PlayRecordingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControllerServer).PlayRecording(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Controller_PlayRecording_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControllerServer).PlayRecording(ctx, req.(*PlayRecordingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Controller_AbortPlayback_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AbortPlaybackRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControllerServer).AbortPlayback(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Controller_AbortPlayback_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControllerServer).AbortPlayback(ctx, req.(*AbortPlaybackRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Controller_ServiceDesc is the grpc.ServiceDesc for Controller service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -191,7 +805,81 @@ var Controller_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetAngles",
 			Handler:    _Controller_GetAngles_Handler,
 		},
+		{
+			MethodName: "SetAngle",
+			Handler:    _Controller_SetAngle_Handler,
+		},
+		{
+			MethodName: "MoveTo",
+			Handler:    _Controller_MoveTo_Handler,
+		},
+		{
+			MethodName: "ExecuteTrajectory",
+			Handler:    _Controller_ExecuteTrajectory_Handler,
+		},
+		{
+			MethodName: "SavePose",
+			Handler:    _Controller_SavePose_Handler,
+		},
+		{
+			MethodName: "ListPoses",
+			Handler:    _Controller_ListPoses_Handler,
+		},
+		{
+			MethodName: "DeletePose",
+			Handler:    _Controller_DeletePose_Handler,
+		},
+		{
+			MethodName: "MoveToPose",
+			Handler:    _Controller_MoveToPose_Handler,
+		},
+		{
+			MethodName: "GetConfig",
+			Handler:    _Controller_GetConfig_Handler,
+		},
+		{
+			MethodName: "SetConfig",
+			Handler:    _Controller_SetConfig_Handler,
+		},
+		{
+			MethodName: "RenewMove",
+			Handler:    _Controller_RenewMove_Handler,
+		},
+		{
+			MethodName: "EmergencyStop",
+			Handler:    _Controller_EmergencyStop_Handler,
+		},
+		{
+			MethodName: "StartRecording",
+			Handler:    _Controller_StartRecording_Handler,
+		},
+		{
+			MethodName: "StopRecording",
+			Handler:    _Controller_StopRecording_Handler,
+		},
+		{
+			MethodName: "ListRecordings",
+			Handler:    _Controller_ListRecordings_Handler,
+		},
+		{
+			MethodName: "DeleteRecording",
+			Handler:    _Controller_DeleteRecording_Handler,
+		},
+		{
+			MethodName: "PlayRecording",
+			Handler:    _Controller_PlayRecording_Handler,
+		},
+		{
+			MethodName: "AbortPlayback",
+			Handler:    _Controller_AbortPlayback_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchState",
+			Handler:       _Controller_WatchState_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "servo.proto",
 }
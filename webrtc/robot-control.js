@@ -36,12 +36,15 @@ async function joinSession() {
 }
 
 async function connectWebSocket() {
-    ws = new WebSocket(
+    const { userId, token } = await fetchWsToken(ROOM);
+    myUUID = userId;
+    ws = new WebSocket(withWsToken(
       (location.protocol === 'https:' ? 'wss://' : 'ws://')
       + location.host
       + '/ws/hub?room=' + encodeURIComponent(ROOM)
-      + '&playerId=' + encodeURIComponent(myUUID)
-    );
+      + '&playerId=' + encodeURIComponent(myUUID),
+      token
+    ));
 
     ws.onopen = () => {
       Logger.info('WebSocket open');
@@ -103,8 +106,21 @@ function createPeerConnection(peerId) {
 
     // accept an *incoming* data‐channel
     pc.ondatachannel = ({ channel }) => {
+      if (channel.label === 'telemetry') {
+        channel.onmessage = e => renderTelemetry(JSON.parse(e.data));
+        return;
+      }
+      if (channel.label === 'mission') {
+        missionChannels[peerId] = channel;
+        channel.onclose = () => delete missionChannels[peerId];
+        channel.onmessage = e => renderMission(JSON.parse(e.data));
+        return;
+      }
       dc = channel;
-      channel.onopen    = () => Logger.info('incoming channel open', { peer: peerId });
+      channel.onopen    = () => {
+        Logger.info('incoming channel open', { peer: peerId });
+        sendLease('request');
+      };
       channel.onmessage = e => {
         const msg = JSON.parse(e.data);
         handleRemoteKey(msg.key, msg.action, peerId);
@@ -330,6 +346,40 @@ function createKeyPressEventListener(key) {
   window.addEventListener('keyup',   handler, true);
 }
 
+// The robot stops all motion if it hears nothing on the keyboard channel
+// for a second (see client/watchdog.go).
+const HEARTBEAT_MS = 250;
+setInterval(() => {
+  if (dc && dc.readyState === 'open') {
+    dc.send(JSON.stringify({ action: 'heartbeat' }));
+  }
+}, HEARTBEAT_MS);
+
+// --- Gamepad ---------------------------------------------------------------
+// Left stick drives (up = forward, left = turn left), right stick pans and
+// tilts the camera, and the triggers raise/lower the arm. Sent as a
+// continuous "drive" message alongside the key protocol.
+const GAMEPAD_MS = 50;
+let lastDrive = '';
+function axis(v) { return Math.abs(v) < 0.05 ? 0 : Math.round(v * 100) / 100; }
+setInterval(() => {
+  const pad = navigator.getGamepads ? Array.from(navigator.getGamepads()).find(p => p) : null;
+  if (!pad || !dc || dc.readyState !== 'open') return;
+  const trigger = i => (pad.buttons[i] ? pad.buttons[i].value : 0);
+  const cmd = {
+    action:  'drive',
+    linear:  axis(-pad.axes[1]),
+    angular: axis(-pad.axes[0]),
+    pan:     axis(-(pad.axes[2] || 0)),
+    tilt:    axis(-(pad.axes[3] || 0)),
+    arm:     axis(trigger(7) - trigger(6)),
+  };
+  const encoded = JSON.stringify(cmd);
+  if (encoded === lastDrive) return;
+  lastDrive = encoded;
+  dc.send(encoded);
+}, GAMEPAD_MS);
+
 function start() {
     joinSession();
 }
@@ -379,18 +429,120 @@ window.addEventListener('DOMContentLoaded', () => {
     });
 });
 
-async function fetchAngles() {
-    // Example: adjust URL and data shape to match your backend
-    const resp = await fetch('/api/servo-angles');
-    if (!resp.ok) return;
-    const data = await resp.json(); // [{channel:4,angle:93.2},...]
+// Telemetry arrives on the robot's "telemetry" data channel
+// (see client/telemetry.go).
+function renderTelemetry(t) {
+    if (t.type !== 'telemetry') return;
     let html = '';
-    for (const servo of data) {
-        html += `<li>Servo ${servo.channel}: <span class="angle">${servo.angle.toFixed(1)}</span>°</li>`;
+    for (const servo of t.servos || []) {
+        let state = '';
+        if (servo.moving && servo.target !== undefined) state += ` → ${servo.target.toFixed(0)}°`;
+        if (servo.limit) state += ` <span class="text-yellow-400">at ${servo.limit}</span>`;
+        if (servo.stopped && !servo.moving) state += ` <span class="text-yellow-400">(${servo.stopped})</span>`;
+        html += `<li>Servo ${servo.name || servo.channel}: <span class="angle">${servo.angle.toFixed(1)}</span>°${state}</li>`;
+    }
+    (t.motors || []).forEach((m, i) => {
+        const dir = m.direction > 0 ? 'fwd' : m.direction < 0 ? 'rev' : 'stop';
+        html += `<li>Motor ${i + 1}: ${dir} ${m.duty.toFixed(0)}%</li>`;
+    });
+    if (t.distanceCm !== undefined) html += `<li>Distance: ${t.distanceCm.toFixed(1)} cm</li>`;
+    if (t.pose) {
+        const deg = t.pose.theta * 180 / Math.PI;
+        html += `<li>Pose: ${t.pose.x.toFixed(2)}, ${t.pose.y.toFixed(2)} m, ${deg.toFixed(0)}°</li>`;
+    }
+    if (t.cpuTempC !== undefined) html += `<li>CPU: ${t.cpuTempC.toFixed(1)} °C</li>`;
+    if (t.link) html += `<li>RTT: ${t.link.rttMs.toFixed(0)} ms</li>`;
+    if (t.safety && t.safety.vetoed) {
+        html += `<li class="text-red-400">Obstacle (${t.safety.reason}): forward ${Math.round(t.safety.factor * 100)}%</li>`;
     }
     document.getElementById('servo-angle-list').innerHTML = html;
+    if (t.mission) renderMissionStatus(t.mission);
+    if (t.control) renderControl(t.control);
+}
+
+// --- Control lease ---------------------------------------------------------
+// One operator drives at a time (see client/lease.go). Everyone asks for
+// control on connect and queues if someone else has it. Open the page with
+// ?admin=<token> to be able to take or revoke control from anyone.
+
+const adminToken = new URLSearchParams(location.search).get('admin') || '';
+
+function operatorName() {
+    let name = localStorage.getItem('robotOperatorName');
+    if (!name) {
+        name = `operator-${myUUID.slice(0, 4)}`;
+        localStorage.setItem('robotOperatorName', name);
+    }
+    return name;
+}
+
+function sendLease(op, extra = {}) {
+    if (!dc || dc.readyState !== 'open') return;
+    dc.send(JSON.stringify({ action: 'lease', op, name: operatorName(), token: adminToken, ...extra }));
+}
+
+function renderControl(c) {
+    const el = document.getElementById('control-status');
+    const mine = c.holder === myUUID;
+    let html = c.holder
+        ? `In control: <b>${escapeHTML(c.holderName)}</b>${mine ? ' (you)' : ''}`
+        : 'Nobody is in control';
+    html += ` &middot; ${c.viewers} watching`;
+    for (const w of c.waiting || []) {
+        html += `<br>Waiting: ${escapeHTML(w.name)}${w.id === myUUID ? ' (you)' : ''}`;
+        if (mine || adminToken) {
+            html += ` <button class="underline" onclick="sendLease('grant', { to: '${w.id}' })">hand over</button>`;
+        }
+    }
+    el.innerHTML = html;
+    document.getElementById('control-request').hidden = mine;
+    document.getElementById('control-release').hidden = !mine;
+    document.getElementById('control-revoke').hidden = !adminToken || !c.holder;
+    document.getElementById('control-buttons').classList.toggle('opacity-50', !mine);
+}
+
+function escapeHTML(s) {
+    const d = document.createElement('div');
+    d.textContent = s;
+    return d.innerHTML;
+}
+
+// Missions go to the robot on its "mission" data channel
+// (see client/mission.go); progress comes back in telemetry.
+const missionChannels = {};
+
+function sendMission(action) {
+    const cmd = { action };
+    if (action === 'start') {
+        try {
+            cmd.mission = JSON.parse(document.getElementById('mission-json').value);
+        } catch (err) {
+            document.getElementById('mission-status').textContent = `Bad mission JSON: ${err.message}`;
+            return;
+        }
+    }
+    for (const ch of Object.values(missionChannels)) {
+        if (ch.readyState === 'open') ch.send(JSON.stringify(cmd));
+    }
+}
+
+function renderMission(reply) {
+    if (reply.type !== 'mission') return;
+    renderMissionStatus(reply.status);
+    if (reply.error) {
+        document.getElementById('mission-status').textContent += ` (${reply.error})`;
+    }
+}
+
+function renderMissionStatus(m) {
+    let text = m.state;
+    if (m.steps) {
+        text = `${m.name || 'mission'}: ${m.state}, step ${m.step + 1}/${m.steps} (${Math.round(m.progress * 100)}%)`;
+    }
+    if (m.error) text += ` - ${m.error}`;
+    if (m.snapshots && m.snapshots.length) text += ` - ${m.snapshots.length} snapshot(s)`;
+    document.getElementById('mission-status').textContent = text;
 }
-setInterval(fetchAngles, 400);
 
 
 async function fetchTurnCredentials() {
@@ -10,6 +10,7 @@ func RobotControlHandler(w http.ResponseWriter, r *http.Request) {
 	page := DefaultLayout(
 		Style(Raw(LoadFile("webrtc/video.css"))),
 		Script(Raw(LoadFile("webrtc/logger.js"))),
+		Script(Raw(LoadFile("webrtc/wstoken.js"))),
 		Script(Raw(LoadFile("webrtc/robot-control.js"))),
 		Div(Attrs(map[string]string{
 			"class":      "flex flex-col items-center justify-center min-h-screen bg-black",
@@ -30,6 +31,15 @@ func RobotControlHandler(w http.ResponseWriter, r *http.Request) {
 					Attr("playsinline", ""),
 				),
 			),
+			// --- Control lease ---
+			Div(
+				Id("control-lease"),
+				Class("mt-4 flex items-center space-x-4 text-gray-200"),
+				Div(Id("control-status"), Class("text-sm")),
+				LeaseButton("control-request", "request", "Request control"),
+				LeaseButton("control-release", "release", "Release"),
+				LeaseButton("control-revoke", "revoke", "Revoke"),
+			),
 			// controls
 			Div(
 				Id("control-buttons"),
@@ -108,9 +118,30 @@ func RobotControlHandler(w http.ResponseWriter, r *http.Request) {
 			Div(
 				Id("servo-angles"),
 				Class("mt-8 bg-gray-900 p-4 rounded-lg shadow text-green-200"),
-				T("Servo Angles:"),
+				T("Telemetry:"),
 				Ul(Id("servo-angle-list")),
 			),
+
+			// --- Missions ---
+			Div(
+				Id("mission"),
+				Class("mt-8 mb-12 w-[640px] bg-gray-900 p-4 rounded-lg shadow text-gray-200 flex flex-col space-y-2"),
+				T("Mission:"),
+				TextArea(
+					Id("mission-json"),
+					Rows(6),
+					Class("w-full bg-black text-green-200 font-mono text-sm p-2 rounded border border-gray-700"),
+					Placeholder(`{"name":"square","steps":[{"type":"drive","distance":0.5},{"type":"turn","angle":90},{"type":"snapshot"}]}`),
+				),
+				Div(
+					Class("flex space-x-2"),
+					MissionButton("start", "Start"),
+					MissionButton("pause", "Pause"),
+					MissionButton("resume", "Resume"),
+					MissionButton("abort", "Abort"),
+				),
+				Div(Id("mission-status"), Class("text-sm text-gray-400")),
+			),
 		),
 	)
 
@@ -119,6 +150,23 @@ func RobotControlHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(page.Render()))
 }
 
+func LeaseButton(id, op, label string) *Node {
+	return Button(
+		Id(id),
+		Class("px-3 py-1 bg-gray-800 text-gray-200 rounded shadow hover:bg-gray-700 transition"),
+		OnClick("sendLease('"+op+"')"),
+		T(label),
+	)
+}
+
+func MissionButton(action, label string) *Node {
+	return Button(
+		Class("px-4 py-1 bg-gray-800 text-gray-200 rounded shadow hover:bg-gray-700 transition"),
+		OnClick("sendMission('"+action+"')"),
+		T(label),
+	)
+}
+
 func ControlButton(key, label string) *Node {
 	return Button(
 		Class(
@@ -166,17 +166,23 @@ func SfuWebsocketHandler(w http.ResponseWriter, r *http.Request) {
 	if room == "" {
 		room = "default"
 	}
-	id := r.URL.Query().Get("id")
+	id, err := wsock.Authorize(r, room, r.URL.Query().Get("id"))
+	if err != nil {
+		log.Printf("[SFU] auth refused room=%s: %v", room, err)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
 	if id == "" {
 		id = randomSFUID()
 	}
 
 	// Reuse your Upgrader (origin check, buffer sizes) & WS durability patterns
-	conn, err := wsock.Upgrader.Upgrade(w, r, nil)
+	conn, release, err := wsock.Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("[SFU] WS upgrade failed: %v", err)
 		return
 	}
+	defer release()
 	log.Printf("[SFU] WS connected room=%s id=%s", room, id)
 
 	pc, err := sfu.api.NewPeerConnection(webrtc.Configuration{ICEServers: sfuIceServers})
@@ -480,15 +486,29 @@ func negotiatorWorker(p *sfuPeer) {
 /* --------------------------- WS read/write pumps --------------------------- */
 
 func writePumpSFU(p *sfuPeer) {
+	ticker := time.NewTicker(wsock.PingPeriod())
 	defer func() {
+		ticker.Stop()
 		close(p.closed)
 		_ = p.conn.Close()
 	}()
 
-	for msg := range p.send {
-		if err := p.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
-			log.Printf("[SFU] write error: %v", err)
-			return
+	for {
+		select {
+		case msg, ok := <-p.send:
+			if !ok {
+				return
+			}
+			_ = p.conn.SetWriteDeadline(time.Now().Add(wsock.WriteWait))
+			if err := p.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+				log.Printf("[SFU] write error: %v", err)
+				return
+			}
+		case <-ticker.C:
+			if err := wsock.Ping(p.conn); err != nil {
+				log.Printf("[SFU] ping error peer=%s: %v", p.id, err)
+				return
+			}
 		}
 	}
 }
@@ -108,11 +108,13 @@ async function maybeMakeOffer() {
 /* ----------------------------------------------------------------------- */
 
 async function connectSFUSocket() {
+    const { userId, token } = await fetchWsToken(ROOM);
+    myUUID = userId;
     const url =
         (location.protocol === "https:" ? "wss://" : "ws://") +
         location.host +
         `/ws/sfu?room=${encodeURIComponent(ROOM)}&id=${encodeURIComponent(myUUID)}`;
-    ws = new WebSocket(url);
+    ws = new WebSocket(withWsToken(url, token));
 
     ws.onopen = async () => {
         Logger.info("[SFU] WS open", { room: ROOM, id: myUUID });
@@ -126,12 +126,15 @@ function showLocalVideo() {
 }
 
 async function connectWebSocket() {
-  ws = new WebSocket(
+  const { userId, token } = await fetchWsToken(ROOM);
+  myUUID = userId;
+  ws = new WebSocket(withWsToken(
     (location.protocol === 'https:' ? 'wss://' : 'ws://') +
     location.host +
     '/ws/hub?room=' + encodeURIComponent(ROOM) +
-    '&playerId=' + encodeURIComponent(myUUID)
-  );
+    '&playerId=' + encodeURIComponent(myUUID),
+    token
+  ));
 
   ws.onopen = () => {
     Logger.info('WebSocket open');
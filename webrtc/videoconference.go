@@ -149,10 +149,11 @@ func VideoPage(w http.ResponseWriter, r *http.Request) {
 	page := DefaultLayout(
 		Style(Raw(LoadFile("webrtc/video.css"))),
 		Script(Raw(LoadFile("webrtc/logger.js"))),
+		Script(Raw(LoadFile("webrtc/wstoken.js"))),
 		Script(Raw(LoadFile("webrtc/"+jsFile))),
 		Script(Raw(LoadFile("webrtc/media-controls.js"))),
 		Attr("hx-ext", "ws"),
-		Attr("ws-connect", wsPath),
+		Attr("ws-connect", wsPath+TokenParam(room, "")),
 		Div(Attrs(map[string]string{
 			"class":      "flex flex-col items-center min-h-screen",
 			"data-theme": "dark",
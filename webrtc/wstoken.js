@@ -0,0 +1,15 @@
+// Fetches a signed websocket token for `room`. The server picks the user id
+// (bound to a session cookie), so callers must use the returned userId as
+// their peer id. token is empty when the server runs without auth.
+async function fetchWsToken(room) {
+  const res = await fetch('/ws/token?room=' + encodeURIComponent(room), { credentials: 'same-origin' });
+  if (!res.ok) {
+    throw new Error('ws token request failed: ' + res.status);
+  }
+  return res.json();
+}
+
+// Appends the token (if any) to a websocket URL.
+function withWsToken(url, token) {
+  return token ? url + '&token=' + encodeURIComponent(token) : url;
+}
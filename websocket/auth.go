@@ -0,0 +1,219 @@
+package websocket
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Token settings. Without WS_TOKEN_SECRET the hub keeps trusting the room
+// and playerId query parameters, which is fine for local development only.
+var (
+	TokenSecret  = []byte(os.Getenv("WS_TOKEN_SECRET"))
+	TokenTTL     = 5 * time.Minute // tokens handed out by /ws/token
+	PageTokenTTL = 12 * time.Hour  // tokens baked into ws-connect URLs, which htmx reuses on reconnect
+	SessionTTL   = 30 * 24 * time.Hour
+)
+
+const (
+	RolePlayer = "player"
+	RoleRobot  = "robot"
+	RoleAdmin  = "admin"
+
+	roleSession   = "session"
+	sessionCookie = "ws_session"
+)
+
+var (
+	errNoToken      = errors.New("missing token")
+	errBadToken     = errors.New("malformed token")
+	errBadSignature = errors.New("bad token signature")
+	errExpired      = errors.New("token expired")
+	errRoom         = errors.New("token not valid for room")
+	errIdentity     = errors.New("token not valid for player id")
+)
+
+// Claims is what a token vouches for. Rooms may contain "*" for any room.
+// An empty Sub is an anonymous connection that cannot claim a player id.
+type Claims struct {
+	Sub   string   `json:"sub"`
+	Rooms []string `json:"rooms"`
+	Role  string   `json:"role"`
+	Exp   int64    `json:"exp"`
+}
+
+func (c *Claims) Allows(room string) bool {
+	for _, r := range c.Rooms {
+		if r == "*" || r == room {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthEnabled reports whether upgrades require a token.
+func AuthEnabled() bool { return len(TokenSecret) > 0 }
+
+// SignToken returns base64url(claims) + "." + base64url(HMAC-SHA256).
+func SignToken(c Claims) (string, error) {
+	if !AuthEnabled() {
+		return "", errors.New("WS_TOKEN_SECRET not set")
+	}
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	body := base64.RawURLEncoding.EncodeToString(payload)
+	return body + "." + sign(body), nil
+}
+
+// IssueToken signs claims for sub valid for ttl.
+func IssueToken(sub, role string, rooms []string, ttl time.Duration) (string, error) {
+	return SignToken(Claims{Sub: sub, Rooms: rooms, Role: role, Exp: time.Now().Add(ttl).Unix()})
+}
+
+func VerifyToken(tok string) (*Claims, error) {
+	body, sig, ok := strings.Cut(tok, ".")
+	if !ok {
+		return nil, errBadToken
+	}
+	if !hmac.Equal([]byte(sig), []byte(sign(body))) {
+		return nil, errBadSignature
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil {
+		return nil, errBadToken
+	}
+	var c Claims
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return nil, errBadToken
+	}
+	if time.Now().Unix() > c.Exp {
+		return nil, errExpired
+	}
+	return &c, nil
+}
+
+func sign(body string) string {
+	mac := hmac.New(sha256.New, TokenSecret)
+	mac.Write([]byte(body))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Authorize checks the token on an upgrade request against the room and
+// player id it asks for and returns the id the client is allowed to use.
+// The token comes from the "token" query parameter (browsers can't set
+// headers on a websocket) or an "Authorization: Bearer" header.
+func Authorize(r *http.Request, room, playerId string) (string, error) {
+	if !AuthEnabled() {
+		return playerId, nil
+	}
+	tok := r.URL.Query().Get("token")
+	if tok == "" {
+		tok = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}
+	if tok == "" {
+		return "", errNoToken
+	}
+	claims, err := VerifyToken(tok)
+	if err != nil {
+		return "", err
+	}
+	if claims.Role == roleSession {
+		return "", errBadToken
+	}
+	if !claims.Allows(room) {
+		return "", errRoom
+	}
+	if claims.Role == RoleAdmin {
+		return playerId, nil
+	}
+	if playerId != "" && playerId != claims.Sub {
+		return "", errIdentity
+	}
+	return claims.Sub, nil
+}
+
+// TokenParam returns "&token=..." for server-rendered ws-connect URLs, or
+// "" when auth is off.
+func TokenParam(room, playerId string) string {
+	if !AuthEnabled() {
+		return ""
+	}
+	tok, err := IssueToken(playerId, RolePlayer, []string{room}, PageTokenTTL)
+	if err != nil {
+		log.Printf("[ERROR] page token: %v", err)
+		return ""
+	}
+	return "&token=" + tok
+}
+
+// TokenHandler serves GET /ws/token?room=... for browser clients. The
+// player id is taken from a signed session cookie (minted on first use), so
+// a client can only ever get tokens for its own id. The "*" room is for
+// robot and admin tokens minted with IssueToken and is never handed out here.
+func TokenHandler(w http.ResponseWriter, r *http.Request) {
+	rooms := r.URL.Query()["room"]
+	if len(rooms) == 0 {
+		http.Error(w, "room not specified", http.StatusBadRequest)
+		return
+	}
+	for _, room := range rooms {
+		if room == "*" {
+			http.Error(w, "wildcard room not allowed", http.StatusForbidden)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if !AuthEnabled() {
+		json.NewEncoder(w).Encode(map[string]interface{}{"userId": uuid.NewString(), "token": ""})
+		return
+	}
+
+	userId := ""
+	if ck, err := r.Cookie(sessionCookie); err == nil {
+		if c, err := VerifyToken(ck.Value); err == nil && c.Role == roleSession {
+			userId = c.Sub
+		}
+	}
+	if userId == "" {
+		userId = uuid.NewString()
+		session, err := IssueToken(userId, roleSession, nil, SessionTTL)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name:     sessionCookie,
+			Value:    session,
+			Path:     "/",
+			MaxAge:   int(SessionTTL.Seconds()),
+			HttpOnly: true,
+			Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+
+	exp := time.Now().Add(TokenTTL)
+	tok, err := SignToken(Claims{Sub: userId, Rooms: rooms, Role: RolePlayer, Exp: exp.Unix()})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"userId":  userId,
+		"token":   tok,
+		"expires": exp.Unix(),
+	})
+}
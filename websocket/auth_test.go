@@ -0,0 +1,98 @@
+package websocket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAuthorize(t *testing.T) {
+	defer func(s []byte) { TokenSecret = s }(TokenSecret)
+	TokenSecret = []byte("test-secret")
+
+	player, _ := IssueToken("p1", RolePlayer, []string{"lobby"}, time.Minute)
+	robot, _ := IssueToken("robot", RoleRobot, []string{"robot"}, time.Hour)
+	expired, _ := IssueToken("p1", RolePlayer, []string{"lobby"}, -time.Minute)
+
+	cases := []struct {
+		name, token, room, playerId string
+		wantId                      string
+		wantErr                     bool
+	}{
+		{"own id", player, "lobby", "p1", "p1", false},
+		{"id from token", player, "lobby", "", "p1", false},
+		{"impersonate robot", player, "robot", "robot", "", true},
+		{"other player", player, "lobby", "p2", "", true},
+		{"wrong room", player, "other", "p1", "", true},
+		{"device", robot, "robot", "robot", "robot", false},
+		{"expired", expired, "lobby", "p1", "", true},
+		{"tampered", player[:len(player)-2] + "xx", "lobby", "p1", "", true},
+		{"missing", "", "lobby", "p1", "", true},
+	}
+	for _, tc := range cases {
+		r := httptest.NewRequest("GET", "/ws/hub?room="+tc.room+"&token="+tc.token, nil)
+		id, err := Authorize(r, tc.room, tc.playerId)
+		if (err != nil) != tc.wantErr || id != tc.wantId {
+			t.Errorf("%s: got (%q, %v)", tc.name, id, err)
+		}
+	}
+}
+
+func TestTokenHandlerKeepsSessionId(t *testing.T) {
+	defer func(s []byte) { TokenSecret = s }(TokenSecret)
+	TokenSecret = []byte("test-secret")
+
+	rec := httptest.NewRecorder()
+	TokenHandler(rec, httptest.NewRequest("GET", "/ws/token?room=robot", nil))
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("expected a session cookie, got %v", cookies)
+	}
+
+	req := httptest.NewRequest("GET", "/ws/token?room=robot", nil)
+	req.AddCookie(cookies[0])
+	rec2 := httptest.NewRecorder()
+	TokenHandler(rec2, req)
+	if len(rec2.Result().Cookies()) != 0 {
+		t.Fatal("session cookie should be reused")
+	}
+
+	session, err := VerifyToken(cookies[0].Value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a session cookie is not itself a websocket token
+	r := httptest.NewRequest("GET", "/ws/hub?room=robot&token="+cookies[0].Value, nil)
+	if _, err := Authorize(r, "robot", session.Sub); err == nil {
+		t.Fatal("session token accepted for upgrade")
+	}
+}
+
+func TestTokenHandlerRefusesWildcard(t *testing.T) {
+	defer func(s []byte) { TokenSecret = s }(TokenSecret)
+	TokenSecret = []byte("test-secret")
+
+	rec := httptest.NewRecorder()
+	TokenHandler(rec, httptest.NewRequest("GET", "/ws/token?room=robot&room=*", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("wildcard token request got %d", rec.Code)
+	}
+}
+
+func TestStampIdentity(t *testing.T) {
+	defer func(s []byte) { TokenSecret = s }(TokenSecret)
+	TokenSecret = []byte("test-secret")
+
+	c := &WebsocketClient{Id: "alice"}
+	msg := map[string]interface{}{"type": "discardCard", "from": "bob", "playerId": "bob"}
+	if from := c.stampIdentity(msg); from != "alice" || msg["from"] != "alice" || msg["playerId"] != "alice" {
+		t.Errorf("sender %q, message %v; want alice throughout", from, msg)
+	}
+
+	anon := &WebsocketClient{}
+	msg = map[string]interface{}{"type": "say", "from": "bob"}
+	if from := anon.stampIdentity(msg); from != "" || msg["from"] != "" {
+		t.Errorf("anonymous client claimed %q", from)
+	}
+}
@@ -0,0 +1,217 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gorilla/websocket"
+	"github.com/tinylib/msgp/msgp"
+)
+
+// Subprotocols a client may ask for in Sec-WebSocket-Protocol. Clients that
+// ask for none get JSON text frames, as before.
+const (
+	ProtocolJSON    = "json"
+	ProtocolMsgpack = "msgpack"
+	ProtocolBinary  = "binary"
+)
+
+// Codec converts between wire frames and what the rest of the package deals
+// in: a command map for handlers on the way in, and hub content (JSON, HTML
+// or a TypedFrame) on the way out. Handlers never see the wire format.
+type Codec interface {
+	Decode(messageType int, frame []byte) (map[string]interface{}, error)
+	Encode(content []byte) (messageType int, frame []byte, err error)
+}
+
+var codecs = map[string]Codec{
+	"":              jsonCodec{},
+	ProtocolJSON:    jsonCodec{},
+	ProtocolMsgpack: msgpackCodec{},
+	ProtocolBinary:  binaryCodec{},
+}
+
+// CodecFor returns the codec for a negotiated subprotocol.
+func CodecFor(subprotocol string) Codec {
+	if c, ok := codecs[subprotocol]; ok {
+		return c
+	}
+	return jsonCodec{}
+}
+
+// --- Typed binary frames ----------------------------------------------------
+
+// typedMarker starts every typed frame. 0xFF never appears in UTF-8, so it
+// can't be confused with JSON or HTML content travelling through the hub.
+const typedMarker = 0xFF
+
+// TypedFrame packs a binary payload (a thumbnail, a sensor frame, ...) as
+// hub content: 0xFF, len(typ), typ, payload. Binary clients receive it as
+// is; JSON and MessagePack clients get {"type": typ, "data": payload}.
+func TypedFrame(typ string, payload []byte) []byte {
+	if len(typ) > 255 {
+		typ = typ[:255]
+	}
+	out := make([]byte, 0, 2+len(typ)+len(payload))
+	out = append(out, typedMarker, byte(len(typ)))
+	out = append(out, typ...)
+	return append(out, payload...)
+}
+
+// ParseTypedFrame is the inverse of TypedFrame.
+func ParseTypedFrame(frame []byte) (typ string, payload []byte, ok bool) {
+	if len(frame) < 2 || frame[0] != typedMarker {
+		return "", nil, false
+	}
+	n := int(frame[1])
+	if len(frame) < 2+n {
+		return "", nil, false
+	}
+	return string(frame[2 : 2+n]), frame[2+n:], true
+}
+
+func typedCommand(typ string, payload []byte) map[string]interface{} {
+	return map[string]interface{}{"type": typ, "data": payload}
+}
+
+// --- JSON -------------------------------------------------------------------
+
+type jsonCodec struct{}
+
+func (jsonCodec) Decode(_ int, frame []byte) (map[string]interface{}, error) {
+	if typ, payload, ok := ParseTypedFrame(frame); ok {
+		return typedCommand(typ, payload), nil
+	}
+	var m map[string]interface{}
+	err := json.Unmarshal(frame, &m)
+	return m, err
+}
+
+func (jsonCodec) Encode(content []byte) (int, []byte, error) {
+	if typ, payload, ok := ParseTypedFrame(content); ok {
+		// []byte marshals as base64
+		raw, err := json.Marshal(typedCommand(typ, payload))
+		return websocket.TextMessage, raw, err
+	}
+	return websocket.TextMessage, content, nil
+}
+
+// --- MessagePack --------------------------------------------------------------
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Decode(messageType int, frame []byte) (map[string]interface{}, error) {
+	if typ, payload, ok := ParseTypedFrame(frame); ok {
+		return typedCommand(typ, payload), nil
+	}
+	v, _, err := msgp.ReadIntfBytes(frame)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := normalize(v).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("msgpack frame is %T, not a map", v)
+	}
+	return m, nil
+}
+
+func (msgpackCodec) Encode(content []byte) (int, []byte, error) {
+	var v interface{}
+	if typ, payload, ok := ParseTypedFrame(content); ok {
+		v = typedCommand(typ, payload)
+	} else if parsed, err := decodeJSON(content); err == nil {
+		v = parsed
+	} else {
+		// HTML fragments and other text go out as a msgpack string
+		v = string(content)
+	}
+	frame, err := msgp.AppendIntf(nil, v)
+	return websocket.BinaryMessage, frame, err
+}
+
+// --- Raw binary -----------------------------------------------------------
+
+// binaryCodec speaks typed frames only. JSON commands are wrapped as type
+// "json" and other text as type "text".
+type binaryCodec struct{}
+
+func (binaryCodec) Decode(_ int, frame []byte) (map[string]interface{}, error) {
+	typ, payload, ok := ParseTypedFrame(frame)
+	if !ok {
+		return nil, errors.New("binary frame without typed header")
+	}
+	if typ == "json" {
+		var m map[string]interface{}
+		err := json.Unmarshal(payload, &m)
+		return m, err
+	}
+	return typedCommand(typ, payload), nil
+}
+
+func (binaryCodec) Encode(content []byte) (int, []byte, error) {
+	if _, _, ok := ParseTypedFrame(content); ok {
+		return websocket.BinaryMessage, content, nil
+	}
+	if json.Valid(content) {
+		return websocket.BinaryMessage, TypedFrame("json", content), nil
+	}
+	return websocket.BinaryMessage, TypedFrame("text", content), nil
+}
+
+// decodeJSON keeps integers as int64 so they survive as msgpack ints.
+func decodeJSON(content []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("trailing data")
+	}
+	return numbers(v), nil
+}
+
+func numbers(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		f, _ := t.Float64()
+		return f
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = numbers(e)
+		}
+	case []interface{}:
+		for i, e := range t {
+			t[i] = numbers(e)
+		}
+	}
+	return v
+}
+
+// normalize makes msgpack values look like encoding/json output (numbers
+// as float64) so handlers can type-assert the same way for every codec.
+func normalize(v interface{}) interface{} {
+	switch t := v.(type) {
+	case int64:
+		return float64(t)
+	case uint64:
+		return float64(t)
+	case float32:
+		return float64(t)
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = normalize(e)
+		}
+	case []interface{}:
+		for i, e := range t {
+			t[i] = normalize(e)
+		}
+	}
+	return v
+}
@@ -0,0 +1,91 @@
+package websocket
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestCodecsDecodeToSameCommand(t *testing.T) {
+	packed, _ := msgp.AppendIntf(nil, map[string]interface{}{"type": "replay", "since": int64(4)})
+	frames := map[string][]byte{
+		ProtocolJSON:    []byte(`{"type":"replay","since":4}`),
+		ProtocolMsgpack: packed,
+		ProtocolBinary:  TypedFrame("json", []byte(`{"type":"replay","since":4}`)),
+	}
+	for proto, frame := range frames {
+		m, err := CodecFor(proto).Decode(websocket.BinaryMessage, frame)
+		if err != nil {
+			t.Fatalf("%s: %v", proto, err)
+		}
+		if m["type"] != "replay" || m["since"] != float64(4) {
+			t.Errorf("%s: got %#v", proto, m)
+		}
+	}
+}
+
+func TestCodecsEncode(t *testing.T) {
+	thumb := TypedFrame("thumbnail", []byte{0x89, 'P', 'N', 'G'})
+
+	mt, frame, _ := CodecFor("").Encode(thumb)
+	if mt != websocket.TextMessage || string(frame) != `{"data":"iVBORw==","type":"thumbnail"}` {
+		t.Errorf("json: %d %s", mt, frame)
+	}
+
+	mt, frame, _ = CodecFor(ProtocolBinary).Encode(thumb)
+	if mt != websocket.BinaryMessage || !bytes.Equal(frame, thumb) {
+		t.Errorf("binary: %d %v", mt, frame)
+	}
+	_, frame, _ = CodecFor(ProtocolBinary).Encode([]byte(`<div id="x"></div>`))
+	if typ, payload, _ := ParseTypedFrame(frame); typ != "text" || string(payload) != `<div id="x"></div>` {
+		t.Errorf("binary html: %q %q", typ, payload)
+	}
+
+	mt, frame, _ = CodecFor(ProtocolMsgpack).Encode([]byte(`{"seq":3,"angle":90.5}`))
+	v, _, err := msgp.ReadIntfBytes(frame)
+	if err != nil || mt != websocket.BinaryMessage {
+		t.Fatalf("msgpack: %v", err)
+	}
+	m := v.(map[string]interface{})
+	if m["seq"] != int64(3) || m["angle"] != 90.5 {
+		t.Errorf("msgpack: %#v", m)
+	}
+}
+
+func TestSubprotocolNegotiation(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, release, err := Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer release()
+		defer conn.Close()
+		mt, frame, _ := CodecFor(conn.Subprotocol()).Encode([]byte(`{"type":"hello"}`))
+		conn.WriteMessage(mt, frame)
+	}))
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	dialer := websocket.Dialer{Subprotocols: []string{ProtocolMsgpack}}
+	conn, _, err := dialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if conn.Subprotocol() != ProtocolMsgpack {
+		t.Fatalf("negotiated %q", conn.Subprotocol())
+	}
+	mt, frame, err := conn.ReadMessage()
+	if err != nil || mt != websocket.BinaryMessage {
+		t.Fatalf("read: %d %v", mt, err)
+	}
+	m, err := CodecFor(ProtocolMsgpack).Decode(mt, frame)
+	if err != nil || m["type"] != "hello" {
+		t.Fatalf("got %#v %v", m, err)
+	}
+}
@@ -0,0 +1,215 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// Every message the hub delivers to a room that keeps history gets the next
+// sequence number for that room so clients can spot gaps and ask for a
+// {"type":"replay","since":N}. Other rooms' messages go out as they are. JSON object payloads carry it as a "seq"
+// field; HTML payloads start with a <!--seq:N--> comment, which htmx skips
+// when it swaps the fragment in.
+
+// HistoryTTL is how long a room's history outlives its last client, so a
+// player who drops out of an otherwise empty room still gets a replay.
+var HistoryTTL = 10 * time.Minute
+
+type historyEntry struct {
+	seq     uint64
+	id      string
+	content []byte
+}
+
+// roomHistory is a fixed-size ring of a room's most recent messages.
+type roomHistory struct {
+	entries []historyEntry
+	next    int
+}
+
+func newRoomHistory(size int) *roomHistory {
+	return &roomHistory{entries: make([]historyEntry, 0, size)}
+}
+
+func (r *roomHistory) add(e historyEntry) {
+	if len(r.entries) < cap(r.entries) {
+		r.entries = append(r.entries, e)
+		return
+	}
+	r.entries[r.next] = e
+	r.next = (r.next + 1) % len(r.entries)
+}
+
+// since returns, oldest first, the messages after seq that client id is
+// allowed to see: room-wide ones and those addressed to it.
+func (r *roomHistory) since(seq uint64, id string) [][]byte {
+	var out [][]byte
+	n := len(r.entries)
+	for i := 0; i < n; i++ {
+		e := r.entries[(r.next+i)%n]
+		if e.seq <= seq || (e.id != "" && e.id != id) {
+			continue
+		}
+		out = append(out, e.content)
+	}
+	return out
+}
+
+// SetHistory keeps the last size messages of every room opened through the
+// given namespace, which is the websocket path (e.g. "/ws/lobby"). A size of
+// zero turns history off.
+func (h *Hub) SetHistory(namespace string, size int) {
+	h.Mu.Lock()
+	defer h.Mu.Unlock()
+	h.initHistory()
+	if size <= 0 {
+		delete(h.historySize, namespace)
+		return
+	}
+	h.historySize[namespace] = size
+}
+
+// Seq reports the sequence number of the last message delivered to room, or
+// zero if it doesn't keep history.
+func (h *Hub) Seq(room string) uint64 {
+	h.Mu.Lock()
+	defer h.Mu.Unlock()
+	return h.seqs[room]
+}
+
+// Replay queues for c every recorded message in its room after since.
+func (h *Hub) Replay(c *WebsocketClient, since uint64) {
+	h.Mu.Lock()
+	defer h.Mu.Unlock()
+	h.replayLocked(c, since)
+}
+
+func (h *Hub) replayLocked(c *WebsocketClient, since uint64) {
+	hist, ok := h.history[c.Room]
+	if !ok {
+		return
+	}
+	for _, content := range hist.since(since, c.Id) {
+		select {
+		case c.Send <- content:
+		default:
+			logInfo("replay truncated", map[string]interface{}{"room": c.Room, "id": c.Id})
+			return
+		}
+	}
+}
+
+func (h *Hub) initHistory() {
+	if h.seqs == nil {
+		h.seqs = make(map[string]uint64)
+		h.historySize = make(map[string]int)
+		h.roomNamespace = make(map[string]string)
+		h.history = make(map[string]*roomHistory)
+		h.emptied = make(map[string]time.Time)
+	}
+}
+
+// emptiedLocked is called when the last client leaves room. A room that
+// keeps history is kept for HistoryTTL; any other is forgotten at once.
+// Caller holds h.Mu.
+func (h *Hub) emptiedLocked(room string, now time.Time) {
+	if h.historySize[h.roomNamespace[room]] > 0 {
+		h.emptied[room] = now
+		return
+	}
+	h.forgetLocked(room)
+}
+
+// pruneHistory forgets rooms that have been empty for longer than
+// HistoryTTL. Caller holds h.Mu.
+func (h *Hub) pruneHistory(now time.Time) {
+	for room, at := range h.emptied {
+		if now.Sub(at) >= HistoryTTL {
+			h.forgetLocked(room)
+		}
+	}
+}
+
+// forgetLocked drops everything the hub keeps about room. Caller holds
+// h.Mu.
+func (h *Hub) forgetLocked(room string) {
+	delete(h.emptied, room)
+	delete(h.history, room)
+	delete(h.seqs, room)
+	delete(h.roomNamespace, room)
+}
+
+// record assigns msg its sequence number and stores it if the room keeps
+// history, and returns the content to send. Caller holds h.Mu.
+func (h *Hub) record(msg WebsocketMessage) []byte {
+	h.initHistory()
+	size := h.historySize[h.roomNamespace[msg.Room]]
+	if size == 0 {
+		return msg.Content
+	}
+	h.seqs[msg.Room]++
+	seq := h.seqs[msg.Room]
+	content := stamp(msg.Content, seq)
+
+	hist, ok := h.history[msg.Room]
+	if !ok {
+		hist = newRoomHistory(size)
+		h.history[msg.Room] = hist
+	}
+	hist.add(historyEntry{seq: seq, id: msg.Id, content: content})
+	return content
+}
+
+// stamp gives content its sequence number: a "seq" field on JSON objects,
+// replacing any the payload already had, and a leading comment on HTML.
+// Other payloads have nowhere to carry it and are sent as they are.
+func stamp(content []byte, seq uint64) []byte {
+	trimmed := bytes.TrimSpace(content)
+	if len(trimmed) > 0 && trimmed[0] == '<' {
+		if bytes.HasPrefix(trimmed, []byte("<!--seq:")) {
+			if end := bytes.Index(trimmed, []byte("-->")); end >= 0 {
+				trimmed = trimmed[end+3:]
+			}
+		}
+		out := make([]byte, 0, len(trimmed)+24)
+		out = append(out, "<!--seq:"...)
+		out = strconv.AppendUint(out, seq, 10)
+		out = append(out, "-->"...)
+		return append(out, trimmed...)
+	}
+	if len(trimmed) < 2 || trimmed[0] != '{' || !json.Valid(trimmed) {
+		return content
+	}
+	out := make([]byte, 0, len(trimmed)+24)
+	out = append(out, `{"seq":`...)
+	out = strconv.AppendUint(out, seq, 10)
+	for _, member := range members(trimmed) {
+		out = append(out, ',')
+		out = append(out, member...)
+	}
+	return append(out, '}')
+}
+
+// members splits a valid JSON object into its "key":value members, in
+// order and without any "seq" it already had.
+func members(obj []byte) [][]byte {
+	dec := json.NewDecoder(bytes.NewReader(obj))
+	dec.Token() // {
+	var out [][]byte
+	for dec.More() {
+		start := dec.InputOffset()
+		key, _ := dec.Token()
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			break
+		}
+		if key == "seq" {
+			continue
+		}
+		member := bytes.TrimLeft(obj[start:dec.InputOffset()], " \t\r\n,")
+		out = append(out, member)
+	}
+	return out
+}
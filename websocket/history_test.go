@@ -0,0 +1,128 @@
+package websocket
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestStamp(t *testing.T) {
+	cases := map[string]string{
+		`{"type":"join"}`:                   `{"seq":7,"type":"join"}`,
+		`{}`:                                `{"seq":7}`,
+		`{"seq":3,"a":1}`:                   `{"seq":7,"a":1}`,
+		`{"a":[1,2], "seq":3 ,"b":{"c":2}}`: `{"seq":7,"a":[1,2],"b":{"c":2}}`,
+		`<div>card</div>`:                   `<!--seq:7--><div>card</div>`,
+		`<!--seq:3--><div>card</div>`:       `<!--seq:7--><div>card</div>`,
+		`{not json`:                         `{not json`,
+		`[1,2]`:                             `[1,2]`,
+	}
+	for in, want := range cases {
+		if got := string(stamp([]byte(in), 7)); got != want {
+			t.Errorf("stamp(%s) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestReplaySince(t *testing.T) {
+	hub := NewHub(nil)
+	hub.SetHistory("/ws/lobby", 3)
+	go hub.Run()
+
+	p1 := &WebsocketClient{Send: make(chan []byte, 16), Room: "g", Id: "p1", Namespace: "/ws/lobby"}
+	hub.Register <- p1
+
+	for i := 1; i <= 4; i++ {
+		hub.Broadcast <- WebsocketMessage{Room: "g", Content: []byte(fmt.Sprintf(`{"n":%d}`, i))}
+	}
+	hub.Broadcast <- WebsocketMessage{Room: "g", Content: []byte(`{"hand":"p2"}`), Id: "p2"}
+	for i := 0; i < 4; i++ {
+		recv(t, p1)
+	}
+	// the message for p2 reaches no one here, so wait for the hub to take it
+	deadline := time.Now().Add(time.Second)
+	for hub.Seq("g") != 5 {
+		if time.Now().After(deadline) {
+			t.Fatalf("seq = %d, want 5", hub.Seq("g"))
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// p2 reconnects having seen up to seq 2; only the last 3 messages are
+	// kept, and p2 may see its own hand.
+	p2 := &WebsocketClient{Send: make(chan []byte, 16), Room: "g", Id: "p2", Namespace: "/ws/lobby", replay: true, since: 2}
+	hub.Register <- p2
+	want := []string{`{"seq":3,"n":3}`, `{"seq":4,"n":4}`, `{"seq":5,"hand":"p2"}`}
+	for _, w := range want {
+		if got := string(recv(t, p2)); got != w {
+			t.Fatalf("replay got %s, want %s", got, w)
+		}
+	}
+
+	// p1 asking for everything must not see p2's hand.
+	hub.Replay(p1, 0)
+	for _, w := range []string{`{"seq":3,"n":3}`, `{"seq":4,"n":4}`} {
+		if got := string(recv(t, p1)); got != w {
+			t.Fatalf("replay got %s, want %s", got, w)
+		}
+	}
+	select {
+	case msg := <-p1.Send:
+		t.Fatalf("unexpected %s", msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestHistoryOutlivesEmptyRoom(t *testing.T) {
+	hub := NewHub(nil)
+	hub.SetHistory("/ws/lobby", 8)
+	go hub.Run()
+
+	p1 := &WebsocketClient{Send: make(chan []byte, 16), Room: "solo", Id: "p1", Namespace: "/ws/lobby"}
+	hub.Register <- p1
+	hub.Broadcast <- WebsocketMessage{Room: "solo", Content: []byte(`<div id="hand">A</div>`)}
+	recv(t, p1)
+	hub.Unregister <- p1
+
+	p1 = &WebsocketClient{Send: make(chan []byte, 16), Room: "solo", Id: "p1", Namespace: "/ws/lobby", replay: true}
+	hub.Register <- p1
+	if got := string(recv(t, p1)); got != `<!--seq:1--><div id="hand">A</div>` {
+		t.Fatalf("replay got %s", got)
+	}
+
+	// once the room has been empty for HistoryTTL its history goes
+	hub.Unregister <- p1
+	for range p1.Send {
+	}
+	hub.Mu.Lock()
+	hub.pruneHistory(time.Now())
+	_, kept := hub.history["solo"]
+	hub.pruneHistory(time.Now().Add(HistoryTTL))
+	_, expired := hub.history["solo"]
+	leftover := len(hub.seqs) + len(hub.roomNamespace) + len(hub.emptied)
+	hub.Mu.Unlock()
+	if !kept || expired || leftover != 0 {
+		t.Errorf("history kept %v, still there after HistoryTTL %v, %d entries left", kept, expired, leftover)
+	}
+}
+
+func TestNoHistoryNoSeq(t *testing.T) {
+	hub := NewHub(nil)
+	hub.SetHistory("/ws/lobby", 8)
+	go hub.Run()
+
+	c := &WebsocketClient{Send: make(chan []byte, 4), Room: "chat", Id: "p1", Namespace: "/ws/chat"}
+	hub.Register <- c
+	hub.Broadcast <- WebsocketMessage{Room: "chat", Content: []byte(`{"type":"say"}`)}
+	if got := string(recv(t, c)); got != `{"type":"say"}` {
+		t.Errorf("got %s from a room without history", got)
+	}
+	hub.Unregister <- c
+	for range c.Send {
+	}
+	hub.Mu.Lock()
+	defer hub.Mu.Unlock()
+	if len(hub.seqs)+len(hub.roomNamespace)+len(hub.emptied) != 0 {
+		t.Errorf("an empty room without history left seqs %v, namespaces %v", hub.seqs, hub.roomNamespace)
+	}
+}
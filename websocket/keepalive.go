@@ -0,0 +1,178 @@
+package websocket
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Keepalive and limit settings shared by every socket the server upgrades.
+// Override them before serving.
+var (
+	PongWait             = 60 * time.Second
+	WriteWait            = 10 * time.Second
+	MaxMessageSize int64 = 512 * 1024
+	MaxConnsPerIP        = 32
+)
+
+// PingPeriod is how often to ping a peer: often enough that its pong lands
+// inside PongWait.
+func PingPeriod() time.Duration { return (PongWait * 9) / 10 }
+
+// TrustedProxies are the reverse proxies whose X-Forwarded-For and X-Real-IP
+// headers are believed. main sets them from WS_TRUSTED_PROXIES; with none,
+// the socket address is the client.
+var TrustedProxies []netip.Prefix
+
+// ParseProxies reads a comma-separated list of IPs and CIDR prefixes.
+func ParseProxies(list string) ([]netip.Prefix, error) {
+	var out []netip.Prefix
+	for _, f := range strings.Split(list, ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		if !strings.Contains(f, "/") {
+			addr, err := netip.ParseAddr(f)
+			if err != nil {
+				return nil, fmt.Errorf("trusted proxy %q: %w", f, err)
+			}
+			out = append(out, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		p, err := netip.ParsePrefix(f)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q: %w", f, err)
+		}
+		out = append(out, p.Masked())
+	}
+	return out, nil
+}
+
+func trustedProxy(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range TrustedProxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+type connLimiter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+var ipConns = &connLimiter{counts: make(map[string]int)}
+
+var errTooManyConns = errors.New("too many connections from this address")
+
+func (l *connLimiter) acquire(ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if MaxConnsPerIP > 0 && l.counts[ip] >= MaxConnsPerIP {
+		return false
+	}
+	l.counts[ip]++
+	return true
+}
+
+func (l *connLimiter) release(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.counts[ip] <= 1 {
+		delete(l.counts, ip)
+		return
+	}
+	l.counts[ip]--
+}
+
+// clientIP is the socket address, unless that is a trusted proxy: then it
+// is the nearest untrusted address in X-Forwarded-For, or X-Real-IP.
+// Anyone else could put any address they like in those headers.
+func clientIP(r *http.Request) string {
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+	if !trustedProxy(remote) {
+		return remote
+	}
+	if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+		hops := strings.Split(fwd, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			ip := strings.TrimSpace(hops[i])
+			if ip != "" && !trustedProxy(ip) {
+				return ip
+			}
+		}
+	}
+	if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); ip != "" {
+		return ip
+	}
+	return remote
+}
+
+// Upgrade enforces the per-IP connection cap, upgrades with Upgrader and
+// applies the read limit and keepalive deadlines. release must be called
+// once the connection is finished with.
+func Upgrade(w http.ResponseWriter, r *http.Request, header http.Header) (*websocket.Conn, func(), error) {
+	ip := clientIP(r)
+	if !ipConns.acquire(ip) {
+		logInfo("connection cap reached", map[string]interface{}{"ip": ip})
+		http.Error(w, "too many connections", http.StatusTooManyRequests)
+		return nil, nil, errTooManyConns
+	}
+	conn, err := Upgrader.Upgrade(w, r, header)
+	if err != nil {
+		ipConns.release(ip)
+		return nil, nil, err
+	}
+	KeepAlive(conn)
+	var once sync.Once
+	return conn, func() { once.Do(func() { ipConns.release(ip) }) }, nil
+}
+
+// KeepAlive sets the read limit and extends the read deadline each time the
+// peer answers a ping, so half-open connections error out of ReadMessage.
+func KeepAlive(conn *websocket.Conn) {
+	conn.SetReadLimit(MaxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(PongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(PongWait))
+	})
+}
+
+// Ping sends a ping frame under the write deadline. Control frames may be
+// written concurrently with a writer goroutine.
+func Ping(conn *websocket.Conn) error {
+	return conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(WriteWait))
+}
+
+// PingLoop pings conn every PingPeriod() until done is closed or a ping fails.
+func PingLoop(conn *websocket.Conn, done <-chan struct{}) {
+	ticker := time.NewTicker(PingPeriod())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := Ping(conn); err != nil {
+				return
+			}
+		}
+	}
+}
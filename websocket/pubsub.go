@@ -0,0 +1,304 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// PubSub carries room broadcasts between hub instances. A hub publishes every
+// message it is asked to broadcast and only delivers to its local clients what
+// comes back through its subscription, so all instances sharing a backend see
+// the same stream.
+type PubSub interface {
+	Publish(msg WebsocketMessage) error
+	Subscribe(handler func(WebsocketMessage))
+	Close() error
+}
+
+// --- In-memory ------------------------------------------------------------
+
+// MemoryPubSub keeps broadcasts inside the current process.
+type MemoryPubSub struct {
+	mu       sync.RWMutex
+	handlers []func(WebsocketMessage)
+}
+
+func NewMemoryPubSub() *MemoryPubSub {
+	return &MemoryPubSub{}
+}
+
+func (m *MemoryPubSub) Publish(msg WebsocketMessage) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, h := range m.handlers {
+		h(msg)
+	}
+	return nil
+}
+
+func (m *MemoryPubSub) Subscribe(handler func(WebsocketMessage)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers = append(m.handlers, handler)
+}
+
+func (m *MemoryPubSub) Close() error { return nil }
+
+// --- TCP fan-out ----------------------------------------------------------
+
+// wireMessage is the broker framing. Content is kept as bytes because rooms
+// carry rendered HTML as well as JSON.
+type wireMessage struct {
+	Room    string `json:"room"`
+	Id      string `json:"id,omitempty"`
+	Content []byte `json:"content"`
+}
+
+// BrokerQueue is how many lines the broker buffers for each hub.
+var BrokerQueue = 256
+
+// Broker relays every line it receives to all connected hubs, including the
+// sender. Run one per deployment (or embed it in one of the servers).
+type Broker struct {
+	ln    net.Listener
+	mu    sync.Mutex
+	conns map[net.Conn]*brokerHub
+}
+
+// brokerHub is one connected hub's outbound queue.
+type brokerHub struct {
+	out  chan []byte
+	done chan struct{} // closed when the hub is dropped
+}
+
+// ListenBroker starts a broker on addr; call Serve to accept hubs.
+func ListenBroker(addr string) (*Broker, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &Broker{ln: ln, conns: make(map[net.Conn]*brokerHub)}, nil
+}
+
+// Addr is the address the broker is listening on.
+func (b *Broker) Addr() string { return b.ln.Addr().String() }
+
+func (b *Broker) Serve() error {
+	for {
+		conn, err := b.ln.Accept()
+		if err != nil {
+			return err
+		}
+		h := &brokerHub{out: make(chan []byte, BrokerQueue), done: make(chan struct{})}
+		b.mu.Lock()
+		b.conns[conn] = h
+		b.mu.Unlock()
+		go b.write(conn, h)
+		go b.relay(conn)
+	}
+}
+
+// relay queues every line conn sends for every hub. A hub whose queue stays
+// full for WriteWait is dropped, so one stuck hub can't hold up the rest for
+// longer than that; it redials and carries on.
+func (b *Broker) relay(conn net.Conn) {
+	defer b.drop(conn)
+
+	sc := bufio.NewScanner(conn)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		line := append(append([]byte(nil), sc.Bytes()...), '\n')
+		b.mu.Lock()
+		hubs := make(map[net.Conn]*brokerHub, len(b.conns))
+		for c, h := range b.conns {
+			hubs[c] = h
+		}
+		b.mu.Unlock()
+		for c, h := range hubs {
+			select {
+			case h.out <- line:
+				continue
+			case <-h.done:
+				continue
+			default:
+			}
+			timer := time.NewTimer(WriteWait)
+			select {
+			case h.out <- line:
+			case <-h.done:
+			case <-timer.C:
+				logError("broker", errors.New("hub fell behind; disconnecting it"), map[string]interface{}{"peer": c.RemoteAddr().String()})
+				b.drop(c)
+			}
+			timer.Stop()
+		}
+	}
+}
+
+// write sends a hub its queued lines, each within WriteWait.
+func (b *Broker) write(conn net.Conn, h *brokerHub) {
+	for {
+		select {
+		case <-h.done:
+			return
+		case line := <-h.out:
+			conn.SetWriteDeadline(time.Now().Add(WriteWait))
+			if _, err := conn.Write(line); err != nil {
+				logError("broker write", err, map[string]interface{}{"peer": conn.RemoteAddr().String()})
+				b.drop(conn)
+				return
+			}
+		}
+	}
+}
+
+// drop disconnects a hub, once.
+func (b *Broker) drop(conn net.Conn) {
+	b.mu.Lock()
+	h, ok := b.conns[conn]
+	delete(b.conns, conn)
+	b.mu.Unlock()
+	if ok {
+		close(h.done)
+		conn.Close()
+	}
+}
+
+func (b *Broker) Close() error {
+	b.mu.Lock()
+	conns := make([]net.Conn, 0, len(b.conns))
+	for c := range b.conns {
+		conns = append(conns, c)
+	}
+	b.mu.Unlock()
+	for _, c := range conns {
+		b.drop(c)
+	}
+	return b.ln.Close()
+}
+
+// TCPPubSub connects a hub to a Broker so rooms span several server
+// instances. The connection is re-dialled if the broker goes away; until
+// it's back, messages reach this instance's clients only.
+type TCPPubSub struct {
+	addr string
+
+	mu       sync.Mutex
+	conn     net.Conn // nil while the broker is unreachable
+	handlers []func(WebsocketMessage)
+	closed   bool
+}
+
+// ErrBrokerDown is what Publish returns for a message it could only
+// deliver to this instance.
+var ErrBrokerDown = errors.New("pubsub broker unreachable; delivered locally only")
+
+func NewTCPPubSub(addr string) (*TCPPubSub, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	p := &TCPPubSub{addr: addr, conn: conn}
+	go p.readLoop(conn)
+	return p, nil
+}
+
+func (p *TCPPubSub) Publish(msg WebsocketMessage) error {
+	raw, err := json.Marshal(wireMessage{Room: msg.Room, Id: msg.Id, Content: msg.Content})
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	conn := p.conn
+	if conn != nil {
+		conn.SetWriteDeadline(time.Now().Add(WriteWait))
+		if _, err := conn.Write(append(raw, '\n')); err == nil {
+			p.mu.Unlock()
+			return nil
+		}
+	}
+	handlers := p.handlers
+	p.mu.Unlock()
+	// the broker would have echoed it back; without one, this instance's
+	// clients still get it
+	for _, h := range handlers {
+		h(msg)
+	}
+	return ErrBrokerDown
+}
+
+func (p *TCPPubSub) Subscribe(handler func(WebsocketMessage)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.handlers = append(p.handlers, handler)
+}
+
+func (p *TCPPubSub) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	if p.conn == nil {
+		return nil
+	}
+	return p.conn.Close()
+}
+
+func (p *TCPPubSub) readLoop(conn net.Conn) {
+	sc := bufio.NewScanner(conn)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		var w wireMessage
+		if err := json.Unmarshal(sc.Bytes(), &w); err != nil {
+			logError("pubsub decode", err, nil)
+			continue
+		}
+		msg := WebsocketMessage{Room: w.Room, Id: w.Id, Content: w.Content}
+		p.mu.Lock()
+		handlers := p.handlers
+		p.mu.Unlock()
+		for _, h := range handlers {
+			h(msg)
+		}
+	}
+	p.mu.Lock()
+	if p.conn == conn {
+		p.conn = nil
+	}
+	p.mu.Unlock()
+	conn.Close()
+	p.redial()
+}
+
+// redial keeps trying the broker once a second until it answers or the
+// pubsub is closed.
+func (p *TCPPubSub) redial() {
+	for {
+		p.mu.Lock()
+		closed := p.closed
+		p.mu.Unlock()
+		if closed {
+			return
+		}
+		logInfo("pubsub reconnecting", map[string]interface{}{"broker": p.addr})
+		conn, err := net.Dial("tcp", p.addr)
+		if err != nil {
+			time.Sleep(time.Second)
+			continue
+		}
+		p.mu.Lock()
+		if p.closed {
+			// closed while we were dialling
+			p.mu.Unlock()
+			conn.Close()
+			return
+		}
+		p.conn = conn
+		p.mu.Unlock()
+		go p.readLoop(conn)
+		return
+	}
+}
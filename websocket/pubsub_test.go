@@ -0,0 +1,214 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"testing"
+	"time"
+)
+
+func recv(t *testing.T, c *WebsocketClient) []byte {
+	t.Helper()
+	select {
+	case msg := <-c.Send:
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatalf("client %s in %s got nothing", c.Id, c.Room)
+		return nil
+	}
+}
+
+func TestMemoryHubBroadcast(t *testing.T) {
+	hub := NewHub(nil)
+	go hub.Run()
+
+	a := &WebsocketClient{Send: make(chan []byte, 4), Room: "r1", Id: "a"}
+	b := &WebsocketClient{Send: make(chan []byte, 4), Room: "r1", Id: "b"}
+	hub.Register <- a
+	hub.Register <- b
+
+	hub.Broadcast <- WebsocketMessage{Room: "r1", Content: []byte("<div>hi</div>")}
+	if got := string(recv(t, a)); got != "<div>hi</div>" {
+		t.Fatalf("a got %q", got)
+	}
+	if got := string(recv(t, b)); got != "<div>hi</div>" {
+		t.Fatalf("b got %q", got)
+	}
+
+	hub.Broadcast <- WebsocketMessage{Room: "r1", Content: []byte("only b"), Id: "b"}
+	if got := string(recv(t, b)); got != "only b" {
+		t.Fatalf("b got %q", got)
+	}
+	select {
+	case msg := <-a.Send:
+		t.Fatalf("a should not get targeted message, got %q", msg)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestBrokerSpansHubs(t *testing.T) {
+	broker, err := ListenBroker("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer broker.Close()
+	go broker.Serve()
+
+	var hubs []*Hub
+	for i := 0; i < 2; i++ {
+		ps, err := NewTCPPubSub(broker.Addr())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ps.Close()
+		hub := NewHub(ps)
+		go hub.Run()
+		hubs = append(hubs, hub)
+	}
+
+	local := &WebsocketClient{Send: make(chan []byte, 4), Room: "lobby", Id: "p1"}
+	remote := &WebsocketClient{Send: make(chan []byte, 4), Room: "lobby", Id: "p2"}
+	hubs[0].Register <- local
+	hubs[1].Register <- remote
+
+	// wait for both dials to be registered with the broker
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		broker.mu.Lock()
+		n := len(broker.conns)
+		broker.mu.Unlock()
+		if n == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("broker has %d conns", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	hubs[0].Broadcast <- WebsocketMessage{Room: "lobby", Content: []byte(`{"type":"start"}`)}
+	if got := string(recv(t, local)); got != `{"type":"start"}` {
+		t.Fatalf("local got %q", got)
+	}
+	if got := string(recv(t, remote)); got != `{"type":"start"}` {
+		t.Fatalf("remote got %q", got)
+	}
+}
+
+func TestBrokerDropsStuckHub(t *testing.T) {
+	defer func(n int, d time.Duration) { BrokerQueue, WriteWait = n, d }(BrokerQueue, WriteWait)
+	BrokerQueue, WriteWait = 4, 200*time.Millisecond
+	broker, err := ListenBroker("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer broker.Close()
+	go broker.Serve()
+
+	// stuck never reads; reader and sender are healthy hubs
+	stuck, err := net.Dial("tcp", broker.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stuck.Close()
+	reader, err := net.Dial("tcp", broker.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	sender, err := net.Dial("tcp", broker.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sender.Close()
+	for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		broker.mu.Lock()
+		n := len(broker.conns)
+		broker.mu.Unlock()
+		if n == 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("broker has %d conns", n)
+		}
+	}
+
+	const lines = 32000 // far more than the socket buffers hold
+	line := append(bytes.Repeat([]byte("x"), 1024), '\n')
+	go func() {
+		sc := bufio.NewScanner(sender)
+		sc.Buffer(make([]byte, 64*1024), 1<<20)
+		for sc.Scan() {
+		}
+	}()
+	go func() {
+		for i := 0; i < lines; i++ {
+			sender.Write(line)
+		}
+	}()
+
+	reader.SetReadDeadline(time.Now().Add(5 * time.Second))
+	sc := bufio.NewScanner(reader)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	got := 0
+	for got < lines && sc.Scan() {
+		got++
+	}
+	if got != lines {
+		t.Fatalf("reader got %d of %d lines: %v", got, lines, sc.Err())
+	}
+	for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		broker.mu.Lock()
+		n := len(broker.conns)
+		broker.mu.Unlock()
+		if n == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("broker still has %d conns; the stuck hub should have been dropped", n)
+		}
+	}
+}
+
+func TestTCPPubSubBrokerDown(t *testing.T) {
+	broker, err := ListenBroker("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go broker.Serve()
+	ps, err := NewTCPPubSub(broker.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ps.Close()
+	got := make(chan WebsocketMessage, 1)
+	ps.Subscribe(func(msg WebsocketMessage) { got <- msg })
+
+	broker.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		ps.mu.Lock()
+		down := ps.conn == nil
+		ps.mu.Unlock()
+		if down {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("pubsub didn't notice the broker going")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := ps.Publish(WebsocketMessage{Room: "lobby", Content: []byte(`{}`)}); err != ErrBrokerDown {
+		t.Errorf("Publish: %v", err)
+	}
+	select {
+	case msg := <-got:
+		if msg.Room != "lobby" {
+			t.Errorf("got %+v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Error("message lost while the broker was down")
+	}
+}
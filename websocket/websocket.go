@@ -2,11 +2,12 @@ package websocket
 
 import (
 	"encoding/json"
-	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
@@ -25,6 +26,16 @@ type WebsocketClient struct {
 	Registry *CommandRegistry
 	Room     string
 	Id       string
+	// Namespace is the websocket path the client connected through; it
+	// decides whether its room keeps history.
+	Namespace string
+
+	// Codec is the wire format negotiated at upgrade; nil means JSON.
+	Codec Codec
+
+	// replay, when set, sends recorded messages after since on register.
+	replay bool
+	since  uint64
 }
 
 type WebsocketMessage struct {
@@ -40,6 +51,20 @@ type Hub struct {
 	Register   chan *WebsocketClient
 	Unregister chan *WebsocketClient
 	Mu         sync.Mutex
+
+	// PubSub carries broadcasts between server instances. Nil means
+	// in-memory only; set it before calling Run.
+	PubSub PubSub
+
+	disconnectMu sync.Mutex
+	onDisconnect []func(room, id string)
+
+	// room history, guarded by Mu (see history.go)
+	seqs          map[string]uint64
+	historySize   map[string]int
+	roomNamespace map[string]string
+	history       map[string]*roomHistory
+	emptied       map[string]time.Time // when rooms with history last emptied
 }
 
 var WsHub = Hub{
@@ -67,10 +92,41 @@ var Upgrader = websocket.Upgrader{
 		// Default production restriction
 		return origin == "https://noremac.dev"
 	},
+	// server preference order; see codec.go
+	Subprotocols:    []string{ProtocolMsgpack, ProtocolBinary, ProtocolJSON},
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
 }
 
+// NewHub builds a hub backed by ps (in-memory when nil).
+func NewHub(ps PubSub) *Hub {
+	return &Hub{
+		Rooms:      make(map[string]map[*WebsocketClient]bool),
+		Clients:    make(map[*WebsocketClient]bool),
+		Broadcast:  make(chan WebsocketMessage),
+		Register:   make(chan *WebsocketClient),
+		Unregister: make(chan *WebsocketClient),
+		PubSub:     ps,
+	}
+}
+
+// OnDisconnect registers fn to run when the last connection of a client id
+// leaves a room, whether it closed cleanly or timed out.
+func (h *Hub) OnDisconnect(fn func(room, id string)) {
+	h.disconnectMu.Lock()
+	defer h.disconnectMu.Unlock()
+	h.onDisconnect = append(h.onDisconnect, fn)
+}
+
+func (h *Hub) emitDisconnect(room, id string) {
+	h.disconnectMu.Lock()
+	hooks := h.onDisconnect
+	h.disconnectMu.Unlock()
+	for _, fn := range hooks {
+		go fn(room, id)
+	}
+}
+
 func NewCommandRegistry() *CommandRegistry {
 	return &CommandRegistry{
 		handlers: make(map[string]CommandFunc),
@@ -85,6 +141,23 @@ func (cr *CommandRegistry) RegisterWebsocket(command string, handler CommandFunc
 }
 
 func (h *Hub) Run() {
+	if h.PubSub == nil {
+		h.PubSub = NewMemoryPubSub()
+	}
+	deliver := make(chan WebsocketMessage, 256)
+	h.PubSub.Subscribe(func(msg WebsocketMessage) {
+		deliver <- msg
+	})
+	go func() {
+		for msg := range h.Broadcast {
+			if err := h.PubSub.Publish(msg); err != nil {
+				logError("publish failed", err, map[string]interface{}{"room": msg.Room})
+			}
+		}
+	}()
+	prune := time.NewTicker(time.Minute)
+	defer prune.Stop()
+
 	for {
 		select {
 		case client := <-h.Register:
@@ -93,53 +166,94 @@ func (h *Hub) Run() {
 				h.Rooms[client.Room] = make(map[*WebsocketClient]bool)
 			}
 			h.Rooms[client.Room][client] = true
+			if client.Namespace != "" {
+				h.initHistory()
+				h.roomNamespace[client.Room] = client.Namespace
+				delete(h.emptied, client.Room)
+			}
+			if client.replay {
+				h.replayLocked(client, client.since)
+			}
 			h.Mu.Unlock()
 
 		case client := <-h.Unregister:
 			h.Mu.Lock()
-			if clients, ok := h.Rooms[client.Room]; ok {
-				if _, exists := clients[client]; exists {
-					delete(clients, client)
-					close(client.Send)
-					if len(clients) == 0 {
-						delete(h.Rooms, client.Room)
-					}
-				}
-			}
+			gone := h.removeLocked(client)
 			h.Mu.Unlock()
+			if gone {
+				h.emitDisconnect(client.Room, client.Id)
+			}
+
+		case msg := <-deliver:
+			h.deliver(msg)
 
-		case msg := <-h.Broadcast:
+		case now := <-prune.C:
 			h.Mu.Lock()
-			if clients, ok := h.Rooms[msg.Room]; ok {
-				if msg.Id == "" {
-					for client := range clients {
-						select {
-						case client.Send <- msg.Content:
-						default:
-							close(client.Send)
-							delete(clients, client)
-						}
-					}
-				} else {
-					for client := range clients {
-						if client.Id == msg.Id {
-							fmt.Println("Broadcasting to client:", client.Id, "in room:", msg.Room)
-							select {
-							case client.Send <- msg.Content:
-							default:
-								close(client.Send)
-								delete(clients, client)
-							}
-							break
-						}
-					}
-				}
-			}
+			h.pruneHistory(now)
 			h.Mu.Unlock()
 		}
 	}
 }
 
+// removeLocked takes client out of its room and closes its Send channel,
+// reporting whether that was the last connection of its id. Callers hold
+// h.Mu.
+func (h *Hub) removeLocked(client *WebsocketClient) bool {
+	clients, ok := h.Rooms[client.Room]
+	if !ok {
+		return false
+	}
+	if _, exists := clients[client]; !exists {
+		return false
+	}
+	delete(clients, client)
+	close(client.Send)
+	gone := client.Id != ""
+	for other := range clients {
+		if other.Id == client.Id {
+			gone = false
+			break
+		}
+	}
+	if len(clients) == 0 {
+		delete(h.Rooms, client.Room)
+		h.emptiedLocked(client.Room, time.Now())
+	}
+	return gone
+}
+
+// deliver fans a message out to the clients of its room connected to this
+// instance. A client too slow to take it is dropped as if it had left.
+func (h *Hub) deliver(msg WebsocketMessage) {
+	h.Mu.Lock()
+	content := h.record(msg)
+	var slow []*WebsocketClient
+	for client := range h.Rooms[msg.Room] {
+		if msg.Id != "" && client.Id != msg.Id {
+			continue
+		}
+		select {
+		case client.Send <- content:
+		default:
+			slow = append(slow, client)
+		}
+		if msg.Id != "" {
+			break
+		}
+	}
+	var gone []*WebsocketClient
+	for _, client := range slow {
+		logInfo("client too slow; dropping it", map[string]interface{}{"room": client.Room, "id": client.Id})
+		if h.removeLocked(client) {
+			gone = append(gone, client)
+		}
+	}
+	h.Mu.Unlock()
+	for _, client := range gone {
+		h.emitDisconnect(client.Room, client.Id)
+	}
+}
+
 func (c *WebsocketClient) ReadPump() {
 	defer func() {
 		logInfo("client disconnected", map[string]interface{}{"room": c.Room})
@@ -148,15 +262,15 @@ func (c *WebsocketClient) ReadPump() {
 	}()
 
 	for {
-		_, message, err := c.Conn.ReadMessage()
+		mt, message, err := c.Conn.ReadMessage()
 		if err != nil {
 			logError("read error", err, map[string]interface{}{"room": c.Room})
 			break
 		}
 
-		var msgMap map[string]interface{}
-		if err := json.Unmarshal(message, &msgMap); err != nil {
-			logError("JSON unmarshal failed", err, map[string]interface{}{"raw": string(message)})
+		msgMap, err := c.codec().Decode(mt, message)
+		if err != nil {
+			logError("decode failed", err, map[string]interface{}{"raw": string(message)})
 			return
 		}
 
@@ -170,39 +284,89 @@ func (c *WebsocketClient) ReadPump() {
 			logError("type not string", nil, map[string]interface{}{"raw": string(message)})
 			continue
 		}
+		if typStr == "replay" {
+			since, _ := msgMap["since"].(float64)
+			WsHub.Replay(c, uint64(since))
+			continue
+		}
 		handler, ok := c.Registry.handlers[typStr]
 		if !ok {
 			logInfo("unknown command", map[string]interface{}{"cmd": typStr, "room": c.Room})
 			continue
 		}
-		strVal, _ := msgMap["from"].(string)
-		handler(strVal, &WsHub, msgMap)
+		handler(c.stampIdentity(msgMap), &WsHub, msgMap)
 	}
 }
 
+// stampIdentity overwrites the sender fields handlers read with the id the
+// client connected as, so a message can't act for another player, and
+// returns the sender. With auth off and no id on the connection the body is
+// left as sent.
+func (c *WebsocketClient) stampIdentity(msgMap map[string]interface{}) string {
+	if c.Id == "" && !AuthEnabled() {
+		from, _ := msgMap["from"].(string)
+		return from
+	}
+	msgMap["from"] = c.Id
+	if _, ok := msgMap["playerId"]; ok {
+		msgMap["playerId"] = c.Id
+	}
+	return c.Id
+}
+
+func (c *WebsocketClient) codec() Codec {
+	if c.Codec == nil {
+		return jsonCodec{}
+	}
+	return c.Codec
+}
+
 func (c *WebsocketClient) WritePump() {
+	ticker := time.NewTicker(PingPeriod())
 	defer func() {
+		ticker.Stop()
 		logInfo("WritePump closed", map[string]interface{}{"room": c.Room})
 		c.Conn.Close()
 	}()
 
-	for message := range c.Send {
-		err := c.Conn.WriteMessage(websocket.TextMessage, message)
-		if err != nil {
-			logError("write error", err, map[string]interface{}{"room": c.Room})
-			break
+	for {
+		select {
+		case message, ok := <-c.Send:
+			c.Conn.SetWriteDeadline(time.Now().Add(WriteWait))
+			if !ok {
+				c.Conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			mt, frame, err := c.codec().Encode(message)
+			if err != nil {
+				logError("encode error", err, map[string]interface{}{"room": c.Room})
+				continue
+			}
+			if err := c.Conn.WriteMessage(mt, frame); err != nil {
+				logError("write error", err, map[string]interface{}{"room": c.Room})
+				return
+			}
+		case <-ticker.C:
+			if err := Ping(c.Conn); err != nil {
+				logError("ping error", err, map[string]interface{}{"room": c.Room})
+				return
+			}
 		}
 	}
 }
 
 func WithWS(path string, mux *http.ServeMux, handler func(*websocket.Conn)) {
 	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
-		conn, err := Upgrader.Upgrade(w, r, nil)
+		conn, release, err := Upgrade(w, r, nil)
 		if err != nil {
 			log.Printf("WS upgrade %s → %v", path, err)
 			return
 		}
+		defer release()
 		log.Printf("WS %s connected", path)
+		done := make(chan struct{})
+		defer close(done)
+		go PingLoop(conn, done)
 		handler(conn) // delegate to feature‑specific logic
 	})
 }
@@ -210,18 +374,30 @@ func WithWS(path string, mux *http.ServeMux, handler func(*websocket.Conn)) {
 func CreateWebsocket(registry *CommandRegistry) func(http.ResponseWriter, *http.Request) {
 	return func(w http.ResponseWriter, r *http.Request) {
 		room := r.URL.Query().Get("room")
-		playerId := r.URL.Query().Get("playerId")
-		conn, err := Upgrader.Upgrade(w, r, nil)
+		playerId, err := Authorize(r, room, r.URL.Query().Get("playerId"))
 		if err != nil {
+			logInfo("websocket auth refused", map[string]interface{}{"room": room, "err": err.Error()})
+			http.Error(w, err.Error(), http.StatusUnauthorized)
 			return
 		}
+		conn, release, err := Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer release()
 		defer conn.Close()
 		client := &WebsocketClient{
-			Conn:     conn,
-			Send:     make(chan []byte, 256),
-			Registry: registry,
-			Room:     room,
-			Id:       playerId,
+			Conn:      conn,
+			Send:      make(chan []byte, 256),
+			Registry:  registry,
+			Room:      room,
+			Id:        playerId,
+			Namespace: r.URL.Path,
+			Codec:     CodecFor(conn.Subprotocol()),
+		}
+		if since, err := strconv.ParseUint(r.URL.Query().Get("since"), 10, 64); err == nil {
+			client.replay = true
+			client.since = since
 		}
 		WsHub.Register <- client
 		go client.WritePump()
@@ -0,0 +1,153 @@
+package websocket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestDisconnectAfterLastConnection(t *testing.T) {
+	hub := NewHub(nil)
+	go hub.Run()
+
+	left := make(chan string, 2)
+	hub.OnDisconnect(func(room, id string) { left <- room + "/" + id })
+
+	tab1 := &WebsocketClient{Send: make(chan []byte, 1), Room: "lobby", Id: "p1"}
+	tab2 := &WebsocketClient{Send: make(chan []byte, 1), Room: "lobby", Id: "p1"}
+	hub.Register <- tab1
+	hub.Register <- tab2
+
+	hub.Unregister <- tab1
+	select {
+	case got := <-left:
+		t.Fatalf("disconnect fired with a tab still open: %s", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	hub.Unregister <- tab2
+	select {
+	case got := <-left:
+		if got != "lobby/p1" {
+			t.Fatalf("got %q", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no disconnect event")
+	}
+}
+
+func TestSlowClientDisconnects(t *testing.T) {
+	hub := NewHub(nil)
+	go hub.Run()
+
+	left := make(chan string, 1)
+	hub.OnDisconnect(func(room, id string) { left <- room + "/" + id })
+
+	slow := &WebsocketClient{Send: make(chan []byte), Room: "lobby", Id: "p1"}
+	hub.Register <- slow
+	hub.Broadcast <- WebsocketMessage{Room: "lobby", Content: []byte(`{}`)}
+	select {
+	case got := <-left:
+		if got != "lobby/p1" {
+			t.Fatalf("got %q", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("dropping a slow client fired no disconnect event")
+	}
+	if _, open := <-slow.Send; open {
+		t.Error("slow client's Send left open")
+	}
+}
+
+func TestConnectionCapPerIP(t *testing.T) {
+	defer func(n int) { MaxConnsPerIP = n }(MaxConnsPerIP)
+	MaxConnsPerIP = 1
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, release, err := Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer release()
+		defer conn.Close()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	first, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("second dial should be refused, err=%v", err)
+	}
+
+	first.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		c, _, err := websocket.DefaultDialer.Dial(url, nil)
+		if err == nil {
+			c.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("slot not released: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	defer func() { TrustedProxies = nil }()
+	req := func(remote, fwd, real string) *http.Request {
+		r := httptest.NewRequest("GET", "/ws/hub", nil)
+		r.RemoteAddr = remote
+		if fwd != "" {
+			r.Header.Set("X-Forwarded-For", fwd)
+		}
+		if real != "" {
+			r.Header.Set("X-Real-IP", real)
+		}
+		return r
+	}
+
+	// without trusted proxies the headers are ignored
+	if ip := clientIP(req("203.0.113.9:5000", "1.2.3.4", "5.6.7.8")); ip != "203.0.113.9" {
+		t.Errorf("untrusted: %s", ip)
+	}
+
+	proxies, err := ParseProxies("127.0.0.1, 10.0.0.0/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	TrustedProxies = proxies
+	cases := []struct {
+		remote, fwd, real, want string
+	}{
+		{"127.0.0.1:5000", "1.2.3.4", "", "1.2.3.4"},
+		// a client's own X-Forwarded-For entry is to the left of the real one
+		{"127.0.0.1:5000", "6.6.6.6, 1.2.3.4, 10.1.2.3", "", "1.2.3.4"},
+		{"127.0.0.1:5000", "", "5.6.7.8", "5.6.7.8"},
+		{"127.0.0.1:5000", "", "", "127.0.0.1"},
+		{"203.0.113.9:5000", "1.2.3.4", "", "203.0.113.9"},
+	}
+	for _, c := range cases {
+		if ip := clientIP(req(c.remote, c.fwd, c.real)); ip != c.want {
+			t.Errorf("clientIP(%s, %q, %q) = %s, want %s", c.remote, c.fwd, c.real, ip, c.want)
+		}
+	}
+
+	if _, err := ParseProxies("not-an-ip"); err == nil {
+		t.Error("parsed a bad proxy")
+	}
+}